@@ -3,6 +3,8 @@
 package config
 
 import (
+	"encoding/json"
+
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
 )
 
@@ -27,6 +29,9 @@ type KeyVisualConfig struct {
 	AutoCollectionDisabled bool   `json:"auto_collection_disabled"`
 	Policy                 string `json:"policy"`
 	PolicyKVSeparator      string `json:"policy_kv_separator"`
+	// LabelStrategy holds the tunables of the label strategy of the db policy, as a JSON object of
+	// decorator.LabelStrategyConfig. The fields omitted take their defaults.
+	LabelStrategy json.RawMessage `json:"label_strategy,omitempty" swaggertype:"object"`
 }
 
 func (c *KeyVisualConfig) validatePolicy() error {
@@ -71,6 +76,9 @@ func (c *DynamicConfig) Clone() *DynamicConfig {
 	newCfg := *c
 	newCfg.Profiling.AutoCollectionTargets = make([]model.RequestTargetNode, len(c.Profiling.AutoCollectionTargets))
 	copy(newCfg.Profiling.AutoCollectionTargets, c.Profiling.AutoCollectionTargets)
+	if c.KeyVisual.LabelStrategy != nil {
+		newCfg.KeyVisual.LabelStrategy = append(json.RawMessage(nil), c.KeyVisual.LabelStrategy...)
+	}
 	return &newCfg
 }
 
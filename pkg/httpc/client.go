@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/joomcode/errorx"
@@ -22,6 +23,11 @@ const (
 	defaultTimeout = time.Second * 10
 )
 
+var (
+	propStatusCode = errorx.RegisterProperty("status_code")
+	propRetryAfter = errorx.RegisterProperty("retry_after")
+)
+
 type Client struct {
 	http.Client
 
@@ -66,6 +72,12 @@ func (c Client) WithTimeout(timeout time.Duration) *Client {
 	return &c
 }
 
+// WithCheckRedirect returns a client deciding by f whether to follow a redirect, see http.Client.CheckRedirect.
+func (c Client) WithCheckRedirect(f func(req *http.Request, via []*http.Request) error) *Client {
+	c.CheckRedirect = f
+	return &c
+}
+
 func (c *Client) CloneAndAddRequestHeader(key, value string) *Client {
 	cc := c.Clone()
 	if cc.header == nil {
@@ -117,7 +129,11 @@ func (c *Client) Send(
 	if !(resp.StatusCode >= 200 && resp.StatusCode < 300) {
 		defer resp.Body.Close()
 		data, _ := io.ReadAll(resp.Body)
-		e := errType.New("Request failed with status code %d from %s API: %s", resp.StatusCode, errOriginComponent, string(data))
+		e := errType.New("Request failed with status code %d from %s API: %s", resp.StatusCode, errOriginComponent, string(data)).
+			WithProperty(propStatusCode, resp.StatusCode)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			e = e.WithProperty(propRetryAfter, retryAfter)
+		}
 		log.Warn("SendRequest failed", zap.String("uri", uri), zap.Error(err))
 		return nil, e
 	}
@@ -125,6 +141,57 @@ func (c *Client) Send(
 	return &Response{resp}, nil
 }
 
+// parseRetryAfter parses the Retry-After header, which is either in seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func extractProperty(err error, prop errorx.Property) (interface{}, bool) {
+	for cause := err; cause != nil; {
+		ex := errorx.Cast(cause)
+		if ex == nil {
+			break
+		}
+		if v, ok := ex.Property(prop); ok {
+			return v, true
+		}
+		cause = ex.Cause()
+	}
+	return nil, false
+}
+
+// StatusCodeOf returns the HTTP status code of the failed request causing err, or 0 if there is none.
+func StatusCodeOf(err error) int {
+	if v, ok := extractProperty(err, propStatusCode); ok {
+		return v.(int)
+	}
+	return 0
+}
+
+// RetryAfterOf returns the delay asked by the Retry-After header of the failed request causing err.
+func RetryAfterOf(err error) (time.Duration, bool) {
+	if v, ok := extractProperty(err, propRetryAfter); ok {
+		return v.(time.Duration), true
+	}
+	return 0, false
+}
+
 type Response struct {
 	*http.Response
 }
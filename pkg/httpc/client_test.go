@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/joomcode/errorx"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/fx/fxtest"
 
@@ -65,3 +67,30 @@ func Test_Send_withHeader(t *testing.T) {
 	d3, _ := resp3.Body()
 	require.Equal(t, "", string(d3))
 }
+
+func Test_Send_errorProperties(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/busy" {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	errType := errorx.CommonErrors.NewType("test_request_failed")
+	c := newTestClient(t)
+	_, err := c.Send(context.Background(), ts.URL+"/busy", http.MethodGet, nil, errType, "test")
+	require.Error(t, err)
+	require.Equal(t, http.StatusTooManyRequests, StatusCodeOf(errorx.Decorate(err, "wrapped")))
+	retryAfter, ok := RetryAfterOf(err)
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, retryAfter)
+
+	_, err = c.Send(context.Background(), ts.URL+"/missing", http.MethodGet, nil, errType, "test")
+	require.Equal(t, http.StatusNotFound, StatusCodeOf(err))
+	_, ok = RetryAfterOf(err)
+	require.False(t, ok)
+	require.Equal(t, 0, StatusCodeOf(errType.New("no response")))
+}
@@ -0,0 +1,455 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package keyvisual
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	apiutils "github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/decorator"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+type DecoratorStatusResponse struct {
+	// Ready is whether the cold sync has completed, after which the keys are labeled with the table names.
+	// It is always true if the label strategy does not resolve tables.
+	Ready bool `json:"ready"`
+	// Partial is whether the label strategy is ready with part of the tables only, as the cold sync has missed
+	// its deadline. The keys of the tables are labeled `partial` until a schema sync succeeds.
+	Partial bool `json:"partial"`
+	// Paused is whether the schema syncs are paused by the pause API.
+	Paused bool `json:"paused"`
+	// LastError is the error of the last schema sync, or null if it succeeded.
+	LastError *rest.ErrorResponse `json:"last_error"`
+	// History is the outcomes of the recent schema syncs, the latest first.
+	History []DecoratorSyncRecord `json:"history"`
+	// Config is the config the label strategy runs with, or null if it is not configurable.
+	Config *decorator.LabelStrategyConfig `json:"config"`
+}
+
+// DecoratorSyncRecord is the outcome of a schema sync.
+type DecoratorSyncRecord struct {
+	decorator.SyncResult
+	// Error is the error of the sync, or null if it succeeded.
+	Error *rest.ErrorResponse `json:"error"`
+}
+
+// resolverProvider is implemented by the label strategies that resolve tables.
+type resolverProvider interface {
+	Resolver() *decorator.TableResolver
+}
+
+// configProvider is implemented by the label strategies that are created from a LabelStrategyConfig.
+type configProvider interface {
+	EffectiveConfig() decorator.LabelStrategyConfig
+}
+
+// tableResolver returns the TableResolver of the running label strategy,
+// or nil if the label strategy does not resolve tables.
+func (s *Service) tableResolver() *decorator.TableResolver {
+	if r, ok := s.labelStrategy.(resolverProvider); ok {
+		return r.Resolver()
+	}
+	return nil
+}
+
+// @Summary Get the status of the key visual label decorator
+// @Success 200 {object} DecoratorStatusResponse
+// @Router /keyvisual/decorator/status [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getDecoratorStatus(c *gin.Context) {
+	resp := DecoratorStatusResponse{Ready: true, History: []DecoratorSyncRecord{}}
+	if resolver := s.tableResolver(); resolver != nil {
+		select {
+		case <-resolver.ColdSynced():
+		default:
+			resp.Ready = false
+		}
+		resp.Partial = resolver.Partial()
+		resp.Paused = resolver.Paused()
+		if err := resolver.LastError(); err != nil {
+			errResp := rest.NewErrorResponse(err)
+			resp.LastError = &errResp
+		}
+		for _, result := range resolver.SyncHistory() {
+			record := DecoratorSyncRecord{SyncResult: result}
+			if result.Err != nil {
+				errResp := rest.NewErrorResponse(result.Err)
+				record.Error = &errResp
+			}
+			resp.History = append(resp.History, record)
+		}
+	}
+	if p, ok := s.labelStrategy.(configProvider); ok {
+		cfg := p.EffectiveConfig()
+		resp.Config = &cfg
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Pause the schema syncs of the key visual label decorator
+// @Description No request is sent to TiDB until the syncs are resumed, while the keys are still labeled with the tables resolved so far. A schema sync already running is not interrupted.
+// @Success 204 "No Content"
+// @Router /keyvisual/decorator/pause [post]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) pauseDecorator(c *gin.Context) {
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	resolver.Pause()
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Resume the schema syncs of the key visual label decorator paused before
+// @Description The next schema sync only fetches the tables again if the schema version has changed since the pause.
+// @Success 204 "No Content"
+// @Router /keyvisual/decorator/resume [post]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) resumeDecorator(c *gin.Context) {
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	resolver.Resume()
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Look up the tables by the label shown in Key Visualizer
+// @Param label query string true "The label in the `db.table` or `db.table/partition` form, prefixed by `cluster.` if the decorator is tagged with a cluster"
+// @Success 200 {array} decorator.TableInfo
+// @Router /keyvisual/decorator/tables [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) lookupDecoratorTables(c *gin.Context) {
+	label := c.Query("label")
+	if label == "" {
+		rest.Error(c, rest.ErrBadRequest.New("Label is required"))
+		return
+	}
+	infos := []decorator.TableInfo{}
+	if resolver := s.tableResolver(); resolver != nil {
+		infos = append(infos, resolver.LookupLabel(label)...)
+	}
+	c.JSON(http.StatusOK, infos)
+}
+
+// maxDecoratorResolveIDs bounds the IDs resolved by a request.
+const maxDecoratorResolveIDs = 10000
+
+// DecoratorResolveRequest is a batch of table IDs to resolve.
+type DecoratorResolveRequest struct {
+	// Cluster, if not empty, must be the cluster the decorator is tagged with, so that a table ID is never
+	// resolved against the tables of another cluster.
+	Cluster string  `json:"cluster"`
+	IDs     []int64 `json:"ids" binding:"required"`
+}
+
+// @Summary Resolve a batch of table IDs by the tables synced by the key visual label decorator
+// @Description For the other services of the dashboard to share the synced tables instead of syncing their own. The IDs of partitions are resolved as well.
+// @Param request body DecoratorResolveRequest true "Request body"
+// @Success 200 {object} decorator.ResolvedTables
+// @Router /keyvisual/decorator/resolve [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) resolveDecoratorTables(c *gin.Context) {
+	var req DecoratorResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if len(req.IDs) > maxDecoratorResolveIDs {
+		rest.Error(c, rest.ErrBadRequest.New("Expect at most %d IDs", maxDecoratorResolveIDs))
+		return
+	}
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	if req.Cluster != "" && req.Cluster != resolver.Cluster {
+		rest.Error(c, rest.ErrNotFound.New("Cluster %q is not resolved by the decorator", req.Cluster))
+		return
+	}
+	c.JSON(http.StatusOK, resolver.ResolveIDs(req.IDs))
+}
+
+// @Summary Drop a table left behind in the key visual label decorator
+// @Description The table, or partition, is removed with all its partitions. A table still in TiDB is added back by the next schema sync of a new schema version.
+// @Param id path int true "The table ID"
+// @Success 200 {array} int64 "The IDs removed"
+// @Router /keyvisual/decorator/tables/{id} [delete]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) dropDecoratorTable(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("Invalid table ID"))
+		return
+	}
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	ids := resolver.DropTable(id)
+	if len(ids) == 0 {
+		rest.Error(c, rest.ErrNotFound.New("Table %d is not resolved", id))
+		return
+	}
+	c.JSON(http.StatusOK, ids)
+}
+
+// @Summary Resolve the partitions of a table, marking the ones involved by partition pruning
+// @Description The targets, e.g. the partitions left by the partition pruning of a query plan, and their subpartitions are marked as involved, and the other partitions as not involved. The targets which are not partitions of the table are listed as unknown.
+// @Param id path int true "The ID of the partitioned table, or of one of its partitions"
+// @Param targets query string false "The comma separated IDs of the target partitions"
+// @Success 200 {object} decorator.PartitionPruning
+// @Router /keyvisual/decorator/tables/{id}/partitions [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) resolveDecoratorPartitions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("Invalid table ID"))
+		return
+	}
+	var targets []int64
+	if v := c.Query("targets"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			target, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil {
+				rest.Error(c, rest.ErrBadRequest.New("Invalid target partition ID %q", field))
+				return
+			}
+			targets = append(targets, target)
+		}
+	}
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	pruning, ok := resolver.ResolvePartitions(id, targets)
+	if !ok {
+		rest.Error(c, rest.ErrNotFound.New("Table %d is not resolved", id))
+		return
+	}
+	c.JSON(http.StatusOK, pruning)
+}
+
+// @Summary Check that a row key of a table is decoded and labeled with the table
+// @Description A smoke test of the key decoding and the table lookup of the key visual label decorator which needs no live data, e.g. after a deployment. The result tells whether the synthesized row key of the table is labeled with its `db.table`.
+// @Param id path int true "The table ID"
+// @Success 200 {object} decorator.SelfTestResult
+// @Router /keyvisual/decorator/tables/{id}/self_test [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) selfTestDecorator(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("Invalid table ID"))
+		return
+	}
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	c.JSON(http.StatusOK, resolver.SelfTest(id))
+}
+
+const defaultHotTablesLimit = 10
+
+// @Summary Get the tables looked up the most by the key visual label decorator
+// @Description Only the sampled lookups are counted, see `LookupSampleRate`. The result is empty if sampling is disabled.
+// @Param limit query int false "The number of tables to return, defaults to 10"
+// @Success 200 {array} decorator.LookupSample
+// @Router /keyvisual/decorator/hot_tables [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getDecoratorHotTables(c *gin.Context) {
+	limit := defaultHotTablesLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			rest.Error(c, rest.ErrBadRequest.New("Invalid limit"))
+			return
+		}
+		limit = n
+	}
+	samples := []decorator.LookupSample{}
+	if resolver := s.tableResolver(); resolver != nil {
+		samples = append(samples, resolver.HotTables(limit)...)
+	}
+	c.JSON(http.StatusOK, samples)
+}
+
+// @Summary Export the state of the key visual label decorator as a support bundle
+// @Description The bundle contains the schema versions, the last sync result, the tunables and all resolved tables. Tokens are never included. The tables are never half updated by a concurrent schema sync: they are all from before or all from after it, unless streaming apply is enabled.
+// @Success 200 {object} decorator.SupportBundle
+// @Router /keyvisual/decorator/support_bundle [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getDecoratorSupportBundle(c *gin.Context) {
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	c.JSON(http.StatusOK, resolver.SupportBundle(c.Request.Context()))
+}
+
+// @Summary Export the tables resolved by the key visual label decorator as a binary snapshot
+// @Description The snapshot can be compared with the tables at a later time by the snapshot diff API.
+// @Produce application/octet-stream
+// @Success 200 {string} string "The binary snapshot"
+// @Router /keyvisual/decorator/snapshot [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) getDecoratorSnapshot(c *gin.Context) {
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	c.Data(http.StatusOK, "application/octet-stream", resolver.Snapshot())
+}
+
+// maxDecoratorSnapshotSize bounds the snapshot uploaded to be diffed.
+const maxDecoratorSnapshotSize = 512 << 20
+
+// @Summary Diff a snapshot exported earlier against the tables resolved by the key visual label decorator now
+// @Description A table renamed keeps its ID, while a table replaced, e.g. by TRUNCATE TABLE, is both removed and added.
+// @Accept application/octet-stream
+// @Param snapshot body string true "The binary snapshot exported earlier"
+// @Success 200 {object} decorator.SnapshotDiff
+// @Router /keyvisual/decorator/snapshot/diff [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) diffDecoratorSnapshot(c *gin.Context) {
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	older, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxDecoratorSnapshotSize))
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.Wrap(err, "Failed to read the snapshot"))
+		return
+	}
+	diff, err := decorator.DiffSnapshots(older, resolver.Snapshot())
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.Wrap(err, "Invalid snapshot"))
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// sqlTableRow is a row of `INFORMATION_SCHEMA.TABLES` or `INFORMATION_SCHEMA.PARTITIONS`.
+type sqlTableRow struct {
+	ID        int64  `gorm:"column:ID"`
+	DB        string `gorm:"column:TABLE_SCHEMA"`
+	Name      string `gorm:"column:TABLE_NAME"`
+	Partition string `gorm:"column:PARTITION_NAME"`
+}
+
+// @Summary Check the tables resolved by the key visual label decorator against the tables listed by SQL
+// @Description The table IDs and names in `INFORMATION_SCHEMA.TABLES` and `INFORMATION_SCHEMA.PARTITIONS` are compared with the ones resolved from the TiDB status API, which may disagree, e.g. during an upgrade. It is an on-demand diagnostic, which queries all tables. The tables changed by a DDL after the last schema sync show up as discrepancies until the next schema sync.
+// @Success 200 {object} decorator.SQLCheckReport
+// @Router /keyvisual/decorator/sql_check [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) checkDecoratorAgainstSQL(c *gin.Context) {
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	db := apiutils.GetTiDBConnection(c)
+	var tableRows, partitionRows []sqlTableRow
+	err := db.Raw("SELECT TIDB_TABLE_ID AS ID, TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES").
+		Scan(&tableRows).Error
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	err = db.Raw("SELECT TIDB_PARTITION_ID AS ID, TABLE_SCHEMA, TABLE_NAME, PARTITION_NAME " +
+		"FROM INFORMATION_SCHEMA.PARTITIONS WHERE TIDB_PARTITION_ID IS NOT NULL").
+		Scan(&partitionRows).Error
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	tables := make([]decorator.SQLTable, 0, len(tableRows)+len(partitionRows))
+	for _, row := range append(tableRows, partitionRows...) {
+		tables = append(tables, decorator.SQLTable{ID: row.ID, DB: row.DB, Name: row.Name, Partition: row.Partition})
+	}
+	c.JSON(http.StatusOK, resolver.CheckAgainstSQL(tables))
+}
+
+// decoratorChangesBuffer is the number of changes buffered for a client of the change stream, before it is
+// disconnected for being too slow.
+const decoratorChangesBuffer = 16
+
+// @Summary Stream the changes of the tables resolved by the key visual label decorator
+// @Description Server-sent events, each named `change` with a decorator.TableMapChange, are pushed after each schema sync changing the tables. A client falling behind is disconnected, and should reload the tables before reconnecting.
+// @Produce text/event-stream
+// @Success 200 {object} decorator.TableMapChange
+// @Router /keyvisual/decorator/changes [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+func (s *Service) streamDecoratorChanges(c *gin.Context) {
+	resolver := s.tableResolver()
+	if resolver == nil {
+		rest.Error(c, rest.ErrNotFound.New("The label strategy does not resolve tables"))
+		return
+	}
+	changes, unsubscribe := resolver.SubscribeChanges(decoratorChangesBuffer)
+	defer unsubscribe()
+	c.Stream(func(io.Writer) bool {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				return false
+			}
+			c.SSEvent("change", change)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
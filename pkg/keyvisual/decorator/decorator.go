@@ -5,6 +5,8 @@ package decorator
 
 import (
 	"encoding/hex"
+	"fmt"
+	"strings"
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 )
@@ -30,6 +32,34 @@ type Labeler interface {
 	Label(keys []string) []LabelKey
 }
 
+// RegionLabel is the label of a region, made of the labels of its start and end keys.
+type RegionLabel struct {
+	Start LabelKey `json:"start"`
+	End   LabelKey `json:"end"`
+	// Labels are the labels shared by both keys if they are in the same logical range, e.g. `db, table` for
+	// two row keys of a table. Otherwise it is a single `spans X..Y` label, where X and Y are the labels of the
+	// keys joined by `.`.
+	Labels []string `json:"labels"`
+}
+
+// LabelRegion labels the start and end keys of a region with one Label call, and combines them into the label
+// of the region.
+func LabelRegion(labeler Labeler, startKey, endKey string) RegionLabel {
+	keys := labeler.Label([]string{startKey, endKey})
+	label := RegionLabel{Start: keys[0], End: keys[1]}
+	if labeler.CrossBorder(startKey, endKey) {
+		label.Labels = []string{fmt.Sprintf("spans %s..%s",
+			strings.Join(label.Start.Labels, "."), strings.Join(label.End.Labels, "."))}
+		return label
+	}
+	n := 0
+	for n < len(label.Start.Labels) && n < len(label.End.Labels) && label.Start.Labels[n] == label.End.Labels[n] {
+		n++
+	}
+	label.Labels = append([]string{}, label.Start.Labels[:n]...)
+	return label
+}
+
 // NaiveLabelStrategy is one of the simplest LabelStrategy.
 func NaiveLabelStrategy() LabelStrategy {
 	return naiveLabelStrategy{}
@@ -6,28 +6,56 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/fx"
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
 )
 
 // TiDBLabelStrategy implements the LabelStrategy interface. It obtains Label Information from TiDB.
-func TiDBLabelStrategy(lc fx.Lifecycle, wg *sync.WaitGroup, etcdClient *clientv3.Client, tidbClient *tidb.Client) LabelStrategy {
+// It returns ErrInvalidConfig if cfg does not pass LabelStrategyConfig.Validate. snapshotStore, if not nil,
+// keeps the tables across restarts.
+func TiDBLabelStrategy(
+	lc fx.Lifecycle,
+	wg *sync.WaitGroup,
+	cfg LabelStrategyConfig,
+	etcdClient *clientv3.Client,
+	tidbClient *tidb.Client,
+	pdClient *pd.Client,
+	snapshotStore SnapshotStore,
+) (LabelStrategy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	s := &tidbLabelStrategy{
-		EtcdClient:    etcdClient,
-		tidbClient:    tidbClient,
-		SchemaVersion: -1,
+		TableResolver:         NewTableResolver(etcdClient, tidbClient),
+		pdClient:              pdClient,
+		NewKeyDecoder:         NewTiDBKeyDecoder,
+		labelCache:            newLabelCache(cfg.LabelCacheSize),
+		RegionLabels:          cfg.RegionLabels,
+		PKLabels:              cfg.PKLabels,
+		ResourceControlLabels: cfg.ResourceControlLabels,
+		BindingLabels:         cfg.BindingLabels,
+		HandleLabels:          cfg.HandleLabels,
+		PartitionBoundLabels:  cfg.PartitionBoundLabels,
+		GroupPartitions:       cfg.GroupPartitions,
+		IndexLabelTables:      append([]string(nil), cfg.IndexLabelTables...),
+		UnresolvedLabelFormat: cfg.UnresolvedLabelFormat,
 	}
+	s.applyConfig(cfg)
+	s.SnapshotStore = snapshotStore
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
+			ctx, s.cancel = context.WithCancel(ctx)
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
@@ -35,71 +63,281 @@ func TiDBLabelStrategy(lc fx.Lifecycle, wg *sync.WaitGroup, etcdClient *clientv3
 			}()
 			return nil
 		},
+		OnStop: func(context.Context) error {
+			return s.Close()
+		},
 	})
 
-	return s
-}
-
-type tableDetail struct {
-	Name    string
-	DB      string
-	ID      int64
-	Indices map[int64]string
+	return s, nil
 }
 
 type tidbLabelStrategy struct {
-	Config     *config.Config
-	EtcdClient *clientv3.Client
+	*TableResolver
+
+	Config      *config.Config
+	TidbAddress []string
+	pdClient    pdAPIClient
+	// cancel stops the background started by the lifecycle.
+	cancel context.CancelFunc
+
+	// regionLabels holds the []*regionLabelRange fetched from PD.
+	regionLabels atomic.Value
+	// labelCache is shared by the Labelers, or nil to decode each key.
+	labelCache *labelCache
 
-	TableMap      sync.Map
-	tidbClient    *tidb.Client
-	SchemaVersion int64
-	TidbAddress   []string
+	// NewKeyDecoder creates the KeyDecoder for each Labeler. Defaults to the standard TiDB codec.
+	NewKeyDecoder func() KeyDecoder
+	// RegionLabels annotates keys with the PD region labels whose key range contains them.
+	RegionLabels bool
+	// PKLabels annotates the row keys of clustered tables with their handle columns, e.g. `PK(a,b)`.
+	PKLabels bool
+	// ResourceControlLabels annotates the keys of the resource control tables, e.g. the runaway query watches
+	// in `mysql.tidb_runaway_watch`, with `resource_control`, to tell them from the other system tables. Like
+	// all system tables, they are not resolved under HiddenTablesSkip.
+	ResourceControlLabels bool
+	// BindingLabels annotates the keys of the SQL plan binding tables, e.g. `mysql.bind_info`, with
+	// `sql_binding`, for debugging the contention of creating and capturing bindings. Like all system tables,
+	// they are not resolved under HiddenTablesSkip.
+	BindingLabels bool
+	// HandleLabels annotates the row keys with the kind of their handle, `clustered` if the rows are keyed by
+	// the primary key, or `_tidb_rowid` if they are keyed by the implicit row ID. The hotspots of the two
+	// differ, e.g. `_tidb_rowid` grows monotonically unless SHARD_ROW_ID_BITS is set.
+	HandleLabels bool
+	// PartitionBoundLabels annotates the keys of the partitions of range partitioned tables with the value
+	// ranges of the partitions, e.g. `RANGE[10,20)`, so that a hot partition can be mapped to the data range it
+	// holds. It has no effect under GroupPartitions.
+	PartitionBoundLabels bool
+	// GroupPartitions labels the keys of all partitions of a table as the table, so that the partitions, e.g.
+	// of a hash partitioned table, are shown as one range. It must be set before Run.
+	GroupPartitions bool
+	// IndexLabelTables, if not empty, are the tables in the `db.table` form whose index keys are labeled by
+	// index, matched case-insensitively against the display names. The index keys of the other tables are
+	// labeled `indexes`, so that the indexes of a wide table are shown as one range. The partitions follow
+	// their tables. It must be set before Run, as the cached labels are not dropped when it changes.
+	IndexLabelTables []string
+	// UnresolvedLabelFormat is the label of the keys whose table is not found in TableMap. A `%d` verb in it
+	// is replaced by the decoded table ID. Defaults to `table_%d`. It must be set before Run, as the cached
+	// labels are not dropped when it changes.
+	UnresolvedLabelFormat string
+	// LabelFormatter renders the labels of the tables, indexes and rows. Defaults to DefaultLabelFormatter if
+	// nil. It must be set before Run, as the cached labels are not dropped when it changes. An embedder holding
+	// the strategy as a LabelStrategy sets it by SetLabelFormatter.
+	LabelFormatter LabelFormatter
 }
 
+const defaultUnresolvedLabelFormat = "table_%d"
+
 type tidbLabeler struct {
-	TableMap *sync.Map
-	Buffer   model.KeyInfoBuffer
+	// Cluster, if not empty, is the first label of every key, see TableResolver.Cluster.
+	Cluster               string
+	TableMap              tableLookup
+	Decoder               KeyDecoder
+	RegionLabels          []*regionLabelRange
+	PKLabels              bool
+	ResourceControlLabels bool
+	BindingLabels         bool
+	HandleLabels          bool
+	PartitionBoundLabels  bool
+	GroupPartitions       bool
+	// IndexLabelTables, if not nil, are the lower case `db.table` names of the tables labeled by index.
+	IndexLabelTables map[string]struct{}
+	// Partial tags the keys of the tables with partialLabel.
+	Partial bool
+	// KeyIndex, if not nil, resolves the table IDs missing in TableMap by their key ranges, i.e. the skipped
+	// partitions of SkipPartitions to their tables.
+	KeyIndex *tableKeyIndex
+	// UnresolvedLabelFormat defaults to defaultUnresolvedLabelFormat, see tidbLabelStrategy.
+	UnresolvedLabelFormat string
+	// Formatter defaults to DefaultLabelFormatter if nil.
+	Formatter LabelFormatter
+	// OnMiss is called with the table IDs not found in TableMap, if not nil.
+	OnMiss func(tableID int64)
+	// OnLookup is called with the table ID of each table key, if not nil.
+	OnLookup func(tableID int64)
+	// Cache, if not nil, caches the labels computed against the TableMap generation CacheGen.
+	Cache    *labelCache
+	CacheGen int64
+}
+
+// SetLabelFormatter sets the LabelFormatter of the strategy, for an embedder holding the strategy as a
+// LabelStrategy. It must be called before the lifecycle starts.
+func (s *tidbLabelStrategy) SetLabelFormatter(formatter LabelFormatter) {
+	s.LabelFormatter = formatter
+}
+
+// Resolver returns the underlying TableResolver, so that the resolved tables can be served elsewhere.
+func (s *tidbLabelStrategy) Resolver() *TableResolver {
+	return s.TableResolver
+}
+
+// EffectiveConfig returns the config the strategy runs with, including the tunables changed after it is
+// created. It holds no secrets, as the token is provided by TokenProvider, which is not configuration.
+func (s *tidbLabelStrategy) EffectiveConfig() LabelStrategyConfig {
+	cfg := s.currentConfig()
+	cfg.RegionLabels = s.RegionLabels
+	cfg.PKLabels = s.PKLabels
+	cfg.ResourceControlLabels = s.ResourceControlLabels
+	cfg.BindingLabels = s.BindingLabels
+	cfg.HandleLabels = s.HandleLabels
+	cfg.PartitionBoundLabels = s.PartitionBoundLabels
+	cfg.GroupPartitions = s.GroupPartitions
+	cfg.IndexLabelTables = append([]string(nil), s.IndexLabelTables...)
+	cfg.UnresolvedLabelFormat = s.UnresolvedLabelFormat
+	if s.labelCache != nil {
+		cfg.LabelCacheSize = s.labelCache.capacity
+	}
+	return cfg
+}
+
+// Close stops the background sync. The etcd, TiDB and PD clients are shared with the rest of the dashboard,
+// so they are left open. It is called when the lifecycle stops, and can be called more than once.
+func (s *tidbLabelStrategy) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
 }
 
 func (s *tidbLabelStrategy) ReloadConfig(cfg *config.KeyVisualConfig) {}
 
+// Background syncs the schema, and the PD region labels if enabled, until ctx is done.
 func (s *tidbLabelStrategy) Background(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			s.updateMap(ctx)
-		}
+	if !s.RegionLabels {
+		s.Run(ctx)
+		return
 	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Run(ctx)
+	}()
+	s.syncRegionLabels(ctx)
+	wg.Wait()
 }
 
 func (s *tidbLabelStrategy) NewLabeler() Labeler {
+	// Load the generation first, so that it is never newer than the tables labeled against. A snapshot carries
+	// its own generation, which is older than tableMapGen if the snapshot is the one kept while a sync is
+	// applied, so that the labels of the old tables are never cached as the ones of the new generation.
+	gen := s.tableMapGen.Load()
+	tables := s.tables()
+	if snapshot, ok := tables.(*tableSnapshot); ok {
+		gen = snapshot.gen
+	}
+	cache := s.labelCache
+	if gen < 0 {
+		// The empty snapshot served before the first one is built.
+		cache = nil
+	}
+	var keyIndex *tableKeyIndex
+	if s.SkipPartitions {
+		keyIndex = s.loadKeyIndex()
+	}
 	return &tidbLabeler{
-		TableMap: &s.TableMap,
+		Cache:                 cache,
+		CacheGen:              gen,
+		Cluster:               s.Cluster,
+		TableMap:              tables,
+		Decoder:               s.NewKeyDecoder(),
+		RegionLabels:          s.loadRegionLabels(),
+		PKLabels:              s.PKLabels,
+		ResourceControlLabels: s.ResourceControlLabels,
+		BindingLabels:         s.BindingLabels,
+		HandleLabels:          s.HandleLabels,
+		PartitionBoundLabels:  s.PartitionBoundLabels,
+		GroupPartitions:       s.GroupPartitions,
+		IndexLabelTables:      newIndexLabelTables(s.IndexLabelTables),
+		Partial:               s.Partial(),
+		KeyIndex:              keyIndex,
+		UnresolvedLabelFormat: s.UnresolvedLabelFormat,
+		Formatter:             s.LabelFormatter,
+		OnMiss:                s.recordMiss,
+		OnLookup:              s.recordLookup,
 	}
 }
 
 // CrossBorder does not allow cross tables or cross indexes within a table.
 func (e *tidbLabeler) CrossBorder(startKey, endKey string) bool {
-	startInfo, _ := e.Buffer.DecodeKey(region.Bytes(startKey))
-	startIsMeta, startTableID := startInfo.MetaOrTable()
-	startIndex := startInfo.IndexInfo()
+	startInfo := e.Decoder.DecodeKey(region.Bytes(startKey))
+	endInfo := e.Decoder.DecodeKey(region.Bytes(endKey))
 
-	endInfo, _ := e.Buffer.DecodeKey(region.Bytes(endKey))
-	endIsMeta, endTableID := endInfo.MetaOrTable()
-	endIndex := endInfo.IndexInfo()
+	if startInfo.IsMeta || endInfo.IsMeta {
+		return startInfo.IsMeta != endInfo.IsMeta
+	}
+	if startInfo.TableID != endInfo.TableID {
+		if !e.GroupPartitions || e.groupID(startInfo.TableID) != e.groupID(endInfo.TableID) {
+			return true
+		}
+	} else if startInfo.IndexID == endInfo.IndexID && startInfo.IsIndexTruncated == endInfo.IsIndexTruncated {
+		return false
+	}
+	// The partitions of a table share the indices of the table.
+	detail, _ := e.TableMap.Load(startInfo.TableID)
+	startIndexID, startIsIndex := indexIDOf(startInfo, detail)
+	endIndexID, endIsIndex := indexIDOf(endInfo, detail)
+	if startIsIndex && endIsIndex && !e.labelsByIndex(detail) {
+		return false
+	}
+	return startIsIndex != endIsIndex || startIndexID != endIndexID
+}
+
+// indexesLabel labels the index keys of the tables not in IndexLabelTables.
+const indexesLabel = "indexes"
 
-	if startIsMeta || endIsMeta {
-		return startIsMeta != endIsMeta
+func newIndexLabelTables(names []string) map[string]struct{} {
+	if len(names) == 0 {
+		return nil
+	}
+	tables := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		tables[strings.ToLower(name)] = struct{}{}
 	}
-	if startTableID != endTableID {
+	return tables
+}
+
+// labelsByIndex reports whether the index keys of the table, or of the table of a partition, are labeled by
+// index. The keys of an unresolved table are.
+func (e *tidbLabeler) labelsByIndex(detail *tableDetail) bool {
+	if e.IndexLabelTables == nil || detail == nil {
 		return true
 	}
-	return startIndex != endIndex
+	if detail.ParentID != 0 {
+		if parent, ok := e.TableMap.Load(detail.ParentID); ok {
+			detail = parent
+		}
+	}
+	_, ok := e.IndexLabelTables[strings.ToLower(detail.DB+"."+detail.Name)]
+	return ok
+}
+
+// groupID returns the ID of the partitioned table of a partition, or the ID itself otherwise.
+func (e *tidbLabeler) groupID(tableID int64) int64 {
+	if detail, ok := e.TableMap.Load(tableID); ok && detail.ParentID != 0 {
+		return detail.ParentID
+	}
+	return tableID
+}
+
+// indexIDOf returns the ID of the index whose key range [indexID, indexID+1) starts at or contains the key.
+// A truncated index ID is the lowest key of a span of index IDs, so it is resolved against the index set of
+// the table to the first index at or after it. It returns false if the key is not in any index range.
+func indexIDOf(info KeyInfo, detail *tableDetail) (int64, bool) {
+	if !info.IsIndexTruncated {
+		return info.IndexID, info.IndexID != 0
+	}
+	if detail == nil {
+		return 0, false
+	}
+	found := false
+	var indexID int64
+	for id := range detail.Indices {
+		if id >= info.IndexID && (!found || id < indexID) {
+			indexID, found = id, true
+		}
+	}
+	return indexID, found
 }
 
 // Label will parse the ID information of the table and index.
@@ -110,11 +348,11 @@ func (e *tidbLabeler) Label(keys []string) []LabelKey {
 	}
 
 	if keys[0] == "" {
-		labelKeys[0] = globalStart
+		labelKeys[0] = e.withCluster(globalStart)
 	}
 	endIndex := len(keys) - 1
 	if keys[endIndex] == "" {
-		labelKeys[endIndex] = globalEnd
+		labelKeys[endIndex] = e.withCluster(globalEnd)
 	}
 
 	return labelKeys
@@ -122,37 +360,165 @@ func (e *tidbLabeler) Label(keys []string) []LabelKey {
 
 func (e *tidbLabeler) label(key string) (label LabelKey) {
 	keyBytes := region.Bytes(key)
+	defer e.appendRegionLabels(&label, keyBytes)
+
+	if e.Cache == nil {
+		label.Key = hex.EncodeToString(keyBytes)
+		e.decodeLabels(keyBytes, &label)
+		return
+	}
+	if entry, ok := e.Cache.get(key, e.CacheGen); ok {
+		label.Key = entry.label.Key
+		label.Labels = append([]string(nil), entry.label.Labels...)
+		if e.OnLookup != nil && entry.tableID != 0 {
+			e.OnLookup(entry.tableID)
+		}
+		return
+	}
 	label.Key = hex.EncodeToString(keyBytes)
-	keyInfo, _ := e.Buffer.DecodeKey(keyBytes)
+	entry := &labelCacheEntry{key: key, tableID: e.decodeLabels(keyBytes, &label)}
+	entry.label = LabelKey{Key: label.Key, Labels: append([]string(nil), label.Labels...)}
+	e.Cache.add(entry, e.CacheGen)
+	return
+}
+
+// decodeLabels decodes the key into the labels without the region labels, and returns the table ID of a
+// table key, or 0 otherwise.
+func (e *tidbLabeler) decodeLabels(keyBytes []byte, label *LabelKey) int64 {
+	keyInfo := e.Decoder.DecodeKey(keyBytes)
+	if e.Cluster != "" {
+		label.Labels = append(label.Labels, e.Cluster)
+	}
 
-	isMeta, tableID := keyInfo.MetaOrTable()
-	if isMeta {
+	if keyInfo.IsMeta {
 		label.Labels = append(label.Labels, "meta")
-		return
+		if allocator, tableID, ok := autoIDAllocator(keyInfo.MetaKey, keyInfo.MetaField); ok {
+			label.Labels = append(label.Labels, allocator)
+			if detail, ok := e.TableMap.Load(tableID); ok {
+				label.Labels = append(label.Labels, e.formatter().Table(newTableLabel(detail))...)
+			} else {
+				label.Labels = append(label.Labels, e.unresolvedLabel(tableID))
+			}
+		} else if metaLabel := metaKeyLabel(keyInfo.MetaKey); metaLabel != "" {
+			label.Labels = append(label.Labels, metaLabel)
+		}
+		return 0
 	}
 
+	if e.OnLookup != nil {
+		e.OnLookup(keyInfo.TableID)
+	}
 	var detail *tableDetail
-	if v, ok := e.TableMap.Load(tableID); ok {
-		detail = v.(*tableDetail)
-		label.Labels = append(label.Labels, detail.DB, detail.Name)
+	if detail, _ = e.TableMap.Load(keyInfo.TableID); detail == nil && e.KeyIndex != nil {
+		if ownerID, ok := e.KeyIndex.lookup(string(keyBytes)); ok {
+			detail, _ = e.TableMap.Load(ownerID)
+		}
+	}
+	if detail != nil && e.GroupPartitions && detail.ParentID != 0 {
+		if parent, ok := e.TableMap.Load(detail.ParentID); ok {
+			detail = parent
+		}
+	}
+	if detail != nil {
+		label.Labels = append(label.Labels, e.formatter().Table(newTableLabel(detail))...)
+		if e.PartitionBoundLabels && detail.PartitionBound != "" {
+			label.Labels = append(label.Labels, detail.PartitionBound)
+		}
 	} else {
-		label.Labels = append(label.Labels, fmt.Sprintf("table_%d", tableID))
-	}
-
-	if isCommonHandle, rowID := keyInfo.RowInfo(); isCommonHandle {
-		label.Labels = append(label.Labels, "row")
-	} else if rowID != 0 {
-		label.Labels = append(label.Labels, fmt.Sprintf("row_%d", rowID))
-	} else if indexID := keyInfo.IndexInfo(); indexID != 0 {
-		if detail == nil {
-			label.Labels = append(label.Labels, fmt.Sprintf("index_%d", indexID))
-		} else if name, ok := detail.Indices[indexID]; ok {
-			label.Labels = append(label.Labels, name)
+		label.Labels = append(label.Labels, e.unresolvedLabel(keyInfo.TableID))
+		if e.OnMiss != nil {
+			e.OnMiss(keyInfo.TableID)
+		}
+	}
+
+	if detail != nil && detail.CommonHandle && !keyInfo.IsCommonHandle && keyInfo.RowID != 0 {
+		// A common handle of 8 bytes is decoded as an integer handle.
+		keyInfo.IsCommonHandle, keyInfo.Handle, keyInfo.RowID = true, string(model.EncodeIntHandle(keyInfo.RowID)), 0
+	}
+	isRowKey := keyInfo.IsCommonHandle || keyInfo.RowID != 0
+	if e.PKLabels && isRowKey && detail != nil && len(detail.PKColumns) > 0 {
+		label.Labels = append(label.Labels, fmt.Sprintf("PK(%s)", strings.Join(detail.PKColumns, ",")))
+	}
+
+	if isRowKey {
+		label.Labels = append(label.Labels, e.formatter().Row(newRowLabel(keyInfo, detail)))
+	} else if indexID, ok := indexIDOf(keyInfo, detail); ok {
+		if e.labelsByIndex(detail) {
+			label.Labels = append(label.Labels, e.formatter().Index(newIndexLabel(detail, indexID)))
 		} else {
-			label.Labels = append(label.Labels, fmt.Sprintf("index_%d", indexID))
+			label.Labels = append(label.Labels, indexesLabel)
 		}
 	}
-	return
+	if e.HandleLabels && isRowKey && detail != nil {
+		label.Labels = append(label.Labels, handleLabel(detail))
+	}
+	if detail != nil && detail.Hidden {
+		label.Labels = append(label.Labels, hiddenLabel)
+	}
+	if detail != nil && detail.DDLState.InTransition() {
+		label.Labels = append(label.Labels, fmt.Sprintf("DDL(%s)", detail.DDLState))
+	}
+	if detail != nil && isStatsTable(detail) {
+		label.Labels = append(label.Labels, statsLabel)
+	}
+	if detail != nil && detail.TTL {
+		label.Labels = append(label.Labels, ttlLabel)
+	}
+	if e.ResourceControlLabels && detail != nil && isResourceControlTable(detail) {
+		label.Labels = append(label.Labels, resourceControlLabel)
+	}
+	if e.BindingLabels && detail != nil && isBindingTable(detail) {
+		label.Labels = append(label.Labels, bindingLabel)
+	}
+	if e.Partial {
+		label.Labels = append(label.Labels, partialLabel)
+	}
+	return keyInfo.TableID
+}
+
+const (
+	// partialLabel tags the keys of the tables while they are served as partial, see TableResolver.Partial.
+	partialLabel = "partial"
+	// ttlLabel tags the keys of the tables whose expired rows are deleted by TiDB.
+	ttlLabel = "ttl"
+
+	clusteredHandleLabel = "clustered"
+	rowIDHandleLabel     = "_tidb_rowid"
+)
+
+func handleLabel(detail *tableDetail) string {
+	if detail.Clustered {
+		return clusteredHandleLabel
+	}
+	return rowIDHandleLabel
+}
+
+func (e *tidbLabeler) unresolvedLabel(tableID int64) string {
+	format := e.UnresolvedLabelFormat
+	if format == "" {
+		format = defaultUnresolvedLabelFormat
+	}
+	if !strings.Contains(format, "%") {
+		return format
+	}
+	return fmt.Sprintf(format, tableID)
+}
+
+func (e *tidbLabeler) appendRegionLabels(label *LabelKey, key []byte) {
+	for _, r := range e.RegionLabels {
+		if r.contains(key) {
+			label.Labels = append(label.Labels, r.Label)
+		}
+	}
+}
+
+// withCluster returns a copy of the label with the Cluster label in front, or the label itself if Cluster is
+// not set.
+func (e *tidbLabeler) withCluster(label LabelKey) LabelKey {
+	if e.Cluster == "" {
+		return label
+	}
+	return LabelKey{Key: label.Key, Labels: append([]string{e.Cluster}, label.Labels...)}
 }
 
 var globalStart = LabelKey{
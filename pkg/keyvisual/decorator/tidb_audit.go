@@ -0,0 +1,96 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// AuditAction is how an AuditRecord changes TableMap.
+type AuditAction string
+
+const (
+	AuditAdded   AuditAction = "added"
+	AuditChanged AuditAction = "changed"
+	// AuditRenamed is a table or a partition keeping its ID under another name, which may be changed otherwise
+	// as well.
+	AuditRenamed AuditAction = "renamed"
+	// AuditDropped is a table found dropped but kept for TableResolver.DroppedTableGrace.
+	AuditDropped AuditAction = "dropped"
+	AuditRemoved AuditAction = "removed"
+)
+
+// AuditRecord is a change of a table or a partition in TableMap.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Version is the schema version applied by the change, or -1 if the sync failed half way.
+	Version int64       `json:"version"`
+	Action  AuditAction `json:"action"`
+	TableID int64       `json:"table_id"`
+	// Before is nil for AuditAdded, and After is nil for AuditRemoved.
+	Before *TableInfo `json:"before,omitempty"`
+	After  *TableInfo `json:"after,omitempty"`
+}
+
+// AuditSink receives the changes of TableMap, see TableResolver.AuditSink.
+type AuditSink interface {
+	// Write appends the records of a sync, a Revalidate or a DropTable, in the order they are applied.
+	Write(records []AuditRecord) error
+}
+
+// auditChange is a change recorded by a syncSummary, converted into an AuditRecord once the version is known.
+// The details are never modified once stored, so they are kept as is.
+type auditChange struct {
+	time   time.Time
+	action AuditAction
+	id     int64
+	before *tableDetail
+	after  *tableDetail
+}
+
+// recordAudit records a change if the summary is audited.
+func (summary *syncSummary) recordAudit(now time.Time, action AuditAction, id int64, before, after *tableDetail) {
+	if !summary.audited {
+		return
+	}
+	summary.audit = append(summary.audit, auditChange{time: now, action: action, id: id, before: before, after: after})
+}
+
+// newSyncSummary returns a syncSummary recording the changes for AuditSink if it is set.
+func (r *TableResolver) newSyncSummary() *syncSummary {
+	summary := newSyncSummary()
+	summary.audited = r.AuditSink != nil
+	return summary
+}
+
+// writeAudit writes the changes to AuditSink as of version, with applyMu held for writing. An error of the sink
+// is logged and the records are not written again.
+func (r *TableResolver) writeAudit(version int64, changes []auditChange) {
+	if r.AuditSink == nil || len(changes) == 0 {
+		return
+	}
+	records := make([]AuditRecord, 0, len(changes))
+	for _, change := range changes {
+		record := AuditRecord{Time: change.time, Version: version, Action: change.action, TableID: change.id}
+		if change.before != nil {
+			info := change.before.toTableInfo()
+			info.Cluster = r.Cluster
+			record.Before = &info
+		}
+		if change.after != nil {
+			info := change.after.toTableInfo()
+			info.Cluster = r.Cluster
+			record.After = &info
+		}
+		records = append(records, record)
+	}
+	if err := r.AuditSink.Write(records); err != nil {
+		log.Warn("failed to write the audit records of the tidb tables",
+			zap.Int64("version", version),
+			zap.Int("records", len(records)),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sort"
+	"unsafe"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// degradation is how far TableMap is degraded to stay within MemoryBudget. Each level includes the ones
+// before it.
+type degradation int32
+
+const (
+	degradeNone degradation = iota
+	// degradeIndices drops the index names, so that the index keys are labeled as `index_1`.
+	degradeIndices
+	// degradePartitions folds the partitions into their tables, like SkipPartitions.
+	degradePartitions
+	// degradeEviction evicts the entries fetched the longest ago, which are labeled by ID.
+	degradeEviction
+)
+
+func (d degradation) String() string {
+	switch d {
+	case degradeIndices:
+		return "indices"
+	case degradePartitions:
+		return "partitions"
+	case degradeEviction:
+		return "eviction"
+	default:
+		return "none"
+	}
+}
+
+// The approximate bytes of the parts of a detail besides its strings.
+const (
+	// detailOverhead is the entry of the detail in TableMap, its pointer in the snapshot and its entry in the
+	// key index.
+	detailOverhead = 128
+	// mapEntrySize is an entry of a map keyed by int64, besides the value.
+	mapEntrySize = 48
+	// stringHeaderSize is a string in a slice, besides its bytes.
+	stringHeaderSize = 16
+)
+
+// approxSize estimates the bytes held by the detail in TableMap. The indices of a partition are shared with
+// its table, so they are only counted for the table.
+func (d *tableDetail) approxSize() int64 {
+	size := int64(unsafe.Sizeof(*d)) + detailOverhead +
+		int64(len(d.Name)+len(d.DB)+len(d.RawName)+len(d.RawDB)+len(d.PartitionBound))
+	if d.ParentID == 0 {
+		for _, name := range d.Indices {
+			size += mapEntrySize + int64(len(name))
+		}
+		size += mapEntrySize * int64(len(d.GlobalIndices))
+	}
+	for _, col := range d.PKColumns {
+		size += stringHeaderSize + int64(len(col))
+	}
+	for _, name := range d.PartitionPath {
+		size += stringHeaderSize + int64(len(name))
+	}
+	return size + 8*int64(len(d.PartitionIDs))
+}
+
+// tableMapSize estimates the bytes held by TableMap, its snapshot and key index, and the retained versions.
+func (r *TableResolver) tableMapSize() int64 {
+	var size int64
+	r.TableMap.Range(func(_ int64, detail *tableDetail) bool {
+		size += detail.approxSize()
+		return true
+	})
+	r.versions.mu.Lock()
+	for _, retained := range r.versions.snapshots {
+		size += 8 * int64(len(retained.snapshot.details))
+	}
+	r.versions.mu.Unlock()
+	return size
+}
+
+// degraded returns the degradation of TableMap.
+func (r *TableResolver) degraded() degradation {
+	return degradation(r.degradation.Load())
+}
+
+// enforceMemoryBudget degrades TableMap one level at a time until it fits in MemoryBudget, with applyMu held
+// for writing. The degradation is kept by the later syncs, as the tables of a schema are not expected to
+// shrink much, and is only reset by a restart. Under degradeEviction, the entries over the budget are evicted
+// after each sync.
+func (r *TableResolver) enforceMemoryBudget() {
+	if r.MemoryBudget <= 0 {
+		return
+	}
+	size := r.tableMapSize()
+	if size <= r.MemoryBudget {
+		return
+	}
+	for size > r.MemoryBudget && r.degraded() < degradeEviction {
+		level := degradation(r.degradation.Inc())
+		r.degradeTableMap(level)
+		degradedSize := r.tableMapSize()
+		log.Warn("the tidb tables exceed the memory budget, degrade the labels",
+			zap.Stringer("degradation", level),
+			zap.Int64("size", size),
+			zap.Int64("degraded-size", degradedSize),
+			zap.Int64("budget", r.MemoryBudget))
+		size = degradedSize
+	}
+	if size > r.MemoryBudget {
+		if evicted := r.evictTables(size - r.MemoryBudget); evicted > 0 {
+			log.Warn("the tidb tables exceed the memory budget, evict the tables fetched the longest ago",
+				zap.Int("evicted", evicted),
+				zap.Int64("size", size),
+				zap.Int64("budget", r.MemoryBudget))
+		}
+	}
+	r.tableMapGen.Inc()
+}
+
+// degradeTableMap applies a level of degradation to the entries in TableMap. It stores degraded copies,
+// as the stored details are never modified.
+func (r *TableResolver) degradeTableMap(level degradation) {
+	switch level {
+	case degradeIndices:
+		r.TableMap.Range(func(id int64, detail *tableDetail) bool {
+			if len(detail.Indices) > 0 || detail.GlobalIndices != nil {
+				degraded := *detail
+				degraded.Indices = map[int64]string{}
+				degraded.GlobalIndices = nil
+				r.TableMap.Store(id, &degraded)
+			}
+			return true
+		})
+	case degradePartitions:
+		partitionIDs := make(map[int64][]int64)
+		r.TableMap.Range(func(id int64, detail *tableDetail) bool {
+			if detail.ParentID != 0 {
+				partitionIDs[detail.ParentID] = append(partitionIDs[detail.ParentID], id)
+			}
+			return true
+		})
+		for parentID, ids := range partitionIDs {
+			for _, id := range ids {
+				r.TableMap.Delete(id)
+			}
+			parent, ok := r.TableMap.Load(parentID)
+			if !ok {
+				continue
+			}
+			sort.Slice(ids, func(i, j int) bool {
+				return ids[i] < ids[j]
+			})
+			degraded := *parent
+			degraded.PartitionIDs = append(append([]int64(nil), parent.PartitionIDs...), ids...)
+			r.TableMap.Store(parentID, &degraded)
+		}
+	}
+}
+
+// evictTables deletes the entries fetched the longest ago from TableMap, the smallest IDs first among the
+// ones fetched together, until about excess bytes are freed. It returns the number of entries evicted.
+func (r *TableResolver) evictTables(excess int64) int {
+	var details []*tableDetail
+	r.TableMap.Range(func(_ int64, detail *tableDetail) bool {
+		details = append(details, detail)
+		return true
+	})
+	sort.Slice(details, func(i, j int) bool {
+		if !details[i].UpdatedAt.Equal(details[j].UpdatedAt) {
+			return details[i].UpdatedAt.Before(details[j].UpdatedAt)
+		}
+		return details[i].ID < details[j].ID
+	})
+	evicted := 0
+	for _, detail := range details {
+		if excess <= 0 {
+			break
+		}
+		r.TableMap.Delete(detail.ID)
+		excess -= detail.approxSize()
+		evicted++
+	}
+	return evicted
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sync"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// FetchedSchema is the schema fetched from TiDB by a successful sync. It is shared by all callbacks and must
+// not be modified.
+type FetchedSchema struct {
+	Version int64
+	DBs     []*model.DBInfo
+	// Tables maps the database names, in their original form, to the tables of the databases. The databases
+	// whose state is none are not requested, so they are not in it.
+	Tables map[string][]*model.TableInfo
+}
+
+// schemaNotifier calls OnSchemaFetched in its own goroutine, so that a slow callback never delays a sync.
+// The calls never overlap: if more schemas are fetched while a call is running, only the latest one is
+// passed to the next call.
+type schemaNotifier struct {
+	mu      sync.Mutex
+	pending *FetchedSchema
+	running bool
+}
+
+func (n *schemaNotifier) notify(schema *FetchedSchema, callback func(FetchedSchema)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending = schema
+	if n.running {
+		return
+	}
+	n.running = true
+	go func() {
+		for {
+			n.mu.Lock()
+			schema := n.pending
+			n.pending = nil
+			if schema == nil {
+				n.running = false
+				n.mu.Unlock()
+				return
+			}
+			n.mu.Unlock()
+			callback(*schema)
+		}
+	}()
+}
+
+// notifySchemaFetched hands the schema fetched by a successful sync to OnSchemaFetched, if it is set.
+func (r *TableResolver) notifySchemaFetched(schemaVersion int64, dbInfos []*model.DBInfo, fetched []dbTableInfos) {
+	if r.OnSchemaFetched == nil {
+		return
+	}
+	schema := &FetchedSchema{
+		Version: schemaVersion,
+		DBs:     dbInfos,
+		Tables:  make(map[string][]*model.TableInfo, len(fetched)),
+	}
+	for _, res := range fetched {
+		schema.Tables[res.dbName] = res.tableInfos
+	}
+	r.notifier.notify(schema, r.OnSchemaFetched)
+}
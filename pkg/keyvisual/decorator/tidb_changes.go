@@ -0,0 +1,111 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sort"
+	"sync"
+)
+
+// TableMapChange is the set of tables and partitions changed in TableMap by a sync or a Revalidate.
+type TableMapChange struct {
+	// Version is the schema version applied, or -1 if the sync failed half way, in which case the tables
+	// updated before the failure are still reported.
+	Version int64   `json:"version"`
+	Added   []int64 `json:"added"`
+	Changed []int64 `json:"changed"`
+	// Removed are the tables seen by the previous complete sync but not by this one. It is only reported by
+	// complete syncs. The removed tables are kept in TableMap, like the labels of their remaining regions.
+	Removed []int64 `json:"removed"`
+}
+
+func (c *TableMapChange) empty() bool {
+	return len(c.Added)+len(c.Changed)+len(c.Removed) == 0
+}
+
+// changeHub fans the changes out to the subscribers. A subscriber never blocks a sync: once its buffer is
+// full, it is dropped and its channel is closed.
+type changeHub struct {
+	mu   sync.Mutex
+	subs map[chan TableMapChange]struct{}
+}
+
+func (h *changeHub) subscribe(buffer int) (<-chan TableMapChange, func()) {
+	ch := make(chan TableMapChange, buffer)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan TableMapChange]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.drop(ch)
+	}
+}
+
+// drop must be called with mu held. It is a no-op for a dropped subscriber.
+func (h *changeHub) drop(ch chan TableMapChange) {
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+func (h *changeHub) publish(change TableMapChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- change:
+		default:
+			h.drop(ch)
+		}
+	}
+}
+
+// SubscribeChanges returns a channel receiving the changes of TableMap after each sync or Revalidate changing
+// it, and a function to unsubscribe. The channel buffers up to buffer changes. When a subscriber falls further
+// behind, the channel is closed, and the subscriber should reload all tables and subscribe again.
+func (r *TableResolver) SubscribeChanges(buffer int) (<-chan TableMapChange, func()) {
+	return r.changes.subscribe(buffer)
+}
+
+// publishChanges publishes the changes recorded by summary. For a complete sync, the tables seen are compared
+// with the previous complete sync, so that a table removed and created again with the same ID is reported as
+// added. The changes are written to AuditSink as well. It is called with applyMu held.
+func (r *TableResolver) publishChanges(version int64, summary *syncSummary, complete bool) {
+	r.writeAudit(version, summary.audit)
+	change := TableMapChange{Version: version, Added: summary.addedIDs, Changed: summary.changedIDs}
+	if complete {
+		if r.lastSeen != nil {
+			change.Added, change.Changed = nil, nil
+			for _, id := range summary.changedIDs {
+				if _, ok := r.lastSeen[id]; ok {
+					change.Changed = append(change.Changed, id)
+				}
+			}
+			for id := range summary.seen {
+				if _, ok := r.lastSeen[id]; !ok {
+					change.Added = append(change.Added, id)
+				}
+			}
+			for id := range r.lastSeen {
+				if _, ok := summary.seen[id]; !ok {
+					change.Removed = append(change.Removed, id)
+				}
+			}
+		}
+		r.lastSeen = summary.seen
+	}
+	if change.empty() {
+		return
+	}
+	for _, ids := range [][]int64{change.Added, change.Changed, change.Removed} {
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+	}
+	r.changes.publish(change)
+}
@@ -0,0 +1,9 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+//go:build decorator_checks
+// +build decorator_checks
+
+package decorator
+
+// checksTag reports if the invariant checks are built in by the `decorator_checks` tag.
+const checksTag = true
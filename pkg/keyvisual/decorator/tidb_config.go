@@ -0,0 +1,438 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LabelStrategyConfig gathers the tunables of the TiDB label strategy and its TableResolver. The hooks, e.g.
+// NormalizeName and TokenProvider, are not configuration and are still set on the TableResolver.
+// See the fields of the same names in TableResolver and tidbLabelStrategy for their meanings. The durations are
+// in nanoseconds in JSON, e.g. `"sync_interval": 60000000000` for 1 minute.
+type LabelStrategyConfig struct {
+	// SyncInterval defaults to 1 minute if zero.
+	SyncInterval time.Duration `json:"sync_interval"`
+	SyncJitter   time.Duration `json:"sync_jitter"`
+	// SyncTimeout of zero means no bound.
+	SyncTimeout           time.Duration `json:"sync_timeout"`
+	ColdSyncTimeout       time.Duration `json:"cold_sync_timeout"`
+	ColdSyncRetryInterval time.Duration `json:"cold_sync_retry_interval"`
+	// ColdSyncRetryMaxInterval of zero retries the cold sync every ColdSyncRetryInterval.
+	ColdSyncRetryMaxInterval time.Duration `json:"cold_sync_retry_max_interval"`
+	// ColdSyncDeadline of zero waits for the cold sync forever.
+	ColdSyncDeadline time.Duration `json:"cold_sync_deadline"`
+	// RequestTimeout of zero means no bound. EndpointTimeouts are keyed by the endpoint families, e.g.
+	// `schema_db`, and default to RequestTimeout.
+	RequestTimeout   time.Duration                    `json:"request_timeout"`
+	EndpointTimeouts map[StatusEndpoint]time.Duration `json:"endpoint_timeouts"`
+	// EtcdGetTimeout defaults to 1 second if zero.
+	EtcdGetTimeout       time.Duration `json:"etcd_get_timeout"`
+	SyncOnDDLOwnerChange bool          `json:"sync_on_ddl_owner_change"`
+	// OwnerChangeDelay defaults to 5 seconds if zero.
+	OwnerChangeDelay          time.Duration `json:"owner_change_delay"`
+	SyncOnSchemaVersionChange bool          `json:"sync_on_schema_version_change"`
+	// SchemaVersionDebounce defaults to 500 milliseconds if zero.
+	SchemaVersionDebounce time.Duration `json:"schema_version_debounce"`
+	// WatchKeepAliveInterval of zero disables the probes of the watch.
+	WatchKeepAliveInterval time.Duration `json:"watch_keepalive_interval"`
+	// SyncConcurrency defaults to 4 if zero.
+	SyncConcurrency   int `json:"sync_concurrency"`
+	WarmupConcurrency int `json:"warmup_concurrency"`
+	// SchemaPathName defaults to SchemaNameOriginal if empty.
+	SchemaPathName SchemaNameForm `json:"schema_path_name"`
+	// HiddenTables defaults to HiddenTablesTag if empty.
+	HiddenTables    HiddenTablePolicy `json:"hidden_tables"`
+	StatsTables     bool              `json:"stats_tables"`
+	ReorgPartitions bool              `json:"reorg_partitions"`
+	SkipPartitions  bool              `json:"skip_partitions"`
+	Cluster         string            `json:"cluster"`
+	StreamingApply  bool              `json:"streaming_apply"`
+	// TableInfoMetricsLimit of zero disables the table_info metric.
+	TableInfoMetricsLimit int  `json:"table_info_metrics_limit"`
+	SkipDeleteOnlyTables  bool `json:"skip_delete_only_tables"`
+	PruneDroppedTables    bool `json:"prune_dropped_tables"`
+	// DroppedTableGrace of zero removes the dropped tables at once under PruneDroppedTables. The defaults keep
+	// them for 10 minutes.
+	DroppedTableGrace time.Duration `json:"dropped_table_grace"`
+	// ClockSkewThreshold of zero disables the check of the clock skew.
+	ClockSkewThreshold time.Duration `json:"clock_skew_threshold"`
+	// StaleRevalidateSize or StaleRevalidateInterval of zero disables the revalidation of stale tables.
+	StaleRevalidateSize     int           `json:"stale_revalidate_size"`
+	StaleRevalidateInterval time.Duration `json:"stale_revalidate_interval"`
+	// ConsistencyCheckSize of zero disables the consistency check.
+	ConsistencyCheckSize int `json:"consistency_check_size"`
+	// RetainedVersions of zero retains no past schema version.
+	RetainedVersions int `json:"retained_versions"`
+	// The tables of the databases with empty names are skipped if EmptyDBName is empty.
+	EmptyDBName            string `json:"empty_db_name"`
+	CaseInsensitiveLookups bool   `json:"case_insensitive_lookups"`
+	// Redirects defaults to RedirectSameHost if empty.
+	Redirects RedirectPolicy `json:"redirects"`
+	// DuplicateTableIDs defaults to DuplicateKeepLast if empty.
+	DuplicateTableIDs DuplicateTableIDPolicy `json:"duplicate_table_ids"`
+	// InconsistentIndexIDs defaults to InconsistentIndexesByName if empty.
+	InconsistentIndexIDs InconsistentIndexIDPolicy `json:"inconsistent_index_ids"`
+	// MaxResponseSize defaults to 512 MiB if zero.
+	MaxResponseSize int64 `json:"max_response_size"`
+	// MemoryBudget of zero leaves the memory of the tables unbounded.
+	MemoryBudget        int64 `json:"memory_budget"`
+	ResyncMissThreshold int   `json:"resync_miss_threshold"`
+	// MaxVersionStableInterval of zero disables the full syncs of an unchanged schema version.
+	MaxVersionStableInterval time.Duration `json:"max_version_stable_interval"`
+	LookupSampleRate         float64       `json:"lookup_sample_rate"`
+	// LookupSampleWindow defaults to 10 minutes if zero.
+	LookupSampleWindow time.Duration `json:"lookup_sample_window"`
+	HotTablesFirst     bool          `json:"hot_tables_first"`
+	LogSyncSummary     bool          `json:"log_sync_summary"`
+	Lazy               bool          `json:"lazy"`
+	// MaxTableMapEntries of zero leaves TableMap unbounded.
+	MaxTableMapEntries int `json:"max_table_map_entries"`
+
+	RegionLabels          bool `json:"region_labels"`
+	PKLabels              bool `json:"pk_labels"`
+	ResourceControlLabels bool `json:"resource_control_labels"`
+	BindingLabels         bool `json:"binding_labels"`
+	HandleLabels          bool `json:"handle_labels"`
+	PartitionBoundLabels  bool `json:"partition_bound_labels"`
+	GroupPartitions       bool `json:"group_partitions"`
+	// UnresolvedLabelFormat defaults to `table_%d` if empty.
+	UnresolvedLabelFormat string `json:"unresolved_label_format"`
+	// IndexLabelTables are the tables, in the `db.table` form, whose index keys are labeled by index. The
+	// index keys of the other tables are labeled `indexes`. All tables are labeled by index if it is empty.
+	IndexLabelTables []string `json:"index_label_tables"`
+	// LabelCacheSize is the number of region keys whose labels are cached. Defaults to 65536 if zero.
+	LabelCacheSize int `json:"label_cache_size"`
+}
+
+const (
+	// minSyncInterval and minTimeout bound the durations from below, so that a duration given in seconds or
+	// milliseconds rather than nanoseconds is rejected instead of syncing in a busy loop or timing out at once.
+	minSyncInterval = time.Second
+	minTimeout      = 100 * time.Millisecond
+)
+
+// DefaultLabelStrategyConfig returns the config used by the dashboard.
+func DefaultLabelStrategyConfig() LabelStrategyConfig {
+	cfg := LabelStrategyConfig{
+		SyncJitter:             defaultSyncJitter,
+		SyncTimeout:            defaultSyncTimeout,
+		WatchKeepAliveInterval: defaultWatchKeepAlive,
+
+		ColdSyncRetryInterval:    defaultColdSyncRetryInterval,
+		ColdSyncRetryMaxInterval: defaultColdSyncRetryMaxInterval,
+
+		PruneDroppedTables: true,
+		DroppedTableGrace:  defaultDroppedTableGrace,
+	}
+	_ = cfg.Validate()
+	return cfg
+}
+
+// ParseLabelStrategyConfig parses the JSON object of a LabelStrategyConfig, e.g. the `label_strategy` of the
+// key visual config, over DefaultLabelStrategyConfig, so that the fields omitted keep the defaults of the
+// dashboard. Empty data gives the defaults. The config is validated.
+func ParseLabelStrategyConfig(data []byte) (LabelStrategyConfig, error) {
+	cfg := DefaultLabelStrategyConfig()
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, ErrInvalidConfig.Wrap(err, "label_strategy is not a valid config")
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Validate fills the defaults of the zero fields, and returns ErrInvalidConfig if any field is out of range or
+// the fields contradict each other.
+func (c *LabelStrategyConfig) Validate() error {
+	for _, f := range []struct {
+		name  string
+		value int64
+	}{
+		{"sync_interval", int64(c.SyncInterval)},
+		{"sync_jitter", int64(c.SyncJitter)},
+		{"sync_timeout", int64(c.SyncTimeout)},
+		{"cold_sync_timeout", int64(c.ColdSyncTimeout)},
+		{"cold_sync_retry_interval", int64(c.ColdSyncRetryInterval)},
+		{"cold_sync_retry_max_interval", int64(c.ColdSyncRetryMaxInterval)},
+		{"cold_sync_deadline", int64(c.ColdSyncDeadline)},
+		{"request_timeout", int64(c.RequestTimeout)},
+		{"etcd_get_timeout", int64(c.EtcdGetTimeout)},
+		{"owner_change_delay", int64(c.OwnerChangeDelay)},
+		{"schema_version_debounce", int64(c.SchemaVersionDebounce)},
+		{"watch_keepalive_interval", int64(c.WatchKeepAliveInterval)},
+		{"lookup_sample_window", int64(c.LookupSampleWindow)},
+		{"sync_concurrency", int64(c.SyncConcurrency)},
+		{"warmup_concurrency", int64(c.WarmupConcurrency)},
+		{"max_response_size", c.MaxResponseSize},
+		{"memory_budget", c.MemoryBudget},
+		{"resync_miss_threshold", int64(c.ResyncMissThreshold)},
+		{"max_version_stable_interval", int64(c.MaxVersionStableInterval)},
+		{"max_table_map_entries", int64(c.MaxTableMapEntries)},
+		{"label_cache_size", int64(c.LabelCacheSize)},
+		{"consistency_check_size", int64(c.ConsistencyCheckSize)},
+		{"retained_versions", int64(c.RetainedVersions)},
+		{"clock_skew_threshold", int64(c.ClockSkewThreshold)},
+		{"dropped_table_grace", int64(c.DroppedTableGrace)},
+		{"table_info_metrics_limit", int64(c.TableInfoMetricsLimit)},
+		{"stale_revalidate_size", int64(c.StaleRevalidateSize)},
+		{"stale_revalidate_interval", int64(c.StaleRevalidateInterval)},
+	} {
+		if f.value < 0 {
+			return ErrInvalidConfig.New("%s must not be negative", f.name)
+		}
+	}
+	for _, name := range c.IndexLabelTables {
+		if !strings.Contains(name, ".") {
+			return ErrInvalidConfig.New("index_label_tables must be in the db.table form, got %q", name)
+		}
+	}
+	for endpoint, timeout := range c.EndpointTimeouts {
+		switch endpoint {
+		case EndpointSchema, EndpointSchemaDB, EndpointDBTable:
+		default:
+			return ErrInvalidConfig.New("unknown endpoint %q in endpoint_timeouts", endpoint)
+		}
+		if timeout < 0 {
+			return ErrInvalidConfig.New("endpoint_timeouts of %s must not be negative", endpoint)
+		}
+	}
+	if c.LookupSampleRate < 0 || c.LookupSampleRate > 1 {
+		return ErrInvalidConfig.New("lookup_sample_rate must be in [0, 1], got %v", c.LookupSampleRate)
+	}
+	switch c.HiddenTables {
+	case "", HiddenTablesTag, HiddenTablesStore, HiddenTablesSkip:
+	default:
+		return ErrInvalidConfig.New("unknown hidden_tables %q", c.HiddenTables)
+	}
+	switch c.Redirects {
+	case "", RedirectSameHost, RedirectNone:
+	default:
+		return ErrInvalidConfig.New("unknown redirects %q", c.Redirects)
+	}
+	switch c.DuplicateTableIDs {
+	case "", DuplicateKeepFirst, DuplicateKeepLast, DuplicateError:
+	default:
+		return ErrInvalidConfig.New("unknown duplicate_table_ids %q", c.DuplicateTableIDs)
+	}
+	switch c.InconsistentIndexIDs {
+	case "", InconsistentIndexesByName, InconsistentIndexesKeepLast:
+	default:
+		return ErrInvalidConfig.New("unknown inconsistent_index_ids %q", c.InconsistentIndexIDs)
+	}
+	switch c.SchemaPathName {
+	case "", SchemaNameOriginal, SchemaNameLower:
+	default:
+		return ErrInvalidConfig.New("unknown schema_path_name %q", c.SchemaPathName)
+	}
+	if strings.Contains(c.UnresolvedLabelFormat, "%") &&
+		strings.Contains(fmt.Sprintf(c.UnresolvedLabelFormat, int64(1)), "%!") {
+		return ErrInvalidConfig.New("unresolved_label_format must contain at most one %%d, got %q", c.UnresolvedLabelFormat)
+	}
+
+	if c.SyncInterval == 0 {
+		c.SyncInterval = defaultSyncInterval
+	}
+	if c.OwnerChangeDelay == 0 {
+		c.OwnerChangeDelay = defaultOwnerChangeDelay
+	}
+	if c.SchemaVersionDebounce == 0 {
+		c.SchemaVersionDebounce = defaultSchemaVersionDebounce
+	}
+	if c.SyncConcurrency == 0 {
+		c.SyncConcurrency = defaultSyncConcurrency
+	}
+	if c.EtcdGetTimeout == 0 {
+		c.EtcdGetTimeout = defaultEtcdGetTimeout
+	}
+	if c.SchemaPathName == "" {
+		c.SchemaPathName = SchemaNameOriginal
+	}
+	if c.HiddenTables == "" {
+		c.HiddenTables = HiddenTablesTag
+	}
+	if c.Redirects == "" {
+		c.Redirects = RedirectSameHost
+	}
+	if c.DuplicateTableIDs == "" {
+		c.DuplicateTableIDs = DuplicateKeepLast
+	}
+	if c.InconsistentIndexIDs == "" {
+		c.InconsistentIndexIDs = InconsistentIndexesByName
+	}
+	if c.MaxResponseSize == 0 {
+		c.MaxResponseSize = defaultMaxResponseSize
+	}
+	if c.LookupSampleWindow == 0 {
+		c.LookupSampleWindow = defaultLookupSampleWindow
+	}
+	if c.UnresolvedLabelFormat == "" {
+		c.UnresolvedLabelFormat = defaultUnresolvedLabelFormat
+	}
+	if c.LabelCacheSize == 0 {
+		c.LabelCacheSize = defaultLabelCacheSize
+	}
+
+	if c.SyncInterval < minSyncInterval {
+		return ErrInvalidConfig.New("sync_interval must be at least %s, got %s", minSyncInterval, c.SyncInterval)
+	}
+	for _, f := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"sync_timeout", c.SyncTimeout},
+		{"cold_sync_timeout", c.ColdSyncTimeout},
+		{"request_timeout", c.RequestTimeout},
+		{"etcd_get_timeout", c.EtcdGetTimeout},
+	} {
+		if f.value != 0 && f.value < minTimeout {
+			return ErrInvalidConfig.New("%s must be zero or at least %s, got %s", f.name, minTimeout, f.value)
+		}
+	}
+	for endpoint, timeout := range c.EndpointTimeouts {
+		if timeout != 0 && timeout < minTimeout {
+			return ErrInvalidConfig.New("endpoint_timeouts of %s must be zero or at least %s, got %s",
+				endpoint, minTimeout, timeout)
+		}
+	}
+
+	// An owner change resets the timer of the next sync, so a delay longer than the interval postpones the
+	// syncs instead of bringing them forward.
+	if c.SyncOnDDLOwnerChange && c.OwnerChangeDelay >= c.SyncInterval {
+		return ErrInvalidConfig.New("owner_change_delay %s must be shorter than sync_interval %s",
+			c.OwnerChangeDelay, c.SyncInterval)
+	}
+	if c.SyncOnSchemaVersionChange && c.SchemaVersionDebounce >= c.SyncInterval {
+		return ErrInvalidConfig.New("schema_version_debounce %s must be shorter than sync_interval %s",
+			c.SchemaVersionDebounce, c.SyncInterval)
+	}
+	return nil
+}
+
+// applyConfig sets the tunables of the resolver from a validated config.
+func (r *TableResolver) applyConfig(cfg LabelStrategyConfig) {
+	r.SyncInterval = cfg.SyncInterval
+	r.SyncJitter = cfg.SyncJitter
+	r.SyncTimeout = cfg.SyncTimeout
+	r.ColdSyncTimeout = cfg.ColdSyncTimeout
+	r.ColdSyncRetryInterval = cfg.ColdSyncRetryInterval
+	r.ColdSyncRetryMaxInterval = cfg.ColdSyncRetryMaxInterval
+	r.ColdSyncDeadline = cfg.ColdSyncDeadline
+	r.RequestTimeout = cfg.RequestTimeout
+	r.EtcdGetTimeout = cfg.EtcdGetTimeout
+	r.EndpointTimeouts = copyEndpointTimeouts(cfg.EndpointTimeouts)
+	r.SyncOnDDLOwnerChange = cfg.SyncOnDDLOwnerChange
+	r.OwnerChangeDelay = cfg.OwnerChangeDelay
+	r.SyncOnSchemaVersionChange = cfg.SyncOnSchemaVersionChange
+	r.SchemaVersionDebounce = cfg.SchemaVersionDebounce
+	r.WatchKeepAliveInterval = cfg.WatchKeepAliveInterval
+	r.SyncConcurrency = cfg.SyncConcurrency
+	r.WarmupConcurrency = cfg.WarmupConcurrency
+	r.SchemaPathName = cfg.SchemaPathName
+	r.HiddenTables = cfg.HiddenTables
+	r.StatsTables = cfg.StatsTables
+	r.ReorgPartitions = cfg.ReorgPartitions
+	r.SkipPartitions = cfg.SkipPartitions
+	r.EmptyDBName = cfg.EmptyDBName
+	r.CaseInsensitiveLookups = cfg.CaseInsensitiveLookups
+	r.Cluster = cfg.Cluster
+	r.StreamingApply = cfg.StreamingApply
+	r.ConsistencyCheckSize = cfg.ConsistencyCheckSize
+	r.RetainedVersions = cfg.RetainedVersions
+	r.TableInfoMetricsLimit = cfg.TableInfoMetricsLimit
+	r.SkipDeleteOnlyTables = cfg.SkipDeleteOnlyTables
+	r.PruneDroppedTables = cfg.PruneDroppedTables
+	r.DroppedTableGrace = cfg.DroppedTableGrace
+	r.ClockSkewThreshold = cfg.ClockSkewThreshold
+	r.StaleRevalidateSize = cfg.StaleRevalidateSize
+	r.StaleRevalidateInterval = cfg.StaleRevalidateInterval
+	r.Redirects = cfg.Redirects
+	r.DuplicateTableIDs = cfg.DuplicateTableIDs
+	r.InconsistentIndexIDs = cfg.InconsistentIndexIDs
+	r.MaxResponseSize = cfg.MaxResponseSize
+	r.MemoryBudget = cfg.MemoryBudget
+	r.ResyncMissThreshold = cfg.ResyncMissThreshold
+	r.MaxVersionStableInterval = cfg.MaxVersionStableInterval
+	r.LookupSampleRate = cfg.LookupSampleRate
+	r.LookupSampleWindow = cfg.LookupSampleWindow
+	r.HotTablesFirst = cfg.HotTablesFirst
+	r.LogSyncSummary = cfg.LogSyncSummary
+	r.Lazy = cfg.Lazy
+	if cfg.MaxTableMapEntries > 0 && cfg.MaxTableMapEntries != r.MaxTableMapEntries {
+		r.TableMap = newLRUTableStore(cfg.MaxTableMapEntries)
+	}
+	r.MaxTableMapEntries = cfg.MaxTableMapEntries
+}
+
+// currentConfig returns the tunables of the resolver as a config, reverting applyConfig. The fields of the
+// label strategy are left zero.
+func (r *TableResolver) currentConfig() LabelStrategyConfig {
+	return LabelStrategyConfig{
+		SyncInterval:              r.SyncInterval,
+		SyncJitter:                r.SyncJitter,
+		SyncTimeout:               r.SyncTimeout,
+		ColdSyncTimeout:           r.ColdSyncTimeout,
+		ColdSyncRetryInterval:     r.ColdSyncRetryInterval,
+		ColdSyncRetryMaxInterval:  r.ColdSyncRetryMaxInterval,
+		ColdSyncDeadline:          r.ColdSyncDeadline,
+		RequestTimeout:            r.RequestTimeout,
+		EtcdGetTimeout:            r.EtcdGetTimeout,
+		EndpointTimeouts:          copyEndpointTimeouts(r.EndpointTimeouts),
+		SyncOnDDLOwnerChange:      r.SyncOnDDLOwnerChange,
+		OwnerChangeDelay:          r.OwnerChangeDelay,
+		SyncOnSchemaVersionChange: r.SyncOnSchemaVersionChange,
+		SchemaVersionDebounce:     r.SchemaVersionDebounce,
+		WatchKeepAliveInterval:    r.WatchKeepAliveInterval,
+		SyncConcurrency:           r.SyncConcurrency,
+		WarmupConcurrency:         r.WarmupConcurrency,
+		SchemaPathName:            r.SchemaPathName,
+		HiddenTables:              r.HiddenTables,
+		StatsTables:               r.StatsTables,
+		ReorgPartitions:           r.ReorgPartitions,
+		SkipPartitions:            r.SkipPartitions,
+		EmptyDBName:               r.EmptyDBName,
+		CaseInsensitiveLookups:    r.CaseInsensitiveLookups,
+		Cluster:                   r.Cluster,
+		StreamingApply:            r.StreamingApply,
+		ConsistencyCheckSize:      r.ConsistencyCheckSize,
+		RetainedVersions:          r.RetainedVersions,
+		TableInfoMetricsLimit:     r.TableInfoMetricsLimit,
+		SkipDeleteOnlyTables:      r.SkipDeleteOnlyTables,
+		PruneDroppedTables:        r.PruneDroppedTables,
+		DroppedTableGrace:         r.DroppedTableGrace,
+		ClockSkewThreshold:        r.ClockSkewThreshold,
+		MaxTableMapEntries:        r.MaxTableMapEntries,
+		StaleRevalidateSize:       r.StaleRevalidateSize,
+		StaleRevalidateInterval:   r.StaleRevalidateInterval,
+		Redirects:                 r.Redirects,
+		DuplicateTableIDs:         r.DuplicateTableIDs,
+		InconsistentIndexIDs:      r.InconsistentIndexIDs,
+		MaxResponseSize:           r.MaxResponseSize,
+		MemoryBudget:              r.MemoryBudget,
+		ResyncMissThreshold:       r.ResyncMissThreshold,
+		MaxVersionStableInterval:  r.MaxVersionStableInterval,
+		LookupSampleRate:          r.LookupSampleRate,
+		LookupSampleWindow:        r.LookupSampleWindow,
+		HotTablesFirst:            r.HotTablesFirst,
+		LogSyncSummary:            r.LogSyncSummary,
+		Lazy:                      r.Lazy,
+	}
+}
+
+// copyEndpointTimeouts copies the timeouts, so that the config and the resolver never share the map.
+func copyEndpointTimeouts(timeouts map[StatusEndpoint]time.Duration) map[StatusEndpoint]time.Duration {
+	if timeouts == nil {
+		return nil
+	}
+	copied := make(map[StatusEndpoint]time.Duration, len(timeouts))
+	for endpoint, timeout := range timeouts {
+		copied[endpoint] = timeout
+	}
+	return copied
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// checkConsistency re-fetches up to ConsistencyCheckSize tables sampled from TableMap with Revalidate, which
+// repairs the ones differing from TiDB, e.g. with indices lost by a buggy sync. A sync would not repair them
+// until the schema version changes. It returns the number of tables repaired.
+func (r *TableResolver) checkConsistency(ctx context.Context) int {
+	// Sample the tables only, as revalidating a table updates its partitions as well.
+	sample := make([]*tableDetail, 0, r.ConsistencyCheckSize)
+	seen := 0
+	r.TableMap.Range(func(_ int64, detail *tableDetail) bool {
+		if detail.ParentID != 0 {
+			return true
+		}
+		seen++
+		if len(sample) < r.ConsistencyCheckSize {
+			sample = append(sample, detail)
+		} else if i := rand.Intn(seen); i < len(sample) { // #nosec
+			sample[i] = detail
+		}
+		return true
+	})
+
+	logger := syncLogger(ctx)
+	repaired := 0
+	for _, old := range sample {
+		changed, err := r.Revalidate(ctx, old.ID)
+		if err != nil {
+			logger.Debug("failed to check the consistency of table", zap.Int64("table-id", old.ID), zap.Error(err))
+			continue
+		}
+		if !changed {
+			continue
+		}
+		repaired++
+		detail, _ := r.TableMap.Load(old.ID)
+		logger.Warn("repaired a table differing from tidb without a schema change",
+			zap.Int64("table-id", old.ID),
+			zap.String("old-name", old.DB+"."+old.Name),
+			zap.String("new-name", detail.DB+"."+detail.Name),
+			zap.Any("old-indices", old.Indices),
+			zap.Any("new-indices", detail.Indices),
+		)
+	}
+	return repaired
+}
+
+// revalidateStale revalidates the StaleRevalidateSize tables fetched from TiDB the longest ago, or whose last
+// revalidation is the longest ago if it failed, so that a table gone from TiDB never holds up the others. It
+// returns the number of tables changed.
+func (r *TableResolver) revalidateStale(ctx context.Context) int {
+	if r.staleAttempts == nil {
+		r.staleAttempts = make(map[int64]time.Time)
+	}
+	type staleTable struct {
+		id        int64
+		checkedAt time.Time
+	}
+	var tables []staleTable
+	r.TableMap.Range(func(id int64, detail *tableDetail) bool {
+		if detail.ParentID != 0 {
+			return true
+		}
+		checkedAt := detail.UpdatedAt
+		if attempt, ok := r.staleAttempts[id]; ok && attempt.After(checkedAt) {
+			checkedAt = attempt
+		}
+		tables = append(tables, staleTable{id: id, checkedAt: checkedAt})
+		return true
+	})
+	sort.Slice(tables, func(i, j int) bool {
+		if !tables[i].checkedAt.Equal(tables[j].checkedAt) {
+			return tables[i].checkedAt.Before(tables[j].checkedAt)
+		}
+		return tables[i].id < tables[j].id
+	})
+	if len(tables) > r.StaleRevalidateSize {
+		tables = tables[:r.StaleRevalidateSize]
+	}
+	// Forget the attempts of the tables no longer in TableMap.
+	for id := range r.staleAttempts {
+		if _, ok := r.TableMap.Load(id); !ok {
+			delete(r.staleAttempts, id)
+		}
+	}
+
+	logger := syncLogger(ctx)
+	changed := 0
+	if len(tables) > 0 {
+		defer func(start time.Time) {
+			syncPhaseDurations.WithLabelValues(r.Cluster, syncPhaseDBTable).Observe(time.Since(start).Seconds())
+		}(time.Now())
+	}
+	for _, table := range tables {
+		r.staleAttempts[table.id] = time.Now()
+		ok, err := r.Revalidate(ctx, table.id)
+		if err != nil {
+			logger.Debug("failed to revalidate stale table", zap.Int64("table-id", table.id), zap.Error(err))
+			continue
+		}
+		if ok {
+			changed++
+			logger.Info("revalidated a stale table changed in tidb", zap.Int64("table-id", table.id),
+				zap.Time("fetched-at", table.checkedAt))
+		}
+	}
+	return changed
+}
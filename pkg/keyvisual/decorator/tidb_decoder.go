@@ -0,0 +1,93 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"bytes"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+var (
+	relativeRowPrefix   = []byte("_r")
+	relativeIndexPrefix = []byte("_i")
+)
+
+// KeyInfo is the table and index information decoded from a region key.
+type KeyInfo struct {
+	IsMeta         bool
+	TableID        int64
+	IsCommonHandle bool
+	RowID          int64
+	// Handle is the encoded common handle of a row key if IsCommonHandle is set, empty otherwise. It is a
+	// string to keep KeyInfo comparable.
+	Handle  string
+	IndexID int64
+	// MetaKey is the name of a meta key, e.g. `DDLJobList`, or "" if it is unknown.
+	MetaKey string
+	// MetaField is the field of a hash meta key, e.g. `TID:10` of `DB:2`, or "" if there is none.
+	MetaField string
+	// IsIndexTruncated is true if the key ends inside the index ID, in which case IndexID is the lowest
+	// index ID with the remaining prefix.
+	IsIndexTruncated bool
+}
+
+// KeyDecoder extracts the table and index information from region keys.
+// Each Labeler owns its decoder, so implementations do not need to be safe for concurrent use.
+type KeyDecoder interface {
+	DecodeKey(key []byte) KeyInfo
+}
+
+// NewTiDBKeyDecoder returns a KeyDecoder for the standard TiDB codec.
+func NewTiDBKeyDecoder() KeyDecoder {
+	return &tidbKeyDecoder{}
+}
+
+type tidbKeyDecoder struct {
+	Buffer model.KeyInfoBuffer
+}
+
+// DecodeKey returns a zero KeyInfo if the key is not a valid encoded TiDB key.
+func (d *tidbKeyDecoder) DecodeKey(key []byte) (info KeyInfo) {
+	keyInfo, _ := d.Buffer.DecodeKey(key)
+	info.IsMeta, info.TableID = keyInfo.MetaOrTable()
+	if info.IsMeta {
+		info.MetaKey, _ = keyInfo.MetaKeyName()
+		info.MetaField, _ = keyInfo.MetaKeyField()
+	}
+	info.IsCommonHandle, info.RowID = keyInfo.RowInfo()
+	if info.IsCommonHandle {
+		handle, _ := keyInfo.CommonHandle()
+		info.Handle = string(handle)
+	}
+	info.IndexID, info.IsIndexTruncated = keyInfo.IndexPrefixInfo()
+	return
+}
+
+// DecodeRelativeKey decodes a key of a known table whose table prefix `t{tableID}` has been stripped. The
+// relative key is in the raw form, i.e. not memcomparable encoded like region keys, and is either empty for
+// the start of the table, or starts with `_r` followed by a handle, or `_i` followed by a maybe truncated
+// index ID. Otherwise an ErrInvalidKey is returned.
+func DecodeRelativeKey(tableID int64, relativeKey []byte) (info KeyInfo, err error) {
+	if tableID <= 0 {
+		return info, ErrInvalidKey.New("invalid table ID %d", tableID)
+	}
+	switch {
+	case len(relativeKey) == 0, bytes.HasPrefix(relativeKey, relativeIndexPrefix):
+	case bytes.HasPrefix(relativeKey, relativeRowPrefix):
+		if len(relativeKey) < len(relativeRowPrefix)+8 {
+			return info, ErrInvalidKey.New("relative row key %x of table %d is too short to hold a handle", relativeKey, tableID)
+		}
+	default:
+		return info, ErrInvalidKey.New("relative key %x is out of the range of table %d", relativeKey, tableID)
+	}
+	keyInfo := model.GenerateRawTableKey(tableID, relativeKey)
+	info.TableID = tableID
+	info.IsCommonHandle, info.RowID = keyInfo.RowInfo()
+	if info.IsCommonHandle {
+		handle, _ := keyInfo.CommonHandle()
+		info.Handle = string(handle)
+	}
+	info.IndexID, info.IsIndexTruncated = keyInfo.IndexPrefixInfo()
+	return info, nil
+}
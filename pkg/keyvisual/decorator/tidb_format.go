@@ -0,0 +1,176 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// LabelFormatter renders the labels naming the tables, indexes and rows of the keys, so that an embedder can
+// change the label strings shown by Key Visualizer, e.g. into a single `db.table` label, without forking the
+// strategy. The Cluster label, the unresolved tables, see UnresolvedLabelFormat, and the tags like `hidden`
+// are not rendered by it. DefaultLabelFormatter renders the labels of the dashboard.
+//
+// It is called by the Labelers concurrently, and must return the same labels for the same arguments, as the
+// labels are cached.
+type LabelFormatter interface {
+	// Table returns the labels naming a table or a partition.
+	Table(table TableLabel) []string
+	// Index returns the label of the keys of an index.
+	Index(index IndexLabel) string
+	// Row returns the label of a row key.
+	Row(row RowLabel) string
+}
+
+// TableLabel is a table or a partition rendered by LabelFormatter.Table.
+type TableLabel struct {
+	DB string
+	// Name is the name of the table, or the partition name in the `t/p0/sp1` form for a partition.
+	Name string
+	// Table is the name of the table, or of the partitioned table for a partition. It equals Name for a
+	// partition whose PartitionPath is unknown, e.g. preloaded without it.
+	Table string
+	// PartitionPath is the names of a partition below the table, outermost first. It is empty for a table.
+	PartitionPath []string
+	// Sequence is set for a sequence object.
+	Sequence bool
+	// Dropped is set for a table kept for TableResolver.DroppedTableGrace after it is dropped.
+	Dropped bool
+}
+
+// IndexLabel is an index rendered by LabelFormatter.Index.
+type IndexLabel struct {
+	ID int64
+	// Name is empty if the index is not known, e.g. dropped since the last sync.
+	Name string
+	// Global is set for a global index of a partitioned table.
+	Global bool
+	// Building is set for the temporary index written while the index is being backfilled. ID is the one of
+	// the index being built.
+	Building bool
+}
+
+// RowLabel is a row key rendered by LabelFormatter.Row.
+type RowLabel struct {
+	// RowID is the integer handle of the row, or 0 for a common handle.
+	RowID int64
+	// CommonHandle is set if the row is keyed by the encoded primary key of a clustered table.
+	CommonHandle bool
+	// Columns are the primary key columns of a common handle, empty if the table is not known.
+	Columns []string
+	// Handle are the values decoded from a common handle, see model.DecodeHandleDatums, in the order of
+	// Columns. HandleTruncated is set if the values after them are not decoded, e.g. as a region split key
+	// may end inside a value.
+	Handle          []string
+	HandleTruncated bool
+}
+
+// DefaultLabelFormatter renders the labels as `["db", "t/p0"]`, `idx_a` and `row_1`, with the sequences as
+// `seq (sequence)`, the dropped tables as `t (dropped)`, the global indexes as `idx (global)`, the indexes being
+// built as `idx (building)`, the unknown indexes as `index_2` and the common handles as `row_(a=1,b=x)`, or
+// `row` if no value is decoded.
+type DefaultLabelFormatter struct{}
+
+func (DefaultLabelFormatter) Table(table TableLabel) []string {
+	name := table.Name
+	if table.Sequence {
+		name += " (sequence)"
+	}
+	if table.Dropped {
+		name += " (dropped)"
+	}
+	return []string{table.DB, name}
+}
+
+func (DefaultLabelFormatter) Index(index IndexLabel) string {
+	label := index.Name
+	switch {
+	case label == "":
+		label = fmt.Sprintf("index_%d", index.ID)
+	case index.Global:
+		label += " (global)"
+	}
+	if index.Building {
+		label += " (building)"
+	}
+	return label
+}
+
+func (DefaultLabelFormatter) Row(row RowLabel) string {
+	if row.CommonHandle {
+		if len(row.Handle) == 0 {
+			return "row"
+		}
+		values := make([]string, 0, len(row.Handle)+1)
+		for i, value := range row.Handle {
+			if i < len(row.Columns) {
+				value = row.Columns[i] + "=" + value
+			}
+			values = append(values, value)
+		}
+		if row.HandleTruncated {
+			values = append(values, "...")
+		}
+		return "row_(" + strings.Join(values, ",") + ")"
+	}
+	return fmt.Sprintf("row_%d", row.RowID)
+}
+
+func (e *tidbLabeler) formatter() LabelFormatter {
+	if e.Formatter == nil {
+		return DefaultLabelFormatter{}
+	}
+	return e.Formatter
+}
+
+func newTableLabel(detail *tableDetail) TableLabel {
+	table := TableLabel{
+		DB:       detail.DB,
+		Name:     detail.Name,
+		Table:    detail.Name,
+		Sequence: detail.Kind == tableKindSequence,
+		Dropped:  !detail.DroppedAt.IsZero(),
+	}
+	if len(detail.PartitionPath) > 0 {
+		table.PartitionPath = append([]string(nil), detail.PartitionPath...)
+		table.Table = strings.TrimSuffix(detail.Name, "/"+strings.Join(detail.PartitionPath, "/"))
+	}
+	return table
+}
+
+// tempIndexPrefix marks the temporary index written while an index is being backfilled, with the ID of the
+// index being built in the rest bits. See TempIndexPrefix in the tablecodec package of TiDB.
+const tempIndexPrefix int64 = 0x7fff000000000000
+
+func newIndexLabel(detail *tableDetail, indexID int64) IndexLabel {
+	var index IndexLabel
+	if indexID > 0 && indexID&tempIndexPrefix == tempIndexPrefix {
+		index.Building = true
+		indexID &^= tempIndexPrefix
+	}
+	index.ID = indexID
+	if detail != nil {
+		if name, ok := detail.Indices[indexID]; ok {
+			index.Name = name
+			_, index.Global = detail.GlobalIndices[indexID]
+		}
+	}
+	return index
+}
+
+func newRowLabel(keyInfo KeyInfo, detail *tableDetail) RowLabel {
+	row := RowLabel{RowID: keyInfo.RowID, CommonHandle: keyInfo.IsCommonHandle}
+	if !row.CommonHandle {
+		return row
+	}
+	if detail != nil && detail.CommonHandle {
+		row.Columns = append([]string(nil), detail.PKColumns...)
+	}
+	var complete bool
+	row.Handle, complete = model.DecodeHandleDatums([]byte(keyInfo.Handle))
+	row.HandleTruncated = !complete
+	return row
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-dashboard/util/israce"
+)
+
+// invariantChecks enables the checks of the invariants shared by TableMap and its snapshots, which panic on
+// a violation. They are built into the development builds, by the `decorator_checks` tag or the race
+// detector, and are compiled out otherwise.
+const invariantChecks = checksTag || israce.Enabled
+
+// clone returns a deep copy of the detail.
+func (d *tableDetail) clone() *tableDetail {
+	c := *d
+	c.Indices = make(map[int64]string, len(d.Indices))
+	for id, name := range d.Indices {
+		c.Indices[id] = name
+	}
+	if d.GlobalIndices != nil {
+		c.GlobalIndices = make(map[int64]struct{}, len(d.GlobalIndices))
+		for id := range d.GlobalIndices {
+			c.GlobalIndices[id] = struct{}{}
+		}
+	}
+	c.PKColumns = append([]string(nil), d.PKColumns...)
+	c.PartitionPath = append([]string(nil), d.PartitionPath...)
+	c.PartitionIDs = append([]int64(nil), d.PartitionIDs...)
+	return &c
+}
+
+// checkOrder panics if the details of the snapshot are not sorted by ID, or an ID is not unique.
+func (s *tableSnapshot) checkOrder() {
+	for i := 1; i < len(s.details); i++ {
+		if s.details[i-1].ID >= s.details[i].ID {
+			panic(fmt.Sprintf("the snapshot of generation %d is inconsistent: table %d is followed by table %d",
+				s.gen, s.details[i-1].ID, s.details[i].ID))
+		}
+	}
+}
+
+// checkUnmodified panics if a detail of the snapshot has been modified since the snapshot was taken. As the
+// details are shared with TableMap, such a write races with the Labelers reading the snapshot, see tableDetail.
+func (s *tableSnapshot) checkUnmodified() {
+	for i, detail := range s.details {
+		if !detail.equal(s.copies[i]) {
+			panic(fmt.Sprintf("the detail of table %d is modified after it is stored: a sync must store a new one",
+				detail.ID))
+		}
+	}
+}
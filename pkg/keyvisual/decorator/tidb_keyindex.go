@@ -0,0 +1,205 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// tableKeyIndex is the inverse of TableMap: the key ranges of the tables sorted by their start keys, so that
+// the table owning a region key is found by a binary search without decoding the key. It is immutable once
+// built.
+type tableKeyIndex struct {
+	ranges []tableKeyRange
+}
+
+// tableKeyRange is the range [start, end) of the memcomparable encoded keys of a table. An empty end means
+// the range is unbounded.
+type tableKeyRange struct {
+	start string
+	end   string
+	id    int64
+}
+
+// newTableKeyIndex builds the index of all tables and partitions in the store. The encoding of the table
+// prefix preserves the order of the IDs, so the ranges sorted by ID are also sorted by key and never overlap.
+//
+// The skipped partitions of SkipPartitions are indexed as the key ranges of their tables.
+func newTableKeyIndex(tableMap tableStore) *tableKeyIndex {
+	var ranges []tableKeyRange
+	tableMap.Range(func(id int64, detail *tableDetail) bool {
+		ranges = appendTableKeyRanges(ranges, id, detail)
+		return true
+	})
+	sortTableKeyRanges(ranges)
+	return &tableKeyIndex{ranges: ranges}
+}
+
+// appendTableKeyRanges appends the key ranges of a table, and of its skipped partitions.
+func appendTableKeyRanges(ranges []tableKeyRange, id int64, detail *tableDetail) []tableKeyRange {
+	add := func(keyID int64) {
+		rng := tableKeyRange{start: tableStartKey(keyID), id: id}
+		if keyID < math.MaxInt64 {
+			rng.end = tableStartKey(keyID + 1)
+		}
+		ranges = append(ranges, rng)
+	}
+	add(id)
+	for _, partitionID := range detail.PartitionIDs {
+		add(partitionID)
+	}
+	return ranges
+}
+
+func sortTableKeyRanges(ranges []tableKeyRange) {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+}
+
+// withTables returns a copy of the index with the ranges of the given tables replaced by the ones of their
+// details in the store, in O(n + k log k) for k tables, rather than sorting all ranges again.
+func (idx *tableKeyIndex) withTables(tableMap tableStore, ids []int64) *tableKeyIndex {
+	updated := make(map[int64]struct{}, len(ids))
+	var added []tableKeyRange
+	for _, id := range ids {
+		if _, ok := updated[id]; ok {
+			continue
+		}
+		updated[id] = struct{}{}
+		if detail, ok := tableMap.Load(id); ok {
+			added = appendTableKeyRanges(added, id, detail)
+		}
+	}
+	sortTableKeyRanges(added)
+	ranges := make([]tableKeyRange, 0, len(idx.ranges)+len(added))
+	i := 0
+	for _, rng := range idx.ranges {
+		if _, ok := updated[rng.id]; ok {
+			continue
+		}
+		for i < len(added) && added[i].start < rng.start {
+			ranges = append(ranges, added[i])
+			i++
+		}
+		// A range taken over by an updated table, e.g. a partition no longer skipped.
+		if i < len(added) && added[i].start == rng.start {
+			continue
+		}
+		ranges = append(ranges, rng)
+	}
+	ranges = append(ranges, added[i:]...)
+	return &tableKeyIndex{ranges: ranges}
+}
+
+func tableStartKey(id int64) string {
+	return string(model.EncodeKey(model.GenerateRawTableKey(id, nil)))
+}
+
+// lookup returns the ID of the table whose key range contains the encoded key.
+func (idx *tableKeyIndex) lookup(key string) (int64, bool) {
+	// Find the first range starting after the key; the range before it is the only candidate.
+	i := sort.Search(len(idx.ranges), func(i int) bool {
+		return idx.ranges[i].start > key
+	})
+	if i == 0 {
+		return 0, false
+	}
+	rng := idx.ranges[i-1]
+	if rng.end != "" && key >= rng.end {
+		return 0, false
+	}
+	return rng.id, true
+}
+
+// rebuildKeyIndex swaps in the key index of the current TableMap. It is called with applyMu held, after
+// TableMap is updated. The generation is bumped again, so that the labels cached against the old key index
+// are dropped.
+func (r *TableResolver) rebuildKeyIndex() {
+	r.keyIndex.Store(newTableKeyIndex(r.TableMap))
+	r.tableMapGen.Inc()
+}
+
+// updateKeyIndex swaps in the key index with the ranges of the tables updated by Revalidate, so that a burst of
+// tables fetched one by one, e.g. by a lazy resolver, does not rebuild the whole index for each. It is called
+// with applyMu held, after updateTableMap, which already bumps the generation.
+func (r *TableResolver) updateKeyIndex(ids []int64) {
+	idx := r.loadKeyIndex()
+	if idx == nil {
+		r.keyIndex.Store(newTableKeyIndex(r.TableMap))
+		return
+	}
+	r.keyIndex.Store(idx.withTables(r.TableMap, ids))
+}
+
+// loadKeyIndex returns the key index of TableMap, or nil before TableMap is first updated.
+func (r *TableResolver) loadKeyIndex() *tableKeyIndex {
+	idx, _ := r.keyIndex.Load().(*tableKeyIndex)
+	return idx
+}
+
+// TableIDOfKey returns the ID of the table or partition owning the memcomparable encoded region key, as of
+// the last update of TableMap. It returns false for keys outside all known tables, e.g. meta keys or keys of
+// tables created after the last sync.
+func (r *TableResolver) TableIDOfKey(key []byte) (int64, bool) {
+	return r.KeyIndex().Lookup(key)
+}
+
+// TableKeyRange is the key range of a table or partition exported by KeyIndexSnapshot.
+type TableKeyRange struct {
+	// StartKey and EndKey are the memcomparable encoded keys bounding the range [StartKey, EndKey). An empty
+	// EndKey means the range is unbounded.
+	StartKey []byte `json:"start_key"`
+	EndKey   []byte `json:"end_key"`
+	// TableID is the table owning the range. The range of a skipped partition of SkipPartitions is owned by
+	// its table.
+	TableID int64 `json:"table_id"`
+}
+
+// KeyIndexSnapshot is a read-only view of the key index at the time it is taken, for external code looking
+// up key ranges consistently with the decorator. It is not updated by later syncs, and is safe for
+// concurrent use.
+type KeyIndexSnapshot struct {
+	idx *tableKeyIndex
+}
+
+// KeyIndex returns a snapshot of the key index as of the last update of TableMap. It is empty before
+// TableMap is first updated.
+func (r *TableResolver) KeyIndex() KeyIndexSnapshot {
+	return KeyIndexSnapshot{idx: r.loadKeyIndex()}
+}
+
+// Len returns the number of key ranges.
+func (s KeyIndexSnapshot) Len() int {
+	if s.idx == nil {
+		return 0
+	}
+	return len(s.idx.ranges)
+}
+
+// Ranges returns the key ranges sorted by their start keys, which never overlap. The slice and the keys are
+// copies, so modifying them does not corrupt the index.
+func (s KeyIndexSnapshot) Ranges() []TableKeyRange {
+	ranges := make([]TableKeyRange, 0, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		rng := s.idx.ranges[i]
+		ranges = append(ranges, TableKeyRange{
+			StartKey: []byte(rng.start),
+			EndKey:   []byte(rng.end),
+			TableID:  rng.id,
+		})
+	}
+	return ranges
+}
+
+// Lookup returns the ID of the table or partition owning the memcomparable encoded key, like TableIDOfKey
+// but against the snapshot.
+func (s KeyIndexSnapshot) Lookup(key []byte) (int64, bool) {
+	if s.idx == nil {
+		return 0, false
+	}
+	return s.idx.lookup(string(key))
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"container/list"
+	"sync"
+)
+
+const defaultLabelCacheSize = 1 << 16
+
+// labelCacheEntry is the decoded table ID of a region key and its label, without the region labels.
+type labelCacheEntry struct {
+	key     string
+	tableID int64
+	label   LabelKey
+}
+
+// labelCache keeps the labels of the most recently labeled region keys, as most region keys of a heatmap frame
+// are labeled again in the next frames. It is shared by all Labelers of a strategy.
+//
+// The entries are valid for one generation of TableMap, which is bumped whenever a sync updates TableMap, e.g.
+// after the schema version changes. The first lookup with a newer generation drops all entries.
+type labelCache struct {
+	mu       sync.Mutex
+	capacity int
+	gen      int64
+	entries  map[string]*list.Element
+	// order holds *labelCacheEntry, the most recently used one at the front.
+	order *list.List
+}
+
+func newLabelCache(capacity int) *labelCache {
+	return &labelCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *labelCache) get(key string, gen int64) (labelCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen > c.gen {
+		c.gen = gen
+		c.entries = make(map[string]*list.Element)
+		c.order.Init()
+	}
+	e, ok := c.entries[key]
+	if !ok || gen != c.gen {
+		labelCacheMisses.Inc()
+		return labelCacheEntry{}, false
+	}
+	labelCacheHits.Inc()
+	c.order.MoveToFront(e)
+	return *e.Value.(*labelCacheEntry), true
+}
+
+// add stores an entry labeled against the given generation, unless the cache has moved on to a newer one.
+func (c *labelCache) add(entry *labelCacheEntry, gen int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if e, ok := c.entries[entry.key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+	// The key may share its bytes with a region buffer, see region.String.
+	entry.key = string(append([]byte(nil), entry.key...))
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*labelCacheEntry).key)
+	}
+}
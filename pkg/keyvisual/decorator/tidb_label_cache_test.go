@@ -0,0 +1,106 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+var _ = Suite(&testLabelCacheSuite{})
+
+type testLabelCacheSuite struct{}
+
+func newTestCachedLabeler(tableMap tableLookup, cache *labelCache, gen int64) *tidbLabeler {
+	return &tidbLabeler{TableMap: tableMap, Decoder: NewTiDBKeyDecoder(), Cache: cache, CacheGen: gen}
+}
+
+func (s *testLabelCacheSuite) TestLabelCache(c *C) {
+	tableMap := newSyncMapTableStore()
+	tableMap.Store(10, &tableDetail{ID: 10, DB: "test", Name: "t", Indices: map[int64]string{1: "idx"}})
+	cache := newLabelCache(2)
+	key := string(model.GenerateIndexKey(10, 1))
+
+	labeler := newTestCachedLabeler(tableMap, cache, 1)
+	c.Assert(labeler.label(key).Labels, DeepEquals, []string{"test", "t", "idx"})
+	entry, ok := cache.get(key, 1)
+	c.Assert(ok, IsTrue)
+	c.Assert(entry.tableID, Equals, int64(10))
+
+	// The cached labels are used until the generation changes.
+	tableMap.Store(10, &tableDetail{ID: 10, DB: "test", Name: "t2", Indices: map[int64]string{1: "idx"}})
+	c.Assert(labeler.label(key).Labels, DeepEquals, []string{"test", "t", "idx"})
+	labeler = newTestCachedLabeler(tableMap, cache, 2)
+	c.Assert(labeler.label(key).Labels, DeepEquals, []string{"test", "t2", "idx"})
+
+	// A labeler of an older generation does not pollute the cache.
+	oldLabeler := newTestCachedLabeler(newSyncMapTableStore(), cache, 1)
+	c.Assert(oldLabeler.label(key).Labels, DeepEquals, []string{"table_10", "index_1"})
+	c.Assert(labeler.label(key).Labels, DeepEquals, []string{"test", "t2", "idx"})
+
+	// The region labels are appended to a copy of the cached labels.
+	labeler.RegionLabels = []*regionLabelRange{{StartKey: []byte(key), Label: "k=v"}}
+	c.Assert(labeler.label(key).Labels, DeepEquals, []string{"test", "t2", "idx", "k=v"})
+	entry, _ = cache.get(key, 2)
+	c.Assert(entry.label.Labels, DeepEquals, []string{"test", "t2", "idx"})
+
+	// The least recently used entry is evicted.
+	labeler.RegionLabels = nil
+	labeler.label(string(model.GenerateRowKey(10, 1)))
+	labeler.label(string(model.GenerateRowKey(10, 2)))
+	_, ok = cache.get(key, 2)
+	c.Assert(ok, IsFalse)
+	c.Assert(cache.order.Len(), Equals, 2)
+}
+
+// newBenchmarkLabelKeys returns the keys of n regions spread over 1000 tables, like the keys of a heatmap frame.
+func newBenchmarkLabelKeys(n int) (*tableSnapshot, []string) {
+	tableMap := newSyncMapTableStore()
+	for i := int64(0); i < 1000; i++ {
+		tableMap.Store(i+1, &tableDetail{
+			ID:      i + 1,
+			DB:      "test",
+			Name:    fmt.Sprintf("t%d", i),
+			Indices: map[int64]string{1: "PRIMARY", 2: "idx"},
+		})
+	}
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		tableID := int64(i%1000 + 1)
+		if i%2 == 0 {
+			keys = append(keys, string(model.GenerateRowKey(tableID, int64(i))))
+		} else {
+			keys = append(keys, string(model.GenerateIndexKey(tableID, 2)))
+		}
+	}
+	return newTableSnapshot(tableMap, 1), keys
+}
+
+const benchmarkLabelKeys = 10000
+
+func BenchmarkLabelUncached(b *testing.B) {
+	tables, keys := newBenchmarkLabelKeys(benchmarkLabelKeys)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		labeler := newTestCachedLabeler(tables, nil, 1)
+		labeler.Label(keys)
+	}
+}
+
+func BenchmarkLabelCached(b *testing.B) {
+	tables, keys := newBenchmarkLabelKeys(benchmarkLabelKeys)
+	cache := newLabelCache(defaultLabelCacheSize)
+	hits, misses := testutil.ToFloat64(labelCacheHits), testutil.ToFloat64(labelCacheMisses)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		labeler := newTestCachedLabeler(tables, cache, 1)
+		labeler.Label(keys)
+	}
+	hits, misses = testutil.ToFloat64(labelCacheHits)-hits, testutil.ToFloat64(labelCacheMisses)-misses
+	b.ReportMetric(hits/(hits+misses), "hit-rate")
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// lazyRetryInterval is how long a table that can not be fetched in the lazy mode is labeled by ID only,
+// before it is fetched again.
+const lazyRetryInterval = time.Minute
+
+// lazyTables is the lookup of a lazy TableResolver. The tables not in TableMap are fetched from TiDB one by
+// one on their first lookup, and kept in TableMap for the lifetime of the resolver.
+type lazyTables struct {
+	r *TableResolver
+}
+
+func (t lazyTables) Load(id int64) (*tableDetail, bool) {
+	if detail, ok := t.r.TableMap.Load(id); ok {
+		return detail, true
+	}
+	if t.r.paused.Load() {
+		return nil, false
+	}
+	return t.r.lazy.fetch(t.r, id)
+}
+
+// evictedTables is the lookup of a TableResolver whose TableMap is bounded by MaxTableMapEntries. An evicted
+// table is labeled by ID only on its next lookup, which fetches it from TiDB in the background like a lazy
+// resolver does, so that Labelers never wait for TiDB.
+type evictedTables struct {
+	r     *TableResolver
+	store *lruTableStore
+}
+
+func (t evictedTables) Load(id int64) (*tableDetail, bool) {
+	if detail, ok := t.store.Load(id); ok {
+		return detail, true
+	}
+	if t.store.wasEvicted(id) && !t.r.paused.Load() {
+		t.r.lazy.fetchAsync(t.r, id)
+	}
+	return nil, false
+}
+
+// lazyFetcher fetches the tables of a lazy TableResolver. At most SyncConcurrency fetches are sent at a
+// time, and the concurrent lookups of the same table share one fetch. A lookup finding all workers busy does
+// not wait for them: the table is labeled by ID only, and fetched by a later lookup.
+type lazyFetcher struct {
+	group singleflight.Group
+
+	mu    sync.Mutex
+	slots chan struct{}
+	// failed holds the time of the last failed fetch of each table ID, swept of the ones older than
+	// lazyRetryInterval at most once per lazyRetryInterval.
+	failed    map[int64]time.Time
+	lastSwept time.Time
+}
+
+// acquire takes a worker slot if one is free.
+func (f *lazyFetcher) acquire(r *TableResolver) bool {
+	f.mu.Lock()
+	if f.slots == nil {
+		conc := r.SyncConcurrency
+		if conc < 1 {
+			conc = 1
+		}
+		f.slots = make(chan struct{}, conc)
+	}
+	slots := f.slots
+	f.mu.Unlock()
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *lazyFetcher) release() {
+	<-f.slots
+}
+
+func (f *lazyFetcher) recentlyFailed(id int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	failedAt, ok := f.failed[id]
+	return ok && time.Since(failedAt) < lazyRetryInterval
+}
+
+func (f *lazyFetcher) recordFailure(id int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	if f.failed == nil {
+		f.failed = make(map[int64]time.Time)
+	}
+	if now.Sub(f.lastSwept) >= lazyRetryInterval {
+		for failedID, failedAt := range f.failed {
+			if now.Sub(failedAt) >= lazyRetryInterval {
+				delete(f.failed, failedID)
+			}
+		}
+		f.lastSwept = now
+	}
+	f.failed[id] = now
+}
+
+func (f *lazyFetcher) fetch(r *TableResolver, id int64) (*tableDetail, bool) {
+	if f.recentlyFailed(id) {
+		return nil, false
+	}
+	v, _, _ := f.group.Do(strconv.FormatInt(id, 10), func() (interface{}, error) {
+		// The table may have been fetched by a call just finished.
+		if detail, ok := r.TableMap.Load(id); ok {
+			return detail, nil
+		}
+		if !f.acquire(r) {
+			return nil, nil
+		}
+		defer f.release()
+		return f.revalidate(r, id), nil
+	})
+	detail, _ := v.(*tableDetail)
+	return detail, detail != nil
+}
+
+// fetchAsync fetches the table in the background if a worker slot is free, without waiting for it.
+func (f *lazyFetcher) fetchAsync(r *TableResolver, id int64) {
+	if f.recentlyFailed(id) || !f.acquire(r) {
+		return
+	}
+	go func() {
+		defer f.release()
+		_, _, _ = f.group.Do(strconv.FormatInt(id, 10), func() (interface{}, error) {
+			if detail, ok := r.TableMap.Load(id); ok {
+				return detail, nil
+			}
+			return f.revalidate(r, id), nil
+		})
+	}()
+}
+
+// revalidate fetches the table into TableMap, and returns it, or nil if it is still missing.
+func (f *lazyFetcher) revalidate(r *TableResolver, id int64) *tableDetail {
+	ctx := context.Background()
+	if r.SyncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.SyncTimeout)
+		defer cancel()
+	}
+	if _, err := r.Revalidate(ctx, id); err != nil {
+		syncLogger(ctx).Debug("failed to fetch table lazily", zap.Int64("table-id", id), zap.Error(err))
+	}
+	// A table can still be missing after a successful fetch, e.g. a system table under HiddenTablesSkip.
+	detail, ok := r.TableMap.Load(id)
+	if !ok {
+		f.recordFailure(id)
+		return nil
+	}
+	return detail
+}
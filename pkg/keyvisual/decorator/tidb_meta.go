@@ -0,0 +1,141 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// metaKeyLabels labels the TiDB meta keys by their names, which are defined in meta/meta.go of TiDB.
+// Keep it in sync with TiDB when a meta key is added or renamed.
+var metaKeyLabels = map[string]string{
+	"DDLJobList":       "DDL job queue",
+	"DDLJobAddIdxList": "DDL job queue",
+	"DDLJobHistory":    "DDL job history",
+	"DDLJobReorg":      "DDL reorg",
+	"DBs":              "database info",
+}
+
+// metaDBKeyPrefix is the prefix of the meta key of a database, `DB:<database ID>`, which is a hash of the infos
+// of its tables keyed by `Table:<table ID>`, along with their auto IDs.
+const (
+	metaDBKeyPrefix = "DB:"
+	metaDBKeyLabel  = "table info"
+)
+
+// metaKeyLabel returns the label of a meta key name, or "" if the name is unknown.
+func metaKeyLabel(name string) string {
+	if id := strings.TrimPrefix(name, metaDBKeyPrefix); id != name {
+		if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+			return metaDBKeyLabel
+		}
+		return ""
+	}
+	return metaKeyLabels[name]
+}
+
+// autoIDFieldLabels label the fields of a database hash holding the auto ID allocators of its tables, by the
+// prefixes of `<prefix>:<table ID>` defined in meta/meta.go of TiDB. A hot allocator is contended by heavy
+// inserts, while the data of the table may be cold.
+var autoIDFieldLabels = map[string]string{
+	"TID":           "auto-id allocator",
+	"IID":           "auto-increment allocator",
+	"TARID":         "auto-random allocator",
+	"SID":           "sequence allocator",
+	"SequenceCycle": "sequence allocator",
+}
+
+// autoIDAllocator returns the label of an auto ID allocator and the ID of the table owning it, if the meta key
+// is one.
+func autoIDAllocator(name, field string) (string, int64, bool) {
+	if !strings.HasPrefix(name, metaDBKeyPrefix) {
+		return "", 0, false
+	}
+	i := strings.IndexByte(field, ':')
+	if i < 0 {
+		return "", 0, false
+	}
+	label, ok := autoIDFieldLabels[field[:i]]
+	if !ok {
+		return "", 0, false
+	}
+	tableID, err := strconv.ParseInt(field[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return label, tableID, true
+}
+
+const statsLabel = "statistics"
+
+// statsTables are the tables of the `mysql` database written by ANALYZE and the statistics maintenance of TiDB,
+// so that their hotspots are attributed to statistics collection rather than user writes.
+var statsTables = map[string]struct{}{
+	"stats_meta":         {},
+	"stats_meta_history": {},
+	"stats_histograms":   {},
+	"stats_buckets":      {},
+	"stats_top_n":        {},
+	"stats_fm_sketch":    {},
+	"stats_feedback":     {},
+	"stats_extended":     {},
+	"stats_history":      {},
+	"stats_table_locked": {},
+	"column_stats_usage": {},
+	"analyze_jobs":       {},
+	"analyze_options":    {},
+}
+
+func isStatsTable(detail *tableDetail) bool {
+	return isMySQLTable(detail, statsTables)
+}
+
+// keepsStatsTables reports whether the statistics tables of the database are kept by StatsTables while the
+// other tables are skipped.
+func (r *TableResolver) keepsStatsTables(dbName string) bool {
+	return r.StatsTables && r.HiddenTables == HiddenTablesSkip && strings.EqualFold(dbName, "mysql")
+}
+
+const resourceControlLabel = "resource_control"
+
+// resourceControlTables are the tables of the `mysql` database written by resource control, i.e. the runaway
+// queries, their watches and quarantines, and the request units consumed by each resource group.
+var resourceControlTables = map[string]struct{}{
+	"tidb_runaway_queries":           {},
+	"tidb_runaway_watch":             {},
+	"tidb_runaway_watch_done":        {},
+	"tidb_runaway_quarantined_watch": {},
+	"request_unit_by_group":          {},
+}
+
+func isResourceControlTable(detail *tableDetail) bool {
+	return isMySQLTable(detail, resourceControlTables)
+}
+
+const bindingLabel = "sql_binding"
+
+// bindingTables are the tables of the `mysql` database holding the SQL plan bindings, and the tables excluded
+// from capturing them. TiDB keeps no plan cache in tables.
+var bindingTables = map[string]struct{}{
+	"bind_info":                        {},
+	"capture_plan_baselines_blacklist": {},
+}
+
+func isBindingTable(detail *tableDetail) bool {
+	return isMySQLTable(detail, bindingTables)
+}
+
+// isMySQLTable reports whether the detail is of a table in the `mysql` database whose lower case name is in
+// the set. The raw names are checked, so that NormalizeName never hides a system table.
+func isMySQLTable(detail *tableDetail, tables map[string]struct{}) bool {
+	return isMySQLTableName(detail.RawDB, detail.RawName, tables)
+}
+
+func isMySQLTableName(dbName, tableName string, tables map[string]struct{}) bool {
+	if !strings.EqualFold(dbName, "mysql") {
+		return false
+	}
+	_, ok := tables[strings.ToLower(tableName)]
+	return ok
+}
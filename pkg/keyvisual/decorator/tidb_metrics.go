@@ -0,0 +1,265 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "keyvisual"
+	metricsSubsystem = "decorator"
+)
+
+var (
+	registerMetricsOnce sync.Once
+
+	// syncAgeMetrics exposes `seconds_since_last_sync` of the running resolvers by their Clusters, so that a
+	// stuck resolver is not hidden by a healthy one in the same process.
+	syncAgeMetrics = newSyncAgeCollector()
+
+	secondsSinceLastSyncDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "seconds_since_last_sync"),
+		"Seconds since the last fully successful schema sync of the TiDB label strategy.",
+		[]string{"cluster"}, nil)
+
+	labelCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "label_cache_requests_total",
+		Help:      "Lookups of the label cache of the TiDB label strategy, by result.",
+	}, []string{"result"})
+	labelCacheHits   = labelCacheRequests.WithLabelValues("hit")
+	labelCacheMisses = labelCacheRequests.WithLabelValues("miss")
+
+	watchReconnections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "watch_reconnections_total",
+		Help:      "Re-establishments of the watches in etcd, after they are closed, failed or unresponsive, by the key watched.",
+	}, []string{"cluster", "key"})
+
+	// syncDurations carries the ID of each sync as the `sync_id` exemplar, so that a slow sync on a graph
+	// leads to its logs. The exemplars are only exposed to the scrapes negotiating OpenMetrics, which requires
+	// the handler of the default registry, served by the process embedding the dashboard rather than by the
+	// dashboard, to be created with promhttp.HandlerOpts.EnableOpenMetrics set. They are dropped otherwise.
+	syncDurations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of the schema syncs of the TiDB label strategy, by result.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{"cluster", "result"})
+
+	syncFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "sync_failures_total",
+		Help:      "Failed schema syncs of the TiDB label strategy, by the type of the error, e.g. etcd_unavailable.",
+	}, []string{"cluster", "reason"})
+
+	// syncPaths counts the syncs fetching the tables by the path of SyncResult, so that a sync skipped for an
+	// unchanged schema version is not counted.
+	syncPaths = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "sync_paths_total",
+		Help:      "Schema syncs of the TiDB label strategy fetching the tables, by how they are fetched.",
+	}, []string{"cluster", "path"})
+
+	tableMapEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "table_map_entries",
+		Help:      "Tables and partitions in the table map of the TiDB label strategy after the last schema sync.",
+	}, []string{"cluster"})
+
+	syncPhaseDurations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "sync_phase_duration_seconds",
+		Help:      "Duration of the phases of the schema syncs of the TiDB label strategy, by phase.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 18),
+	}, []string{"cluster", "phase"})
+
+	clockSkew = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "clock_skew_seconds",
+		Help:      "Seconds the clock of TiDB is ahead of the one of the dashboard, as seen by the last schema sync checking it.",
+	}, []string{"cluster"})
+
+	// tableInfoMetrics exposes the tables of the resolvers running with TableInfoMetricsLimit set, so that the
+	// table IDs shown by Key Visualizer can be joined against the table names in Grafana.
+	tableInfoMetrics = newTableInfoCollector()
+
+	tableInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "table_info"),
+		"Tables and partitions in the table map of the TiDB label strategy, always 1.",
+		[]string{"cluster", "table_id", "db", "table"}, nil)
+	tableInfoOmittedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "table_info_omitted"),
+		"Tables and partitions not exposed as table_info, since the limit of the TiDB label strategy is reached.",
+		[]string{"cluster"}, nil)
+)
+
+// The phases of sync_phase_duration_seconds.
+const (
+	syncPhaseEtcd     = "etcd"
+	syncPhaseSchema   = "schema"
+	syncPhaseSchemaDB = "schema_db"
+	syncPhaseApply    = "apply"
+	// syncPhaseDBTable is the `/db-table/{id}` requests of a batch of stale tables revalidated, which are
+	// sent between the syncs.
+	syncPhaseDBTable = "db_table"
+)
+
+// syncFailureReasons are the error types counted by sync_failures_total, any other error is counted as `other`.
+var syncFailureReasons = []*errorx.Type{ErrEtcdUnavailable, ErrTiDBUnavailable, ErrParseFailed, ErrDuplicateTableID}
+
+// syncFailureReason returns the reason of a failed sync in sync_failures_total.
+func syncFailureReason(err error) string {
+	for _, typ := range syncFailureReasons {
+		if errorx.IsOfType(err, typ) {
+			return strings.TrimPrefix(typ.FullName(), ErrNSDecorator.FullName()+".")
+		}
+	}
+	return "other"
+}
+
+// observeSyncDuration records the duration of a sync of the resolver with its ID as the exemplar, and counts its
+// path and its failure.
+func (r *TableResolver) observeSyncDuration(result SyncResult) {
+	observer := syncDurations.WithLabelValues(r.Cluster, "ok")
+	if result.Err != nil {
+		observer = syncDurations.WithLabelValues(r.Cluster, "error")
+		syncFailures.WithLabelValues(r.Cluster, syncFailureReason(result.Err)).Inc()
+	}
+	if result.Path != "" {
+		syncPaths.WithLabelValues(r.Cluster, result.Path).Inc()
+	}
+	observer.(prometheus.ExemplarObserver).ObserveWithExemplar(result.Duration.Seconds(),
+		prometheus.Labels{"sync_id": result.ID})
+	for _, phase := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{syncPhaseEtcd, result.Phases.Etcd},
+		{syncPhaseSchema, result.Phases.Schema},
+		{syncPhaseSchemaDB, result.Phases.SchemaDB},
+		{syncPhaseApply, result.Phases.Apply},
+	} {
+		if phase.duration > 0 {
+			syncPhaseDurations.WithLabelValues(r.Cluster, phase.name).Observe(phase.duration.Seconds())
+		}
+	}
+}
+
+// tableCount counts the entries in TableMap, by the committed snapshot if it is up to date. The resolvers setting
+// table_map_entries in the same process must have different Clusters.
+func (r *TableResolver) tableCount() int {
+	if snapshot, ok := r.snapshotCache.Load().(*tableSnapshot); ok && snapshot.gen == r.tableMapGen.Load() {
+		return len(snapshot.details)
+	}
+	count := 0
+	r.TableMap.Range(func(int64, *tableDetail) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// registerMetrics registers the decorator metrics to the default registry.
+// It is safe to be called multiple times.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		prometheus.MustRegister(syncAgeMetrics, labelCacheRequests, syncDurations, syncPhaseDurations,
+			tableInfoMetrics, watchReconnections, clockSkew, syncFailures, syncPaths, tableMapEntries)
+	})
+}
+
+// resolverCollector collects the series of the resolvers added to it, taken by collect on each scrape. The
+// resolvers added must have different Clusters.
+type resolverCollector struct {
+	mu        sync.Mutex
+	resolvers map[*TableResolver]struct{}
+	collect   func(r *TableResolver, ch chan<- prometheus.Metric)
+	descs     []*prometheus.Desc
+}
+
+func newResolverCollector(collect func(r *TableResolver, ch chan<- prometheus.Metric), descs ...*prometheus.Desc) *resolverCollector {
+	return &resolverCollector{resolvers: make(map[*TableResolver]struct{}), collect: collect, descs: descs}
+}
+
+func (c *resolverCollector) add(r *TableResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolvers[r] = struct{}{}
+}
+
+func (c *resolverCollector) remove(r *TableResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.resolvers, r)
+}
+
+func (c *resolverCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+}
+
+func (c *resolverCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for r := range c.resolvers {
+		c.collect(r, ch)
+	}
+}
+
+// newSyncAgeCollector returns a collector of `seconds_since_last_sync`.
+func newSyncAgeCollector() *resolverCollector {
+	return newResolverCollector(func(r *TableResolver, ch chan<- prometheus.Metric) {
+		ch <- prometheus.MustNewConstMetric(secondsSinceLastSyncDesc, prometheus.GaugeValue,
+			time.Since(r.lastSyncSuccess.Load()).Seconds(), r.Cluster)
+	}, secondsSinceLastSyncDesc)
+}
+
+// newTableInfoCollector returns a collector of `table_info`. The series of a resolver are taken from its
+// TableMap, at most TableInfoMetricsLimit of them with the smallest IDs.
+func newTableInfoCollector() *resolverCollector {
+	return newResolverCollector((*TableResolver).collectTableInfo, tableInfoDesc, tableInfoOmittedDesc)
+}
+
+// collectTableInfo sends the `table_info` series of the tables in TableMap, and the number of tables omitted.
+func (r *TableResolver) collectTableInfo(ch chan<- prometheus.Metric) {
+	var details []*tableDetail
+	if snapshot, ok := r.tables().(*tableSnapshot); ok {
+		details = snapshot.details
+	} else {
+		r.TableMap.Range(func(_ int64, detail *tableDetail) bool {
+			details = append(details, detail)
+			return true
+		})
+		sort.Slice(details, func(i, j int) bool {
+			return details[i].ID < details[j].ID
+		})
+	}
+	omitted := 0
+	if limit := r.TableInfoMetricsLimit; len(details) > limit {
+		omitted = len(details) - limit
+		details = details[:limit]
+	}
+	for _, detail := range details {
+		ch <- prometheus.MustNewConstMetric(tableInfoDesc, prometheus.GaugeValue, 1,
+			r.Cluster, strconv.FormatInt(detail.ID, 10), detail.DB, detail.Name)
+	}
+	ch <- prometheus.MustNewConstMetric(tableInfoOmittedDesc, prometheus.GaugeValue, float64(omitted), r.Cluster)
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+// SnapshotStore keeps the snapshot of TableMap across restarts, so that keys can be labeled before the first
+// sync after a restart finishes. It can be backed by the dashboard storage, see NewDBSnapshotStore, a file or
+// an object store.
+type SnapshotStore interface {
+	// Load returns the snapshot stored last, or nil if none is stored.
+	Load(ctx context.Context) ([]byte, error)
+	// Store replaces the stored snapshot.
+	Store(ctx context.Context, snapshot []byte) error
+}
+
+// persistFormatV2 is the first byte of the data stored into SnapshotStore:
+//
+//	version byte | cluster string | fingerprint uvarint | schema version varint | table snapshot
+//
+// where the table snapshot is encoded by encodeTableSnapshot, the schema version is the one of its tables, and the
+// fingerprint is the one of the tunables resolving them, see snapshotFingerprint. The snapshots of persistFormatV1
+// carry neither the cluster nor the fingerprint, and fail to decode.
+const persistFormatV2 byte = 2
+
+// persistedSnapshot is the header and the table snapshot of the data stored into SnapshotStore.
+type persistedSnapshot struct {
+	cluster       string
+	fingerprint   uint64
+	schemaVersion int64
+	snapshot      []byte
+}
+
+func encodePersistedSnapshot(p persistedSnapshot) []byte {
+	e := snapshotEncoder{buf: make([]byte, 0, len(p.snapshot)+len(p.cluster)+3*binary.MaxVarintLen64+1)}
+	e.buf = append(e.buf, persistFormatV2)
+	e.string(p.cluster)
+	e.uvarint(p.fingerprint)
+	e.varint(p.schemaVersion)
+	return append(e.buf, p.snapshot...)
+}
+
+func decodePersistedSnapshot(data []byte) (persistedSnapshot, error) {
+	if len(data) == 0 || data[0] != persistFormatV2 {
+		return persistedSnapshot{}, ErrParseFailed.New("unsupported persisted snapshot format")
+	}
+	d := snapshotDecoder{buf: data[1:]}
+	p := persistedSnapshot{cluster: d.string(), fingerprint: d.uvarint(), schemaVersion: d.varint()}
+	if d.err != nil {
+		return persistedSnapshot{}, d.err
+	}
+	p.snapshot = d.buf
+	return p, nil
+}
+
+// snapshotFingerprint hashes the tunables deciding which tables are stored into TableMap and how, e.g.
+// SkipPartitions. A snapshot persisted under other tunables holds tables this resolver would resolve differently.
+func (r *TableResolver) snapshotFingerprint() uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%t|%t|%t|%t|%s|%t|%s|%s|%s",
+		r.HiddenTables, r.StatsTables, r.ReorgPartitions, r.SkipPartitions, r.SkipDeleteOnlyTables,
+		r.EmptyDBName, r.CaseInsensitiveLookups, r.DuplicateTableIDs, r.InconsistentIndexIDs, r.SchemaPathName)
+	return h.Sum64()
+}
+
+const tableSnapshotModelName = "keyviz_decorator_snapshot"
+
+// snapshotModelID is the ID of the single row holding the snapshot.
+const snapshotModelID = 1
+
+type snapshotModel struct {
+	ID        uint `gorm:"primaryKey"`
+	Snapshot  []byte
+	UpdatedAt time.Time
+}
+
+func (snapshotModel) TableName() string {
+	return tableSnapshotModelName
+}
+
+type dbSnapshotStore struct {
+	db *dbstore.DB
+}
+
+// NewDBSnapshotStore creates a SnapshotStore backed by the dashboard storage.
+func NewDBSnapshotStore(db *dbstore.DB) (SnapshotStore, error) {
+	if err := db.AutoMigrate(&snapshotModel{}); err != nil {
+		return nil, err
+	}
+	return &dbSnapshotStore{db: db}, nil
+}
+
+func (s *dbSnapshotStore) Load(ctx context.Context) ([]byte, error) {
+	var m snapshotModel
+	err := s.db.WithContext(ctx).First(&m, snapshotModelID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.Snapshot, nil
+}
+
+func (s *dbSnapshotStore) Store(ctx context.Context, snapshot []byte) error {
+	return s.db.WithContext(ctx).Save(&snapshotModel{ID: snapshotModelID, Snapshot: snapshot}).Error
+}
+
+// restoreSnapshot stores the tables of the snapshot in SnapshotStore into TableMap, unless TableMap is
+// already filled, e.g. by Preload. Their schema version is restored as applied, like Preload, so the first sync
+// only fetches the tables again if the version in etcd has changed since. A snapshot of another cluster is
+// skipped, and one persisted under other tunables, see snapshotFingerprint, labels the keys until the first sync
+// fetches the tables again. A snapshot failing to decode is skipped, leaving the resolver cold.
+func (r *TableResolver) restoreSnapshot(ctx context.Context) {
+	if r.SnapshotStore == nil || r.initialized.Load() {
+		return
+	}
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+	empty := true
+	r.TableMap.Range(func(int64, *tableDetail) bool {
+		empty = false
+		return false
+	})
+	if !empty {
+		return
+	}
+	data, err := r.SnapshotStore.Load(ctx)
+	if err != nil {
+		log.Warn("failed to load the snapshot of tidb tables", zap.Error(err))
+		return
+	}
+	if data == nil {
+		return
+	}
+	persisted, err := decodePersistedSnapshot(data)
+	if err == nil && persisted.cluster != r.Cluster {
+		err = ErrParseFailed.New("the snapshot is of cluster %q", persisted.cluster)
+	}
+	if err == nil {
+		err = decodeTableSnapshot(persisted.snapshot, r.TableMap)
+	}
+	if err != nil {
+		log.Warn("failed to restore the snapshot of tidb tables", zap.Error(err))
+		return
+	}
+	r.tableMapGen.Inc()
+	r.rebuildKeyIndex()
+	r.commitSnapshot()
+	stale := persisted.fingerprint != r.snapshotFingerprint()
+	if persisted.schemaVersion != -1 && !stale {
+		r.schemaVersion.Store(persisted.schemaVersion)
+		r.retainVersion(persisted.schemaVersion)
+		r.markInitialized()
+	}
+	log.Info("restored the snapshot of tidb tables", zap.Int("size", len(data)),
+		zap.Int64("version", persisted.schemaVersion), zap.Bool("stale", stale))
+}
+
+// persistSnapshot stores the snapshot of TableMap into SnapshotStore after a sync has applied a schema
+// version.
+func (r *TableResolver) persistSnapshot(ctx context.Context, result SyncResult) {
+	if r.SnapshotStore == nil || result.Err != nil || result.Version == -1 {
+		return
+	}
+	if err := r.SnapshotStore.Store(ctx, encodePersistedSnapshot(persistedSnapshot{
+		cluster:       r.Cluster,
+		fingerprint:   r.snapshotFingerprint(),
+		schemaVersion: result.Version,
+		snapshot:      r.Snapshot(),
+	})); err != nil {
+		log.Warn("failed to persist the snapshot of tidb tables", zap.Error(err))
+	}
+}
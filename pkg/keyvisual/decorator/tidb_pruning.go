@@ -0,0 +1,112 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sort"
+	"strings"
+)
+
+// PartitionResolution is a partition of a table resolved by ResolvePartitions.
+type PartitionResolution struct {
+	TableInfo
+	// Involved is whether the partition is one of the targets, or a subpartition of one of them.
+	Involved bool `json:"involved"`
+}
+
+// PartitionPruning is the result of ResolvePartitions.
+type PartitionPruning struct {
+	Table TableInfo `json:"table"`
+	// Partitions are all partitions of the table, sorted by ID.
+	Partitions []PartitionResolution `json:"partitions"`
+	// Unknown are the targets which are not partitions of the table, sorted.
+	Unknown []int64 `json:"unknown"`
+}
+
+// ResolvePartitions resolves the partitions of a partitioned table, marking the targets, e.g. the partitions
+// left by the partition pruning of a query plan, as involved and the others as not involved. A subpartition is
+// involved if one of its parent partitions is targeted. tableID may be the ID of a partition as well, in which
+// case its partitioned table is resolved. It returns false if the table is not resolved.
+//
+// Under SkipPartitions, the partitions are not resolved by name, so each one is the table under its own ID.
+// Under Lazy, only the partitions fetched so far are known.
+func (r *TableResolver) ResolvePartitions(tableID int64, targets []int64) (PartitionPruning, bool) {
+	tables := r.tables()
+	detail, ok := tables.Load(tableID)
+	if ok && detail.ParentID != 0 {
+		detail, ok = tables.Load(detail.ParentID)
+	}
+	if !ok {
+		return PartitionPruning{}, false
+	}
+	pruning := PartitionPruning{
+		Table:      detail.toTableInfo(),
+		Partitions: []PartitionResolution{},
+		Unknown:    []int64{},
+	}
+	pruning.Table.Cluster = r.Cluster
+
+	var partitions []*tableDetail
+	if len(detail.PartitionIDs) > 0 {
+		for _, id := range detail.PartitionIDs {
+			partitions = append(partitions, &tableDetail{
+				ID:       id,
+				Name:     detail.Name,
+				DB:       detail.DB,
+				Indices:  detail.Indices,
+				ParentID: detail.ID,
+				DDLState: detail.DDLState,
+			})
+		}
+	} else {
+		collect := func(partition *tableDetail) {
+			if partition.ParentID == detail.ID {
+				partitions = append(partitions, partition)
+			}
+		}
+		if snapshot, ok := tables.(*tableSnapshot); ok {
+			for _, partition := range snapshot.details {
+				collect(partition)
+			}
+		} else {
+			r.TableMap.Range(func(_ int64, partition *tableDetail) bool {
+				collect(partition)
+				return true
+			})
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].ID < partitions[j].ID
+	})
+
+	unknown := make(map[int64]struct{}, len(targets))
+	for _, id := range targets {
+		unknown[id] = struct{}{}
+	}
+	targeted := make(map[int64]struct{}, len(targets))
+	// targetPaths are the partition paths of the targets, to involve their subpartitions as well.
+	targetPaths := make(map[string]struct{}, len(targets))
+	for _, partition := range partitions {
+		if _, ok := unknown[partition.ID]; ok {
+			delete(unknown, partition.ID)
+			targeted[partition.ID] = struct{}{}
+			targetPaths[strings.Join(partition.PartitionPath, "/")] = struct{}{}
+		}
+	}
+	for _, partition := range partitions {
+		resolution := PartitionResolution{TableInfo: partition.toTableInfo()}
+		resolution.Cluster = r.Cluster
+		_, resolution.Involved = targeted[partition.ID]
+		for i := 1; i < len(partition.PartitionPath) && !resolution.Involved; i++ {
+			_, resolution.Involved = targetPaths[strings.Join(partition.PartitionPath[:i], "/")]
+		}
+		pruning.Partitions = append(pruning.Partitions, resolution)
+	}
+	for id := range unknown {
+		pruning.Unknown = append(pruning.Unknown, id)
+	}
+	sort.Slice(pruning.Unknown, func(i, j int) bool {
+		return pruning.Unknown[i] < pruning.Unknown[j]
+	})
+	return pruning, true
+}
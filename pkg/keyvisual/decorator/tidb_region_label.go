@@ -0,0 +1,118 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/util/distro"
+)
+
+const (
+	regionLabelRulesPath    = "/config/region-label/rules"
+	regionLabelRuleKeyRange = "key-range"
+)
+
+// pdAPIClient is the subset of *pd.Client used to request the PD API.
+type pdAPIClient interface {
+	SendGetRequest(relativeURI string) ([]byte, error)
+}
+
+type regionLabelRule struct {
+	ID     string `json:"id"`
+	Labels []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"labels"`
+	RuleType string          `json:"rule_type"`
+	Data     json.RawMessage `json:"data"`
+}
+
+type regionLabelKeyRange struct {
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+}
+
+// regionLabelRange is a key range of encoded region keys carrying a PD region label.
+type regionLabelRange struct {
+	StartKey []byte
+	EndKey   []byte // empty means the end of the key space
+	Label    string
+}
+
+func (r *regionLabelRange) contains(key []byte) bool {
+	return bytes.Compare(key, r.StartKey) >= 0 && (len(r.EndKey) == 0 || bytes.Compare(key, r.EndKey) < 0)
+}
+
+// syncRegionLabels fetches the region label rules from PD every SyncInterval until ctx is done.
+func (s *tidbLabelStrategy) syncRegionLabels(ctx context.Context) {
+	ticker := time.NewTicker(s.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.updateRegionLabels()
+		}
+	}
+}
+
+// updateRegionLabels fetches the key-range region label rules from PD.
+func (s *tidbLabelStrategy) updateRegionLabels() {
+	data, err := s.pdClient.SendGetRequest(regionLabelRulesPath)
+	if err != nil {
+		log.Warn("fail to send region label rules request", zap.String("component", distro.R().PD), zap.Error(err))
+		return
+	}
+	var rules []*regionLabelRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Warn("fail to unmarshal region label rules", zap.String("component", distro.R().PD), zap.Error(err))
+		return
+	}
+	s.regionLabels.Store(parseRegionLabelRules(rules))
+}
+
+func parseRegionLabelRules(rules []*regionLabelRule) []*regionLabelRange {
+	var ranges []*regionLabelRange
+	for _, rule := range rules {
+		if rule.RuleType != regionLabelRuleKeyRange || len(rule.Labels) == 0 {
+			continue
+		}
+		var keyRanges []regionLabelKeyRange
+		if err := json.Unmarshal(rule.Data, &keyRanges); err != nil {
+			log.Debug("skip region label rule with invalid data", zap.String("rule", rule.ID), zap.Error(err))
+			continue
+		}
+		for _, keyRange := range keyRanges {
+			startKey, err1 := hex.DecodeString(keyRange.StartKey)
+			endKey, err2 := hex.DecodeString(keyRange.EndKey)
+			if err1 != nil || err2 != nil {
+				log.Debug("skip region label rule with invalid key", zap.String("rule", rule.ID))
+				continue
+			}
+			for _, label := range rule.Labels {
+				ranges = append(ranges, &regionLabelRange{
+					StartKey: startKey,
+					EndKey:   endKey,
+					Label:    fmt.Sprintf("%s=%s", label.Key, label.Value),
+				})
+			}
+		}
+	}
+	return ranges
+}
+
+// loadRegionLabels returns the last fetched ranges, or nil if none.
+func (s *tidbLabelStrategy) loadRegionLabels() []*regionLabelRange {
+	ranges, _ := s.regionLabels.Load().([]*regionLabelRange)
+	return ranges
+}
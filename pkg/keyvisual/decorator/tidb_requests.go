@@ -3,120 +3,989 @@
 package decorator
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/joomcode/errorx"
 	"github.com/pingcap/log"
 	"go.uber.org/zap"
 
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
 	"github.com/pingcap/tidb-dashboard/util/distro"
 )
 
 const (
 	schemaVersionPath = "/tidb/ddl/global_schema_version"
-	etcdGetTimeout    = time.Second
+	// defaultEtcdGetTimeout is the default EtcdGetTimeout.
+	defaultEtcdGetTimeout = time.Second
+
+	// defaultRetryAfter is the pause after a 429 response without a valid Retry-After header.
+	defaultRetryAfter = time.Second
+	// maxRetryAfter bounds the pause asked by a 429 response, so that a sync can not be stalled for long.
+	maxRetryAfter = 30 * time.Second
+
+	// syncPathSchema means TableMap is synced by walking the `/schema` API of all databases.
+	syncPathSchema = "schema"
 )
 
 var (
-	ErrNS          = errorx.NewNamespace("error.keyvisual")
-	ErrNSDecorator = ErrNS.NewSubNamespace("decorator")
-	ErrInvalidData = ErrNSDecorator.NewType("invalid_data")
+	ErrNS              = errorx.NewNamespace("error.keyvisual")
+	ErrNSDecorator     = ErrNS.NewSubNamespace("decorator")
+	ErrEtcdUnavailable = ErrNSDecorator.NewType("etcd_unavailable")
+	ErrTiDBUnavailable = ErrNSDecorator.NewType("tidb_unavailable")
+	ErrParseFailed     = ErrNSDecorator.NewType("parse_failed")
+	ErrInvalidKey      = ErrNSDecorator.NewType("invalid_key")
+	ErrInvalidConfig   = ErrNSDecorator.NewType("invalid_config")
+	// ErrDuplicateTableID is the error of a sync seeing a table ID more than once under DuplicateError.
+	ErrDuplicateTableID = ErrNSDecorator.NewType("duplicate_table_id")
 )
 
-func (s *tidbLabelStrategy) updateMap(ctx context.Context) {
+// syncSummary records the changes applied to TableMap by a sync.
+type syncSummary struct {
+	Added      int
+	Removed    int
+	Changed    int
+	Partitions int
+
+	seen       map[int64]struct{}
+	addedIDs   []int64
+	changedIDs []int64
+	// duplicateErr is the error of the first duplicated table ID under DuplicateError.
+	duplicateErr error
+	// audited is set if the changes are recorded into audit for AuditSink.
+	audited bool
+	audit   []auditChange
+}
+
+func newSyncSummary() *syncSummary {
+	return &syncSummary{seen: make(map[int64]struct{})}
+}
+
+// store stores the detail into TableMap and counts it as added or changed by comparing with the previous one.
+func (summary *syncSummary) store(tableMap tableStore, detail *tableDetail) {
+	summary.seen[detail.ID] = struct{}{}
+	if old, ok := tableMap.Load(detail.ID); !ok {
+		summary.Added++
+		summary.addedIDs = append(summary.addedIDs, detail.ID)
+		summary.recordAudit(time.Now(), AuditAdded, detail.ID, nil, detail)
+	} else if !old.equal(detail) {
+		summary.Changed++
+		summary.changedIDs = append(summary.changedIDs, detail.ID)
+		action := AuditChanged
+		if old.DB != detail.DB || old.Name != detail.Name {
+			action = AuditRenamed
+		}
+		summary.recordAudit(time.Now(), action, detail.ID, old, detail)
+	}
+	tableMap.Store(detail.ID, detail)
+}
+
+// countRemoved counts the tables in TableMap which are not seen by this sync, except the ones marked as dropped,
+// which are counted by pruneDropped. It is only meaningful after a complete sync.
+func (summary *syncSummary) countRemoved(tableMap tableStore) {
+	tableMap.Range(func(id int64, detail *tableDetail) bool {
+		if _, ok := summary.seen[id]; !ok && detail.DroppedAt.IsZero() {
+			summary.Removed++
+		}
+		return true
+	})
+}
+
+// pruneDropped removes the tables in TableMap which are not seen by this sync, and counts them as removed. The
+// partitions of a removed table are removed by their ParentID as well, even if seen. If grace is positive, the
+// tables are instead marked as dropped at now, and only removed once grace has passed since then. A table is
+// counted once, by the sync finding it dropped, rather than by each sync keeping it or by the one removing it.
+//
+// It must only be called after a complete sync, as the tables of a database failed to fetch are not seen
+// either. Pruning bounds TableMap by the live schema, rather than growing with every table dropped while the
+// resolver runs, at the cost of the keys of a dropped table, which stay until the GC deletes them, being
+// labeled by table ID only once it is removed.
+func (summary *syncSummary) pruneDropped(tableMap tableStore, now time.Time, grace time.Duration) {
+	// The tables are looked up by Range rather than Load, which would make the dropped tables the most recently
+	// used ones of an LRU TableMap.
+	details := make(map[int64]*tableDetail)
+	tableMap.Range(func(id int64, detail *tableDetail) bool {
+		if _, ok := summary.seen[id]; !ok {
+			details[id] = detail
+		}
+		return true
+	})
+	tableMap.Range(func(id int64, detail *tableDetail) bool {
+		if _, ok := details[detail.ParentID]; ok && detail.ParentID != 0 {
+			details[id] = detail
+		}
+		return true
+	})
+	ids := make([]int64, 0, len(details))
+	for id := range details {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	removed := 0
+	for _, id := range ids {
+		delete(summary.seen, id)
+		detail := details[id]
+		switch {
+		case grace > 0 && detail.DroppedAt.IsZero():
+			marked := *detail
+			marked.DroppedAt = now
+			replaceTable(tableMap, id, &marked)
+			removed++
+			summary.recordAudit(now, AuditDropped, id, detail, &marked)
+		case grace > 0 && now.Sub(detail.DroppedAt) < grace:
+		default:
+			tableMap.Delete(id)
+			if detail.DroppedAt.IsZero() {
+				removed++
+			}
+			summary.recordAudit(now, AuditRemoved, id, detail, nil)
+		}
+	}
+	summary.Removed += removed
+	if store, ok := tableMap.(*lruTableStore); ok {
+		store.forgetEvicted(summary.seen)
+	}
+}
+
+// syncLoggerKey is the context key of the logger of a sync, which carries the ID of the sync.
+type syncLoggerKey struct{}
+
+func syncLogger(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(syncLoggerKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return log.L()
+}
+
+// SyncResult is the outcome of a schema sync.
+type SyncResult struct {
+	// ID identifies the sync. All logs of the sync carry it in the `sync-id` field.
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	// Version is the schema version applied by the sync. It is -1 if nothing is applied, because the
+	// version has not changed, TiDB is not ready yet, or the sync failed.
+	Version int64 `json:"version"`
+	// Path tells how the tables are fetched, e.g. "schema". It is empty if the sync stops before fetching.
+	Path       string `json:"path"`
+	Added      int    `json:"added"`
+	Removed    int    `json:"removed"`
+	Changed    int    `json:"changed"`
+	Partitions int    `json:"partitions"`
+	// Resumed is the number of databases not fetched again, as they are fetched by the previous syncs failing
+	// half way toward the same version.
+	Resumed  int           `json:"resumed"`
+	Duration time.Duration `json:"duration"`
+	// Phases breaks Duration down by the phases of the sync.
+	Phases SyncPhases `json:"phases"`
+	// Err is one of ErrEtcdUnavailable, ErrTiDBUnavailable, ErrParseFailed and ErrDuplicateTableID. The tables
+	// of the databases fetched successfully are still applied and counted.
+	Err error `json:"-"`
+}
+
+// SyncPhases are the durations of the phases of a sync. A phase the sync stops before is zero.
+type SyncPhases struct {
+	// Etcd is the get of the schema version from etcd.
+	Etcd time.Duration `json:"etcd"`
+	// Schema is the `/schema` request listing the databases.
+	Schema time.Duration `json:"schema"`
+	// SchemaDB is the `/schema/{db}` requests fetching the tables of all databases, which run concurrently.
+	// Under StreamingApply, it includes applying the tables as well.
+	SchemaDB time.Duration `json:"schema_db"`
+	// Apply is applying the fetched tables to TableMap, including waiting for the readers of TableMap.
+	Apply time.Duration `json:"apply"`
+}
+
+// updateMap syncs TableMap with the schema of TiDB.
+func (r *TableResolver) updateMap(ctx context.Context) (result SyncResult) {
+	startTime := time.Now()
+	result.ID = uuid.New().String()
+	result.StartedAt = startTime
+	result.Version = -1
+	logger := log.L().With(zap.String("sync-id", result.ID))
+	ctx = context.WithValue(ctx, syncLoggerKey{}, logger)
+	timeout := r.SyncTimeout
+	if r.ColdSyncTimeout > 0 && !r.initialized.Load() {
+		timeout = r.ColdSyncTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var applyStart time.Time
+	defer func() {
+		result.Duration = time.Since(startTime)
+		if !applyStart.IsZero() {
+			result.Phases.Apply = time.Since(applyStart)
+		}
+		if result.Err != nil && ctx.Err() == context.DeadlineExceeded {
+			logger.Warn("schema sync exceeds the timeout", zap.Duration("timeout", timeout), zap.Error(result.Err))
+		}
+	}()
+
 	// check schema version
-	ectx, cancel := context.WithTimeout(ctx, etcdGetTimeout)
-	resp, err := s.EtcdClient.Get(ectx, schemaVersionPath)
+	lastVersion, initialized := r.schemaVersion.Load(), r.initialized.Load()
+	phaseStart := time.Now()
+	ectx, cancel := context.WithTimeout(ctx, r.etcdGetTimeout())
+	resp, err := r.EtcdClient.Get(ectx, schemaVersionPath)
 	cancel()
-	if err != nil || len(resp.Kvs) != 1 {
-		if s.SchemaVersion != -1 {
-			log.Warn("failed to get tidb schema version", zap.Error(err))
+	result.Phases.Etcd = time.Since(phaseStart)
+	if err != nil {
+		result.Err = ErrEtcdUnavailable.Wrap(err, "failed to get %s schema version", distro.R().TiDB)
+		if initialized {
+			logger.Warn("failed to get tidb schema version", zap.Error(result.Err))
 		} else {
-			log.Debug("failed to get tidb schema version, maybe not a db cluster", zap.Error(err))
+			logger.Debug("failed to get tidb schema version, maybe not a db cluster", zap.Error(result.Err))
 		}
 		return
 	}
-	schemaVersion, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
-	if err != nil {
-		if s.SchemaVersion != -1 {
-			log.Warn("failed to get tidb schema version", zap.Error(err))
-		} else {
-			log.Debug("failed to get tidb schema version, maybe not a db cluster", zap.Error(err))
+	if len(resp.Kvs) != 1 {
+		if initialized {
+			logger.Warn("tidb schema version is not found in etcd")
+			result.Err = ErrEtcdUnavailable.New("%s schema version is not found", distro.R().TiDB)
+			return
 		}
+		logger.Debug("tidb schema version is not found in etcd, maybe not a db cluster")
 		return
 	}
-	if schemaVersion == s.SchemaVersion {
-		log.Debug("schema version has not changed, skip this update")
+	schemaVersion, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+	if err != nil {
+		result.Err = ErrParseFailed.Wrap(err, "invalid %s schema version", distro.R().TiDB)
+		logger.Warn("failed to parse tidb schema version", zap.Error(result.Err))
 		return
 	}
+	forced := r.forceResync.Swap(false)
+	if forced {
+		defer func() {
+			if result.Err != nil {
+				r.forceResync.Store(true)
+			}
+		}()
+		r.resumed = nil
+	}
+	if initialized && schemaVersion == lastVersion {
+		switch {
+		case forced:
+			logger.Info("a full resync is forced", zap.Int64("version", schemaVersion))
+		case r.shouldForceResync():
+			logger.Info("too many tables are not found in table map, force a full resync",
+				zap.Int64("version", schemaVersion), zap.Int("misses", r.misses.count()))
+		case r.versionStableTooLong():
+			logger.Info("schema version has not changed for too long, force a full resync",
+				zap.Int64("version", schemaVersion), zap.Duration("max-stable-interval", r.MaxVersionStableInterval))
+		default:
+			logger.Debug("schema version has not changed, skip this update")
+			return
+		}
+	}
 
-	log.Debug("schema version has changed", zap.Int64("old", s.SchemaVersion), zap.Int64("new", schemaVersion))
+	logger.Debug("schema version has changed", zap.Int64("old", lastVersion), zap.Int64("new", schemaVersion))
 
 	// get all database info
+	result.Path = syncPathSchema
 	var dbInfos []*model.DBInfo
-	if err := s.request("/schema", &dbInfos); err != nil {
-		log.Error("fail to send schema request", zap.String("component", distro.R().TiDB), zap.Error(err))
+	phaseStart = time.Now()
+	err = r.request(ctx, "/schema", &dbInfos)
+	result.Phases.Schema = time.Since(phaseStart)
+	if err != nil {
+		logger.Error("fail to send schema request", zap.String("component", distro.R().TiDB), zap.Error(err))
+		result.Err = err
+		return
+	}
+	// TiDB always reports its system databases, so an empty list means the status API is not ready yet.
+	// Keep the schema version untouched to retry in the next round.
+	if len(dbInfos) == 0 {
+		logger.Debug("no database is reported by schema request, retry later", zap.String("component", distro.R().TiDB))
 		return
 	}
 
 	// get all table info
-	updateSuccess := true
+	summary := r.newSyncSummary()
+	var onFetched func(res *dbTableInfos)
+	if r.StreamingApply {
+		onFetched = func(res *dbTableInfos) {
+			if res.err != nil {
+				return
+			}
+			r.applyMu.Lock()
+			r.updateTableMap(res.dbName, res.tableInfos, summary)
+			r.applyMu.Unlock()
+			if r.OnSchemaFetched == nil {
+				res.tableInfos = nil
+			}
+		}
+	}
+	pending := r.pending(schemaVersion, dbInfos, summary)
+	result.Resumed = len(dbInfos) - len(pending)
+	phaseStart = time.Now()
+	fetched := r.fetchTableInfos(ctx, pending, onFetched)
+	applyStart = time.Now()
+	result.Phases.SchemaDB = applyStart.Sub(phaseStart)
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+	for _, res := range fetched {
+		if res.err != nil {
+			logger.Error("fail to send schema request", zap.String("component", distro.R().TiDB), zap.Error(res.err))
+			result.Err = res.err
+			continue
+		}
+		if !r.StreamingApply {
+			r.updateTableMap(res.dbName, res.tableInfos, summary)
+		}
+	}
+	if result.Err == nil && summary.duplicateErr != nil {
+		result.Err = summary.duplicateErr
+	}
+	r.recordProgress(schemaVersion, dbInfos, fetched, summary)
+	if result.Err == nil && r.PruneDroppedTables {
+		summary.pruneDropped(r.TableMap, time.Now(), r.DroppedTableGrace)
+		r.tableMapGen.Inc()
+	}
+	r.enforceMemoryBudget()
+	r.rebuildKeyIndex()
+	r.commitSnapshot()
+	result.Added, result.Changed, result.Partitions = summary.Added, summary.Changed, summary.Partitions
+	if result.Err != nil {
+		r.publishChanges(-1, summary, false)
+		return
+	}
+
+	// update schema version
+	r.schemaVersion.Store(schemaVersion)
+	r.retainVersion(schemaVersion)
+	r.markInitialized()
+	result.Version = schemaVersion
+	r.lastSyncSuccess.Store(time.Now())
+	r.misses.reset()
+	r.notifySchemaFetched(schemaVersion, dbInfos, fetched)
+	summary.countRemoved(r.TableMap)
+	result.Removed = summary.Removed
+	r.publishChanges(schemaVersion, summary, true)
+	if r.LogSyncSummary {
+		logger.Info("schema sync finished",
+			zap.Int64("version", schemaVersion),
+			zap.String("path", result.Path),
+			zap.Int("added", result.Added),
+			zap.Int("removed", result.Removed),
+			zap.Int("changed", result.Changed),
+			zap.Int("partitions", result.Partitions),
+			zap.Duration("duration", time.Since(startTime)),
+		)
+	}
+	return
+}
+
+// dbTableInfos is the result of requesting the tables of a database.
+type dbTableInfos struct {
+	dbName     string
+	tableInfos []*model.TableInfo
+	err        error
+}
+
+// fetchTableInfos requests the tables of the databases with SyncConcurrency, or WarmupConcurrency, workers. The
+// results are in the order of dbInfos, so that they are applied to TableMap in the same order as a serial sync.
+// onFetched, if not nil, is called by the workers with each result once it is fetched.
+func (r *TableResolver) fetchTableInfos(ctx context.Context, dbInfos []*model.DBInfo, onFetched func(res *dbTableInfos)) []dbTableInfos {
+	var dbNames []model.CIStr
 	for _, db := range dbInfos {
-		if db.State == model.StateNone {
+		if db.State != model.StateNone {
+			dbNames = append(dbNames, db.Name)
+		}
+	}
+	results := make([]dbTableInfos, len(dbNames))
+	conc := r.SyncConcurrency
+	if r.WarmupConcurrency > 0 && !r.initialized.Load() {
+		conc = r.WarmupConcurrency
+	}
+	if conc < 1 {
+		conc = 1
+	}
+	if conc > len(dbNames) {
+		conc = len(dbNames)
+	}
+
+	taskChan := make(chan int, len(dbNames))
+	for _, i := range r.fetchOrder(dbNames) {
+		taskChan <- i
+	}
+	close(taskChan)
+	var wg sync.WaitGroup
+	for w := 0; w < conc; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range taskChan {
+				res := &results[i]
+				res.dbName = dbNames[i].O
+				// Once the sync is cancelled or times out, the databases left are failed without requests.
+				if err := ctx.Err(); err != nil {
+					res.err = ErrTiDBUnavailable.Wrap(err, "%s schema API request failed", distro.R().TiDB)
+					continue
+				}
+				pathName := dbNames[i].O
+				if r.SchemaPathName == SchemaNameLower {
+					pathName = dbNames[i].L
+				}
+				res.err = r.request(ctx, "/schema/"+escapePathSegment(pathName), &res.tableInfos)
+				if onFetched != nil {
+					onFetched(res)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// fetchOrder returns the indexes of dbNames in the order to fetch them. Under HotTablesFirst, the databases are
+// sorted by the highest priority of their tables in TableMap, and the ones of the same priority are kept in
+// order.
+func (r *TableResolver) fetchOrder(dbNames []model.CIStr) []int {
+	order := make([]int, len(dbNames))
+	for i := range order {
+		order[i] = i
+	}
+	if !r.HotTablesFirst {
+		return order
+	}
+	priorities := make(map[int64]int)
+	for _, sample := range r.samples.top(math.MaxInt32, r.lookupSampleWindow(), time.Now()) {
+		priorities[sample.TableID] += sample.Count
+	}
+	if r.PriorityHint != nil {
+		for id, priority := range r.PriorityHint() {
+			priorities[id] += priority
+		}
+	}
+	dbPriorities := make(map[string]int)
+	for id, priority := range priorities {
+		detail, ok := r.TableMap.Load(id)
+		if !ok {
+			continue
+		}
+		db := detail.RawDB
+		if db == "" {
+			db = detail.DB
+		}
+		if old, ok := dbPriorities[db]; !ok || priority > old {
+			dbPriorities[db] = priority
+		}
+	}
+	if len(dbPriorities) == 0 {
+		return order
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return dbPriorities[dbNames[order[i]].O] > dbPriorities[dbNames[order[j]].O]
+	})
+	return order
+}
+
+// escapePathSegment escapes a name into a single segment of a URL path. Besides url.PathEscape, a name made of
+// dots only is escaped as well, so that it is not taken as a `.` or `..` segment.
+func escapePathSegment(name string) string {
+	if strings.Trim(name, ".") == "" {
+		return strings.ReplaceAll(name, ".", "%2E")
+	}
+	return url.PathEscape(name)
+}
+
+// updateTableMap stores the tables of a database and their partitions into TableMap.
+// The stored details are always rebuilt from the latest TableInfo, so that a dropped or recreated
+// index never leaves a stale name behind.
+func (r *TableResolver) updateTableMap(dbName string, tableInfos []*model.TableInfo, summary *syncSummary) {
+	hidden := isHiddenSchema(dbName)
+	statsOnly := r.keepsStatsTables(dbName)
+	if hidden && r.HiddenTables == HiddenTablesSkip && !statsOnly {
+		return
+	}
+	emptyDB := strings.TrimSpace(dbName) == ""
+	if emptyDB {
+		log.Warn("tidb reports a database with an empty name",
+			zap.Int("tables", len(tableInfos)), zap.Bool("skipped", r.EmptyDBName == ""))
+		if r.EmptyDBName == "" {
+			return
+		}
+	}
+	tagHidden := hidden && (r.HiddenTables == "" || r.HiddenTables == HiddenTablesTag || statsOnly)
+	now := time.Now()
+	for _, table := range tableInfos {
+		if statsOnly && !isMySQLTableName(dbName, table.Name.O, statsTables) {
 			continue
 		}
-		var tableInfos []*model.TableInfo
-		encodeName := url.PathEscape(db.Name.O)
-		if err := s.request(fmt.Sprintf("/schema/%s", encodeName), &tableInfos); err != nil {
-			log.Error("fail to send schema request", zap.String("component", distro.R().TiDB), zap.Error(err))
-			updateSuccess = false
+		if r.SkipDeleteOnlyTables && table.State == model.StateDeleteOnly {
 			continue
 		}
-		for _, table := range tableInfos {
-			indices := make(map[int64]string, len(table.Indices))
+		var ddlState model.SchemaState
+		if table.State.InTransition() {
+			ddlState = table.State
+		}
+		// The index names are dropped under degradeIndices, see MemoryBudget.
+		indices := map[int64]string{}
+		var globalIndices map[int64]struct{}
+		if r.degraded() < degradeIndices {
+			indices = r.tableIndices(dbName, table)
 			for _, index := range table.Indices {
-				indices[index.ID] = index.Name.O
+				if index.Global {
+					if globalIndices == nil {
+						globalIndices = make(map[int64]struct{})
+					}
+					globalIndices[index.ID] = struct{}{}
+				}
 			}
-			detail := &tableDetail{
-				Name:    table.Name.O,
-				DB:      db.Name.O,
-				ID:      table.ID,
-				Indices: indices,
+		}
+		displayDB, displayName := dbName, table.Name.O
+		if r.NormalizeName != nil {
+			displayDB, displayName = r.NormalizeName(dbName, table.Name.O)
+		}
+		if emptyDB {
+			displayDB = r.EmptyDBName
+		}
+		pkColumns := table.GetPKColumnNames()
+		clustered := table.PKIsHandle || table.IsCommonHandle
+		kind := tableKindTable
+		if table.Sequence != nil {
+			kind = tableKindSequence
+		}
+		ttl := table.TTLInfo != nil && table.TTLInfo.Enable
+		detail := &tableDetail{
+			Name:          displayName,
+			DB:            displayDB,
+			ID:            table.ID,
+			Indices:       indices,
+			GlobalIndices: globalIndices,
+			PKColumns:     pkColumns,
+			Clustered:     clustered,
+			CommonHandle:  table.IsCommonHandle,
+			Hidden:        tagHidden,
+			DDLState:      ddlState,
+			Kind:          kind,
+			TTL:           ttl,
+			UpdatedAt:     now,
+			RawName:       table.Name.O,
+			RawDB:         dbName,
+		}
+		partition := table.GetPartitionInfo()
+		if partition != nil && (r.SkipPartitions || r.degraded() >= degradePartitions) {
+			r.walkStoredPartitions(partition, func(partitionDef *model.PartitionDefinition, _ []string, _ bool) {
+				detail.PartitionIDs = append(detail.PartitionIDs, partitionDef.ID)
+			})
+			partition = nil
+		}
+		r.storeDetail(summary, detail)
+		if partition != nil {
+			bounds := rangePartitionBounds(partition)
+			var bound string
+			reorgState := ddlState
+			if partition.DDLState.InTransition() {
+				reorgState = partition.DDLState
 			}
-			s.TableMap.Store(table.ID, detail)
-			if partition := table.GetPartitionInfo(); partition != nil {
-				for _, partitionDef := range partition.Definitions {
-					detail := &tableDetail{
-						Name:    fmt.Sprintf("%s/%s", table.Name.O, partitionDef.Name.O),
-						DB:      db.Name.O,
-						ID:      partitionDef.ID,
-						Indices: indices,
-					}
-					s.TableMap.Store(partitionDef.ID, detail)
+			r.walkStoredPartitions(partition, func(partitionDef *model.PartitionDefinition, path []string, reorg bool) {
+				// The subpartitions are walked right after their partition, whose bound they share.
+				if len(path) == 1 {
+					bound = bounds[partitionDef.ID]
+				}
+				partitionState := ddlState
+				if reorg {
+					partitionState = reorgState
 				}
+				detail := &tableDetail{
+					Name:           partitionName(displayName, path),
+					DB:             displayDB,
+					ID:             partitionDef.ID,
+					Indices:        indices,
+					GlobalIndices:  globalIndices,
+					PKColumns:      pkColumns,
+					Clustered:      clustered,
+					CommonHandle:   table.IsCommonHandle,
+					Hidden:         tagHidden,
+					DDLState:       partitionState,
+					TTL:            ttl,
+					UpdatedAt:      now,
+					ParentID:       table.ID,
+					PartitionPath:  path,
+					PartitionBound: bound,
+					RawName:        partitionName(table.Name.O, path),
+					RawDB:          dbName,
+				}
+				r.storeDetail(summary, detail)
+				summary.Partitions++
+			})
+		}
+	}
+	r.tableMapGen.Inc()
+}
+
+// tableIndices maps the index IDs of a table to the index names. An index ID of zero, or shared by several
+// indexes, is logged, and InconsistentIndexIDs decides the name of a shared one.
+func (r *TableResolver) tableIndices(dbName string, table *model.TableInfo) map[int64]string {
+	policy := r.InconsistentIndexIDs
+	if policy == "" {
+		policy = InconsistentIndexesByName
+	}
+	indices := make(map[int64]string, len(table.Indices))
+	// inconsistent are the inconsistent index IDs in the reported order, each once.
+	var inconsistent []int64
+	counts := make(map[int64]int, len(table.Indices))
+	for _, index := range table.Indices {
+		counts[index.ID]++
+		if index.ID == 0 && counts[index.ID] == 1 || index.ID != 0 && counts[index.ID] == 2 {
+			inconsistent = append(inconsistent, index.ID)
+		}
+		if name, shared := indices[index.ID]; shared && policy == InconsistentIndexesByName {
+			indices[index.ID] = name + "|" + index.Name.O
+		} else {
+			indices[index.ID] = index.Name.O
+		}
+	}
+	if len(inconsistent) > 0 {
+		log.Warn("tidb reports inconsistent index ids",
+			zap.String("db", dbName),
+			zap.String("table", table.Name.O),
+			zap.Int64("table-id", table.ID),
+			zap.Int64s("index-ids", inconsistent),
+			zap.String("policy", string(policy)))
+	}
+	return indices
+}
+
+// storeDetail stores the detail by summary.store, unless its ID is already stored by this sync, in which case
+// DuplicateTableIDs decides which one is kept.
+func (r *TableResolver) storeDetail(summary *syncSummary, detail *tableDetail) {
+	if _, ok := summary.seen[detail.ID]; ok {
+		policy := r.DuplicateTableIDs
+		if policy == "" {
+			policy = DuplicateKeepLast
+		}
+		fields := []zap.Field{zap.Int64("table-id", detail.ID), zap.String("policy", string(policy))}
+		if first, ok := r.TableMap.Load(detail.ID); ok {
+			fields = append(fields,
+				zap.String("first", first.RawDB+"."+first.RawName),
+				zap.String("second", detail.RawDB+"."+detail.RawName))
+		}
+		log.Warn("tidb reports a duplicated table id", fields...)
+		switch policy {
+		case DuplicateKeepFirst:
+			return
+		case DuplicateError:
+			if summary.duplicateErr == nil {
+				summary.duplicateErr = ErrDuplicateTableID.New("table ID %d is reported more than once by %s",
+					detail.ID, distro.R().TiDB)
 			}
 		}
 	}
+	summary.store(r.TableMap, detail)
+}
 
-	// update schema version
-	if updateSuccess {
-		s.SchemaVersion = schemaVersion
+// walkPartitions calls f with each partition in defs and each of their subpartitions, parents first. path is
+// the names of the partition below the table, outermost first, and is not modified afterwards.
+func walkPartitions(defs []*model.PartitionDefinition, parent []string, f func(*model.PartitionDefinition, []string)) {
+	for _, def := range defs {
+		path := make([]string, len(parent)+1)
+		copy(path, parent)
+		path[len(parent)] = def.Name.O
+		f(def, path)
+		walkPartitions(def.SubPartitions, path, f)
 	}
 }
 
-func (s *tidbLabelStrategy) request(path string, v interface{}) error {
-	data, err := s.tidbClient.SendGetRequest(path)
-	if err != nil {
+// walkStoredPartitions walks the partitions of a table to be stored, like walkPartitions. Under
+// ReorgPartitions, the partitions being added or dropped by a partition DDL are walked as well after the
+// others, with reorg set.
+func (r *TableResolver) walkStoredPartitions(partition *model.PartitionInfo, f func(*model.PartitionDefinition, []string, bool)) {
+	walkPartitions(partition.Definitions, nil, func(partitionDef *model.PartitionDefinition, path []string) {
+		f(partitionDef, path, false)
+	})
+	if !r.ReorgPartitions {
+		return
+	}
+	defined := make(map[int64]struct{})
+	walkPartitions(partition.Definitions, nil, func(partitionDef *model.PartitionDefinition, _ []string) {
+		defined[partitionDef.ID] = struct{}{}
+	})
+	for _, defs := range [][]*model.PartitionDefinition{partition.AddingDefinitions, partition.DroppingDefinitions} {
+		walkPartitions(defs, nil, func(partitionDef *model.PartitionDefinition, path []string) {
+			if _, ok := defined[partitionDef.ID]; !ok {
+				defined[partitionDef.ID] = struct{}{}
+				f(partitionDef, path, true)
+			}
+		})
+	}
+}
+
+// partitionName returns the name of the partition of a table at path, e.g. `t/p0/sp1`.
+func partitionName(table string, path []string) string {
+	return table + "/" + strings.Join(path, "/")
+}
+
+// maxPartitionBoundLen bounds the length of each value in PartitionBound, as the values of RANGE COLUMNS may
+// be long strings.
+const maxPartitionBoundLen = 32
+
+// partitionMaxValue is the value of LessThan for no upper bound.
+const partitionMaxValue = "MAXVALUE"
+
+// rangePartitionBounds returns the PartitionBound of each partition of a range partitioned table, keyed by
+// partition ID, or nil for another partitioning or if the bounds are not reported. The lower bound of a
+// partition is the upper bound of the one before it, e.g. `RANGE(-inf,10)`, `RANGE[10,20)` and
+// `RANGE[20,+inf)` for `LESS THAN (10)`, `(20)` and `MAXVALUE`, and `RANGE[(1,'a'),(2,'b'))` for the
+// partitions of RANGE COLUMNS over two columns.
+func rangePartitionBounds(partition *model.PartitionInfo) map[int64]string {
+	if partition.Type != model.PartitionTypeRange {
+		return nil
+	}
+	bounds := make(map[int64]string, len(partition.Definitions))
+	lower := "(-inf"
+	for _, def := range partition.Definitions {
+		if len(def.LessThan) == 0 {
+			return nil
+		}
+		upper := "+inf)"
+		if !isPartitionMaxValue(def.LessThan) {
+			upper = formatPartitionBound(def.LessThan) + ")"
+		}
+		bounds[def.ID] = "RANGE" + lower + "," + upper
+		lower = "[" + formatPartitionBound(def.LessThan)
+	}
+	return bounds
+}
+
+func isPartitionMaxValue(values []string) bool {
+	for _, v := range values {
+		if !strings.EqualFold(v, partitionMaxValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatPartitionBound formats the values of a bound, as a tuple for more than one column.
+func formatPartitionBound(values []string) string {
+	truncated := make([]string, 0, len(values))
+	for _, v := range values {
+		if len(v) > maxPartitionBoundLen {
+			n := maxPartitionBoundLen
+			for n > 0 && !utf8.RuneStart(v[n]) {
+				n--
+			}
+			v = v[:n] + "..."
+		}
+		truncated = append(truncated, v)
+	}
+	if len(truncated) == 1 {
+		return truncated[0]
+	}
+	return "(" + strings.Join(truncated, ",") + ")"
+}
+
+// dbTableInfo is the response of the `/db-table/{tableID}` request. For the ID of a partition, TableInfo is
+// the partitioned table.
+type dbTableInfo struct {
+	DBInfo    *model.DBInfo    `json:"db_info"`
+	TableInfo *model.TableInfo `json:"table_info"`
+}
+
+// Revalidate fetches a single table or partition from TiDB, and updates TableMap with it if the stored one
+// differs, e.g. to recheck a suspicious label without a full sync. For a partition, the partitioned table and
+// all its partitions are updated. It reports whether TableMap is changed. On error, TableMap is left as is,
+// even if the table no longer exists.
+func (r *TableResolver) Revalidate(ctx context.Context, id int64) (bool, error) {
+	var info dbTableInfo
+	if err := r.request(ctx, "/db-table/"+strconv.FormatInt(id, 10), &info); err != nil {
+		return false, err
+	}
+	if info.DBInfo == nil || info.TableInfo == nil {
+		return false, ErrParseFailed.New("%s returned no table for ID %d", distro.R().TiDB, id)
+	}
+
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+	summary := r.newSyncSummary()
+	r.updateTableMap(info.DBInfo.Name.O, []*model.TableInfo{info.TableInfo}, summary)
+	// A changed table keeps its key range, but may gain or lose skipped partitions.
+	if ids := append(append([]int64(nil), summary.addedIDs...), summary.changedIDs...); len(ids) > 0 {
+		r.updateKeyIndex(ids)
+	}
+	r.commitSnapshot()
+	r.publishChanges(r.schemaVersion.Load(), summary, false)
+	return summary.Added+summary.Changed > 0, nil
+}
+
+// request sends a request to the TiDB status API. If TiDB, or a proxy in front of it, rejects the request
+// with 429, it waits for the Retry-After delay and retries once. Only the worker sending the request is
+// paused, the other workers of the sync go on. Both attempts are bounded by the timeout of the endpoint.
+func (r *TableResolver) request(ctx context.Context, path string, v interface{}) error {
+	logger := syncLogger(ctx)
+	if timeout := r.requestTimeout(path); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	data, err := r.send(ctx, path)
+	if httpc.StatusCodeOf(err) == http.StatusTooManyRequests {
+		retryAfter, ok := httpc.RetryAfterOf(err)
+		if !ok {
+			retryAfter = defaultRetryAfter
+		} else if retryAfter > maxRetryAfter {
+			retryAfter = maxRetryAfter
+		}
+		logger.Debug("schema request is rate limited, retry later",
+			zap.String("path", path), zap.Duration("retry-after", retryAfter))
+		select {
+		case <-ctx.Done():
+			return ErrTiDBUnavailable.Wrap(err, "%s schema API request failed", distro.R().TiDB)
+		case <-time.After(retryAfter):
+		}
+		data, err = r.send(ctx, path)
+	}
+	if errorx.IsOfType(err, ErrParseFailed) {
 		return err
 	}
+	if err != nil {
+		return ErrTiDBUnavailable.Wrap(err, "%s schema API request failed", distro.R().TiDB)
+	}
 	if err = json.Unmarshal(data, v); err != nil {
-		return ErrInvalidData.Wrap(err, "%s schema API unmarshal failed", distro.R().TiDB)
+		return ErrParseFailed.Wrap(err, "%s schema API unmarshal failed", distro.R().TiDB)
 	}
 	return nil
 }
+
+// etcdGetTimeout returns EtcdGetTimeout, or defaultEtcdGetTimeout if it is not positive.
+func (r *TableResolver) etcdGetTimeout() time.Duration {
+	if r.EtcdGetTimeout <= 0 {
+		return defaultEtcdGetTimeout
+	}
+	return r.EtcdGetTimeout
+}
+
+// requestTimeout returns the timeout of the requests to path, from EndpointTimeouts or else RequestTimeout.
+func (r *TableResolver) requestTimeout(path string) time.Duration {
+	if timeout, ok := r.EndpointTimeouts[endpointOf(path)]; ok {
+		return timeout
+	}
+	return r.RequestTimeout
+}
+
+// isHTMLResponse reports whether the body starts with `<`, which can never begin a JSON value. A proxy may
+// serve its error pages with any content type, so the body is checked and the content type is sniffed from it.
+func isHTMLResponse(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '<'
+}
+
+// send sends a GET request to the TiDB status API, with the token from TokenProvider if set. If the token is
+// rejected with 401, the request is retried once with a refreshed token.
+func (r *TableResolver) send(ctx context.Context, path string) ([]byte, error) {
+	if r.TokenProvider == nil {
+		return r.sendWithToken(ctx, path, "")
+	}
+	token, err := r.TokenProvider(false)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.sendWithToken(ctx, path, token)
+	if httpc.StatusCodeOf(err) == http.StatusUnauthorized {
+		syncLogger(ctx).Debug("token is rejected by schema request, refresh it", zap.String("path", path))
+		if token, err = r.TokenProvider(true); err != nil {
+			return nil, err
+		}
+		data, err = r.sendWithToken(ctx, path, token)
+	}
+	return data, err
+}
+
+// sendWithToken sends a GET request once the Limiter allows it, with the bearer token if it is not empty.
+func (r *TableResolver) sendWithToken(ctx context.Context, path string, token string) ([]byte, error) {
+	if r.Limiter != nil {
+		if err := r.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	client := r.tidbClient.WithCheckRedirect(r.checkRedirect)
+	if token != "" {
+		client = client.WithHeader("Authorization", "Bearer "+token)
+	}
+	sentAt := time.Now()
+	res, err := client.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if r.ClockSkewThreshold > 0 && path == "/schema" {
+		r.checkClockSkew(ctx, res, sentAt)
+	}
+	return r.readBody(res)
+}
+
+// checkClockSkew compares the `Date` header of a response of TiDB with the local time halfway through the
+// request, and warns if they are further apart than ClockSkewThreshold. The header has a resolution of one
+// second, so the skew is only accurate to about a second.
+func (r *TableResolver) checkClockSkew(ctx context.Context, res *httpc.Response, sentAt time.Time) {
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	receivedAt := time.Now()
+	skew := date.Sub(sentAt.Add(receivedAt.Sub(sentAt) / 2))
+	clockSkew.WithLabelValues(r.Cluster).Set(skew.Seconds())
+	if skew > r.ClockSkewThreshold || skew < -r.ClockSkewThreshold {
+		syncLogger(ctx).Warn("the clocks of tidb and the dashboard skew",
+			zap.Duration("skew", skew),
+			zap.Duration("threshold", r.ClockSkewThreshold))
+	}
+}
+
+// checkRedirect follows the redirects allowed by Redirects. The error is returned by the request, so it tells
+// where the redirect goes.
+func (r *TableResolver) checkRedirect(req *http.Request, via []*http.Request) error {
+	policy := r.Redirects
+	if policy == "" {
+		policy = RedirectSameHost
+	}
+	if policy == RedirectSameHost && req.URL.Host == via[0].URL.Host && len(via) <= maxRedirects {
+		return nil
+	}
+	return fmt.Errorf("redirect to %s is not followed under the %s redirect policy, after %d redirects",
+		req.URL.Redacted(), policy, len(via)-1)
+}
+
+// readBody reads the body of a response up to MaxResponseSize bytes. An HTML body, typically an error page of a
+// misconfigured proxy served with 200, is reported with the Content-Type claimed by the response, or the one
+// sniffed from the body if the response has none.
+func (r *TableResolver) readBody(res *httpc.Response) ([]byte, error) {
+	defer res.Response.Body.Close()
+	limit := r.MaxResponseSize
+	if limit < 1 {
+		limit = defaultMaxResponseSize
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Response.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrParseFailed.New("%s status API response exceeds the limit of %d bytes", distro.R().TiDB, limit)
+	}
+	if isHTMLResponse(data) {
+		contentType := res.Response.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = http.DetectContentType(data) + " (sniffed)"
+		}
+		return nil, ErrParseFailed.New("non-JSON response from %s status API, got content-type %s",
+			distro.R().TiDB, contentType)
+	}
+	return data, nil
+}
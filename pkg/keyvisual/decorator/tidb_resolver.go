@@ -0,0 +1,1323 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+const (
+	defaultSyncInterval     = time.Minute
+	defaultSyncJitter       = 10 * time.Second
+	defaultOwnerChangeDelay = 5 * time.Second
+	// defaultSchemaVersionDebounce spans the bumps of a batch of DDL statements run back to back.
+	defaultSchemaVersionDebounce = 500 * time.Millisecond
+	defaultSyncConcurrency       = 4
+	defaultSyncTimeout           = time.Minute
+	// defaultWatchKeepAlive is well below the minutes a partition takes to be noticed otherwise.
+	defaultWatchKeepAlive = 30 * time.Second
+	// watchRetryDelay is the delay before a watch in etcd is re-established.
+	watchRetryDelay = time.Second
+	// defaultMaxResponseSize is far above the responses of the largest known schemas.
+	defaultMaxResponseSize = 512 << 20
+	// defaultColdSyncRetryInterval and defaultColdSyncRetryMaxInterval retry a cold sync failing on a cluster
+	// not reachable yet within seconds, backing off to the default sync interval at most.
+	defaultColdSyncRetryInterval    = time.Second
+	defaultColdSyncRetryMaxInterval = 30 * time.Second
+	// defaultDroppedTableGrace is the default `tikv_gc_life_time`, after which the GC may delete the keys of a
+	// dropped table.
+	defaultDroppedTableGrace = 10 * time.Minute
+
+	// ddlOwnerPrefix holds the election keys of the TiDB DDL owner.
+	ddlOwnerPrefix = "/tidb/ddl/fg/owner"
+)
+
+// HiddenTablePolicy decides how the tables of the TiDB system databases, e.g. the statistics and bindings in
+// `mysql`, are kept in the TableMap. TiDB does not mark tables as hidden, so the system databases are the
+// hidden ones.
+type HiddenTablePolicy string
+
+const (
+	// HiddenTablesTag stores hidden tables and adds a "hidden" label to their keys. It is the default.
+	HiddenTablesTag HiddenTablePolicy = "tag"
+	// HiddenTablesStore stores hidden tables like the others.
+	HiddenTablesStore HiddenTablePolicy = "store"
+	// HiddenTablesSkip does not store hidden tables, so their keys are labeled by table ID only.
+	HiddenTablesSkip HiddenTablePolicy = "skip"
+
+	hiddenLabel = "hidden"
+)
+
+// hiddenSchemas are the lower case names of the TiDB system databases.
+var hiddenSchemas = map[string]struct{}{
+	"mysql":              {},
+	"information_schema": {},
+	"performance_schema": {},
+	"metrics_schema":     {},
+	"sys":                {},
+}
+
+func isHiddenSchema(dbName string) bool {
+	_, ok := hiddenSchemas[strings.ToLower(dbName)]
+	return ok
+}
+
+// SchemaNameForm decides which form of a database name is used in the path of the `/schema/{db}` request.
+type SchemaNameForm string
+
+const (
+	// SchemaNameOriginal uses the name as created, e.g. `Test`. It is the default.
+	SchemaNameOriginal SchemaNameForm = "original"
+	// SchemaNameLower uses the lower case name, e.g. `test`.
+	SchemaNameLower SchemaNameForm = "lower"
+)
+
+// RedirectPolicy decides which redirects of the TiDB status API are followed, e.g. the ones issued by a
+// gateway in front of it. A redirect not followed fails the request.
+type RedirectPolicy string
+
+const (
+	// RedirectSameHost follows the redirects to the same host and port, at most maxRedirects in a row, so that
+	// the token is never sent to another origin and a redirect loop ends. It is the default.
+	RedirectSameHost RedirectPolicy = "same_host"
+	// RedirectNone follows no redirect.
+	RedirectNone RedirectPolicy = "none"
+
+	maxRedirects = 10
+)
+
+// DuplicateTableIDPolicy decides which table is kept when the status API reports the same table ID more than
+// once in a sync, e.g. under two databases, which is a corruption of the schema or a bug of the status API.
+// The duplicates are logged under all policies.
+type DuplicateTableIDPolicy string
+
+const (
+	// DuplicateKeepFirst keeps the table reported first.
+	DuplicateKeepFirst DuplicateTableIDPolicy = "keep_first"
+	// DuplicateKeepLast keeps the table reported last. It is the default.
+	DuplicateKeepLast DuplicateTableIDPolicy = "keep_last"
+	// DuplicateError keeps the table reported last, but fails the sync with ErrDuplicateTableID, so that the
+	// schema version is not taken as synced.
+	DuplicateError DuplicateTableIDPolicy = "error"
+)
+
+// InconsistentIndexIDPolicy decides how the indexes of a table are labeled when the status API reports an
+// index ID of zero, or shared by several indexes of the table. The inconsistent IDs are logged under all
+// policies.
+type InconsistentIndexIDPolicy string
+
+const (
+	// InconsistentIndexesByName labels an index ID shared by several indexes with all their names, joined by
+	// `|` in the reported order, so that no index is dropped. It is the default.
+	InconsistentIndexesByName InconsistentIndexIDPolicy = "by_name"
+	// InconsistentIndexesKeepLast labels an index ID shared by several indexes with the one reported last.
+	InconsistentIndexesKeepLast InconsistentIndexIDPolicy = "keep_last"
+)
+
+// StatusEndpoint is a family of the TiDB status API endpoints requested by the resolver, whose responses
+// differ much in size.
+type StatusEndpoint string
+
+const (
+	// EndpointSchema is `/schema`, listing the databases.
+	EndpointSchema StatusEndpoint = "schema"
+	// EndpointSchemaDB is `/schema/{db}`, listing the tables of a database, which can be huge.
+	EndpointSchemaDB StatusEndpoint = "schema_db"
+	// EndpointDBTable is `/db-table/{id}`, returning a single table.
+	EndpointDBTable StatusEndpoint = "db_table"
+)
+
+// endpointOf returns the endpoint family of a request path.
+func endpointOf(path string) StatusEndpoint {
+	switch {
+	case strings.HasPrefix(path, "/schema/"):
+		return EndpointSchemaDB
+	case strings.HasPrefix(path, "/db-table/"):
+		return EndpointDBTable
+	default:
+		return EndpointSchema
+	}
+}
+
+// TableInfo is the resolved information of a table or a partition.
+type TableInfo struct {
+	ID   int64  `json:"id"`
+	DB   string `json:"db"`
+	Name string `json:"name"`
+	// Indices maps index IDs to index names.
+	Indices map[int64]string `json:"indices"`
+	// ParentID is the ID of the partitioned table of a partition, or 0 for a table.
+	ParentID int64 `json:"parent_id,omitempty"`
+	// PartitionPath is the names of a partition and its parent partitions below the table, outermost first,
+	// e.g. `["p0", "sp1"]` for the subpartition named `t/p0/sp1`. It is empty for a table.
+	PartitionPath []string `json:"partition_path,omitempty"`
+	// PartitionBound is the value range of a range partition, e.g. `RANGE[10,20)`, or empty.
+	PartitionBound string `json:"partition_bound,omitempty"`
+	// Cluster is the TableResolver.Cluster the table is resolved by.
+	Cluster string `json:"cluster,omitempty"`
+	// DDLState is the state of a table in the middle of a DDL, e.g. `write reorganization`, or empty.
+	DDLState string `json:"ddl_state,omitempty"`
+	// Kind is `sequence` for a sequence object, or empty for a table.
+	Kind string `json:"kind,omitempty"`
+	// TTL is whether the expired rows of the table are deleted by TiDB, see tableDetail.TTL.
+	TTL bool `json:"ttl,omitempty"`
+}
+
+// tableKind tells the schema objects stored in TableMap apart, as the sequences have table IDs as well.
+type tableKind byte
+
+const (
+	tableKindTable tableKind = iota
+	tableKindSequence
+)
+
+func (k tableKind) String() string {
+	if k == tableKindSequence {
+		return "sequence"
+	}
+	return ""
+}
+
+// tableDetail is shared by TableMap and the snapshots read by the Labelers, so it is never modified once stored:
+// a sync stores a new one instead. The public lookups hand out copies, see toTableInfo.
+type tableDetail struct {
+	Name    string
+	DB      string
+	ID      int64
+	Indices map[int64]string
+	// GlobalIndices are the IDs of the global indexes of a partitioned table, nil if there is none. Their keys
+	// are in the key range of the table, while the keys of the other indexes are in the ones of the partitions.
+	GlobalIndices map[int64]struct{}
+	// PKColumns are the columns forming the row handle of a clustered table, empty otherwise.
+	PKColumns []string
+	// Clustered is set if the rows are keyed by the primary key, otherwise they are keyed by the implicit
+	// `_tidb_rowid`.
+	Clustered bool
+	// CommonHandle is set if the rows are keyed by a common handle, i.e. the encoded values of a primary key
+	// which is not a single integer column, in the order of PKColumns.
+	CommonHandle bool
+	// Hidden is set for the tables of the system databases under HiddenTablesTag.
+	Hidden bool
+	// ParentID is the ID of the partitioned table of a partition, or 0 for a table. A subpartition has the
+	// table as its parent as well, not the partition it is nested in.
+	ParentID int64
+	// PartitionPath is the names of a partition below the table, outermost first, from which Name is built by
+	// partitionName. It is nil for a table, and for a partition restored from a TableInfo without it, whose
+	// Name is taken as is.
+	PartitionPath []string
+	// PartitionBound is the value range of a range partition, e.g. `RANGE[10,20)`, shared by its subpartitions.
+	// It is empty for a table and for a partition of another partitioning.
+	PartitionBound string
+	// PartitionIDs are the IDs of the partitions of a partitioned table under SkipPartitions, which are not
+	// stored themselves. It is nil otherwise.
+	PartitionIDs []int64
+	// DDLState is the state of a table in the middle of a DDL, e.g. model.StateWriteReorganization, whose keys
+	// still hold data, or model.StateNone if the table is public. The partitions share the one of the table.
+	DDLState model.SchemaState
+	// Kind is tableKindSequence for a sequence object, whose keys are labeled `db.seq (sequence)`.
+	Kind tableKind
+	// TTL is set for a table with TTL enabled, whose keys are tagged with ttlLabel, as the deletions of the
+	// expired rows show up as hotspots. The partitions share the one of the table.
+	TTL bool
+	// UpdatedAt is when the detail was last fetched from TiDB, by a sync or Revalidate, as the unchanged tables
+	// are stored again. It is zero for a table preloaded or restored from a snapshot.
+	UpdatedAt time.Time
+	// DroppedAt is when the table was found dropped under DroppedTableGrace, or zero if it is not dropped.
+	DroppedAt time.Time
+
+	// RawName and RawDB are the names reported by TiDB, before NormalizeName is applied.
+	RawName string
+	RawDB   string
+}
+
+func (d *tableDetail) equal(other *tableDetail) bool {
+	if d.Name != other.Name || d.DB != other.DB || d.ID != other.ID || len(d.Indices) != len(other.Indices) ||
+		d.RawName != other.RawName || d.RawDB != other.RawDB || d.Hidden != other.Hidden ||
+		d.ParentID != other.ParentID || d.Clustered != other.Clustered || d.CommonHandle != other.CommonHandle ||
+		d.DDLState != other.DDLState || d.DroppedAt.IsZero() != other.DroppedAt.IsZero() ||
+		d.Kind != other.Kind || d.PartitionBound != other.PartitionBound || d.TTL != other.TTL {
+		return false
+	}
+	for id, name := range d.Indices {
+		if otherName, ok := other.Indices[id]; !ok || otherName != name {
+			return false
+		}
+	}
+	if len(d.GlobalIndices) != len(other.GlobalIndices) {
+		return false
+	}
+	for id := range d.GlobalIndices {
+		if _, ok := other.GlobalIndices[id]; !ok {
+			return false
+		}
+	}
+	if len(d.PKColumns) != len(other.PKColumns) || len(d.PartitionIDs) != len(other.PartitionIDs) ||
+		len(d.PartitionPath) != len(other.PartitionPath) {
+		return false
+	}
+	for i, name := range d.PartitionPath {
+		if other.PartitionPath[i] != name {
+			return false
+		}
+	}
+	for i, col := range d.PKColumns {
+		if other.PKColumns[i] != col {
+			return false
+		}
+	}
+	for i, id := range d.PartitionIDs {
+		if other.PartitionIDs[i] != id {
+			return false
+		}
+	}
+	return true
+}
+
+// toTableInfo returns a copy of the detail, which is safe to be handed out.
+func (d *tableDetail) toTableInfo() TableInfo {
+	indices := make(map[int64]string, len(d.Indices))
+	for id, name := range d.Indices {
+		indices[id] = name
+	}
+	info := TableInfo{
+		ID:             d.ID,
+		DB:             d.DB,
+		Name:           d.Name,
+		Indices:        indices,
+		ParentID:       d.ParentID,
+		PartitionBound: d.PartitionBound,
+		Kind:           d.Kind.String(),
+		TTL:            d.TTL,
+	}
+	if len(d.PartitionPath) > 0 {
+		info.PartitionPath = append([]string(nil), d.PartitionPath...)
+	}
+	if d.DDLState.InTransition() {
+		info.DDLState = d.DDLState.String()
+	}
+	return info
+}
+
+// statusAPIClient is the subset of *tidb.Client used to request the TiDB status API.
+type statusAPIClient interface {
+	// Get sends a GET request, canceled when ctx is done. The caller reads and closes the body of the response.
+	Get(ctx context.Context, relativeURI string) (*httpc.Response, error)
+	// WithHeader returns a client sending the header with each request.
+	WithHeader(key, value string) statusAPIClient
+	// WithCheckRedirect returns a client deciding by f whether to follow a redirect.
+	WithCheckRedirect(f func(req *http.Request, via []*http.Request) error) statusAPIClient
+}
+
+type tidbStatusAPIClient struct {
+	*tidb.Client
+}
+
+func (c tidbStatusAPIClient) Get(ctx context.Context, relativeURI string) (*httpc.Response, error) {
+	return c.Client.GetWithContext(ctx, relativeURI)
+}
+
+func (c tidbStatusAPIClient) WithHeader(key, value string) statusAPIClient {
+	return tidbStatusAPIClient{c.Client.WithStatusAPIHeader(key, value)}
+}
+
+func (c tidbStatusAPIClient) WithCheckRedirect(f func(req *http.Request, via []*http.Request) error) statusAPIClient {
+	return tidbStatusAPIClient{c.Client.WithStatusAPICheckRedirect(f)}
+}
+
+// TokenProvider returns the bearer token for the TiDB status API, for the environments where it is fronted by
+// a gateway. It is called for each request, so the token can rotate. It must be safe for concurrent use, as
+// the requests of a sync are sent by SyncConcurrency workers. refresh is true after the token is
+// rejected with 401, in which case a new token should be fetched instead of a cached one.
+type TokenProvider func(refresh bool) (string, error)
+
+// RequestLimiter is a budget of requests shared with other components, e.g. a *rate.Limiter of
+// golang.org/x/time/rate. Wait blocks until a request is allowed, or returns an error if ctx is done first.
+type RequestLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TableResolver keeps the mapping from table IDs to table and index names in sync with the TiDB schema.
+// It can be used on its own, while TiDBLabelStrategy is a thin wrapper of it for Key Visualizer.
+// The etcd and TiDB clients are owned by the caller and never closed by TableResolver, so that they can be
+// shared. Cancel the context passed to Run to stop it.
+type TableResolver struct {
+	EtcdClient  clientv3.KV
+	etcdWatcher clientv3.Watcher
+
+	// TableMap defaults to an unbounded store. applyConfig bounds it by MaxTableMapEntries.
+	TableMap tableStore
+	// tableMapGen is bumped whenever TableMap is updated, to invalidate the cached snapshot.
+	tableMapGen   atomic.Int64
+	snapshotCache atomic.Value
+	// keyIndex holds the *tableKeyIndex of TableMap, rebuilt after each update of TableMap.
+	keyIndex atomic.Value
+	// applyMu is held for writing while TableMap and the schema version are updated, so that SupportBundle
+	// never sees the tables of a sync half applied. The requests of a sync are sent without holding it.
+	applyMu       sync.RWMutex
+	tidbClient    statusAPIClient
+	schemaVersion atomic.Int64
+	// initialized is set once schemaVersion holds a version applied by a sync, a Preload or SetSchemaVersion.
+	// Before that, schemaVersion is -1 and never compared with the version in etcd, and the failures to read
+	// the version are only logged at the debug level, as the cluster may have no TiDB at all.
+	initialized atomic.Bool
+	// coldSynced is closed when initialized is first set.
+	coldSynced     chan struct{}
+	coldSyncedInit sync.Once
+	coldSyncedDone sync.Once
+	// paused is set between Pause and Resume.
+	paused atomic.Bool
+	// forceResync is set by ForceResync until a sync fetches all tables, and resyncRequested wakes Run up.
+	forceResync         atomic.Bool
+	resyncRequested     chan struct{}
+	resyncRequestedInit sync.Once
+	lastError           atomic.Error
+	// lastSyncSuccess is the time of the last fully successful sync, exposed as `seconds_since_last_sync`. Run
+	// sets it to its start if there is none, so that the gauge keeps climbing if the first sync never succeeds.
+	lastSyncSuccess atomic.Time
+	// lastResult holds the SyncResult of the last sync.
+	lastResult atomic.Value
+	history    syncHistory
+	changes    changeHub
+	// lastSeen holds the IDs seen by the last complete sync, to tell the removed tables to the subscribers.
+	lastSeen map[int64]struct{}
+	// resumed is the progress of the syncs failing half way, see resumedSync.
+	resumed *resumedSync
+
+	// The following tunables must be set before calling Run.
+
+	// Lazy turns off the scheduled syncs: Run returns at once, and the Labelers fetch each table not in
+	// TableMap from TiDB on its first lookup, which is then kept for the lifetime of the resolver. It suits
+	// short-lived tools labeling a few keys, which need not run the resolver. The tables are never refreshed,
+	// so the labels of tables altered afterwards go stale. At most SyncConcurrency tables are fetched at a time;
+	// the tables missed while all of them are busy are labeled by ID only, until a later lookup fetches them.
+	Lazy bool
+	lazy lazyFetcher
+
+	// NormalizeName rewrites the database and table names reported by TiDB into the display form, e.g. to
+	// strip a per-tenant prefix. The raw names are kept in tableDetail as well.
+	NormalizeName func(db, table string) (string, string)
+	// SyncInterval is the interval between two schema syncs.
+	SyncInterval time.Duration
+	// SyncJitter is the upper bound of the random delay added to each SyncInterval.
+	SyncJitter time.Duration
+	// SyncTimeout bounds each sync as a whole. The outstanding requests are canceled once it is exceeded, and
+	// the sync fails like any other failed sync. Values below 1 mean no bound.
+	SyncTimeout time.Duration
+	// RequestTimeout bounds each request to the status API, including its retry after a 429. EndpointTimeouts
+	// overrides it for the endpoint families set, e.g. to give the heavy `/schema/{db}` requests more headroom.
+	// Values below 1 mean no bound other than SyncTimeout.
+	RequestTimeout   time.Duration
+	EndpointTimeouts map[StatusEndpoint]time.Duration
+	// EtcdGetTimeout bounds each get of the schema version from etcd, e.g. to tolerate a loaded PD. Values below
+	// 1 mean 1 second.
+	EtcdGetTimeout time.Duration
+	// SyncOnDDLOwnerChange watches the DDL owner election in etcd and syncs OwnerChangeDelay after the owner
+	// changes, since a new owner often comes with a burst of DDL. The sync is still skipped if the schema
+	// version has not changed.
+	SyncOnDDLOwnerChange bool
+	// OwnerChangeDelay is the delay between an owner change and the sync triggered by it.
+	OwnerChangeDelay time.Duration
+	// SyncOnSchemaVersionChange watches the global schema version in etcd and syncs once the version changes.
+	// The changes are coalesced over SchemaVersionDebounce from the first one, so a batch of DDL statements
+	// bumping the version many times triggers a single sync. The sync reads the version when it starts, so it
+	// always applies the latest one, and a change during the sync triggers the next sync.
+	SyncOnSchemaVersionChange bool
+	// SchemaVersionDebounce is the window over which the schema version changes are coalesced.
+	SchemaVersionDebounce time.Duration
+	// WatchKeepAliveInterval is the interval between the progress requests probing the watches in etcd.
+	// A watch not responding for two intervals is re-established, as a watch stuck after a long partition
+	// delivers no events without failing. Values below 1 disable the probes.
+	WatchKeepAliveInterval time.Duration
+	// SchemaPathName decides which form of the database names is used in the request paths. Defaults to
+	// SchemaNameOriginal. The names stored in TableMap are always the original ones.
+	SchemaPathName SchemaNameForm
+	// HiddenTables decides how the tables of the system databases are kept. Defaults to HiddenTablesTag.
+	HiddenTables HiddenTablePolicy
+	// StatsTables keeps the statistics tables of the `mysql` database, e.g. `stats_meta` and
+	// `stats_histograms`, under HiddenTablesSkip, tagged as hidden, so that the hotspots of ANALYZE are
+	// still recognized as statistics storage. Their keys are labeled `statistics` under all policies.
+	StatsTables bool
+	// ReorgPartitions stores the partitions being added or dropped by a partition DDL, e.g. `REORGANIZE
+	// PARTITION`, tagged with the DDL state of the partitioning, so that their keys are labeled by name during
+	// the DDL rather than by ID. They have no PartitionBound. They are not stored otherwise.
+	ReorgPartitions bool
+	// Cluster, if set, identifies the cluster of the tables, so that a dashboard monitoring several clusters,
+	// with a resolver for each, can tell apart their tables, whose IDs are only unique within a cluster. It is
+	// the first label of every key, the prefix `cluster.` of the labels accepted by LookupLabel, and the
+	// Cluster of the TableInfos handed out. It must be set before Run.
+	Cluster string
+	// ColdSyncTimeout and ColdSyncRetryInterval replace SyncTimeout and the delay between the syncs until the
+	// cold sync, i.e. the first sync applying a schema version, succeeds, if they are positive. Until then no
+	// key is labeled with table names, and TiDB may still be warming up, so the cold sync can be made both more
+	// patient and retried sooner. See ColdSynced.
+	ColdSyncTimeout       time.Duration
+	ColdSyncRetryInterval time.Duration
+	// ColdSyncRetryMaxInterval, if above ColdSyncRetryInterval, backs off the retries of the cold sync: the
+	// delay doubles after each failed one, up to ColdSyncRetryMaxInterval.
+	ColdSyncRetryMaxInterval time.Duration
+	// coldRetryDelay is the delay before the next retry of the cold sync, used by Run only.
+	coldRetryDelay time.Duration
+	// ColdSyncDeadline, if positive, satisfies ColdSynced once Run has been running this long without a cold
+	// sync, so that the dashboard becomes ready on a very slow cluster. The tables applied so far are served,
+	// and the keys of the tables are labeled `partial` until a sync succeeds, see Partial. Zero waits forever.
+	ColdSyncDeadline time.Duration
+	// partial is set between ColdSyncDeadline and the cold sync.
+	partial atomic.Bool
+	// StaleRevalidateSize and StaleRevalidateInterval, if both positive, revalidate the StaleRevalidateSize
+	// tables fetched the longest ago every StaleRevalidateInterval while Run is running, catching the changes
+	// missed as the schema version is unchanged, e.g. by a DDL not bumping it due to a bug. Each table costs a
+	// `/db-table` request. A table failing to be revalidated waits for its turn again.
+	StaleRevalidateSize     int
+	StaleRevalidateInterval time.Duration
+	// staleAttempts are the times of the last revalidations of the stale tables, only used by Run.
+	staleAttempts map[int64]time.Time
+	// SkipDeleteOnlyTables skips the tables in StateDeleteOnly, i.e. dropped or truncated tables whose data is
+	// waiting for the GC, so that their keys are labeled by table ID only. The tables in the other states of a
+	// DDL are always kept, tagged with their states.
+	SkipDeleteOnlyTables bool
+	// ClockSkewThreshold, if positive, compares the clock of TiDB, from the `Date` header of the `/schema`
+	// response of each sync, with the local one, exposing the difference as the `clock_skew_seconds` metric
+	// and warning if it exceeds the threshold, as the staleness of the tables is judged by the local clock.
+	ClockSkewThreshold time.Duration
+	// PruneDroppedTables removes the tables which are no longer reported by TiDB from TableMap after each
+	// complete sync, together with all partitions of each, so that TableMap is bounded by the live schema. It
+	// is set by DefaultLabelStrategyConfig, with DroppedTableGrace covering the GC window. Otherwise the dropped
+	// tables are kept forever, labeled by their last names.
+	PruneDroppedTables bool
+	// DroppedTableGrace, if positive, keeps the tables removed by PruneDroppedTables for the duration after the
+	// sync finding them dropped, labeled as `db.t (dropped)`, so that the hotspots on their keys are still
+	// explicable until the GC deletes the keys. They are removed by the first complete sync after it passes,
+	// and restored if reported by TiDB again, e.g. by `RECOVER TABLE`.
+	DroppedTableGrace time.Duration
+	// TableInfoMetricsLimit, if positive, exposes the tables in TableMap as the `table_info` metric while Run
+	// is running, labeled by their IDs and names. At most that many tables are exposed, the ones with the
+	// smallest IDs, and the rest are counted by `table_info_omitted`, so that millions of tables never melt
+	// Prometheus. The resolvers exposing `table_info` in the same process must have different Clusters.
+	TableInfoMetricsLimit int
+	// ConsistencyCheckSize is the number of tables sampled after each scheduled sync to be re-fetched and
+	// compared with TableMap, repairing and logging the ones differing from TiDB. It is a safety net costing
+	// as many requests per sync. Zero disables it.
+	ConsistencyCheckSize int
+	// RetainedVersions is the number of the recent schema versions whose tables are retained for ResolveAt,
+	// e.g. to label historical key visual data as of its schema version. The versions share the details of
+	// the unchanged tables, so each one costs the tables changed by it and a sorted slice. Zero disables it.
+	RetainedVersions int
+	versions         versionedSnapshots
+	// StreamingApply applies the tables of each database to TableMap as soon as they are fetched, rather than
+	// after all databases are fetched. Only the responses being applied are held then, unless OnSchemaFetched
+	// needs them all, which suits memory-constrained deployments with large schemas, and the Labelers see
+	// the tables of the fetched databases earlier. The cost is consistency: SupportBundle may see a sync half
+	// applied, and the databases are applied in the order their responses arrive.
+	StreamingApply bool
+	// EmptyDBName is the display name of the databases whose names are empty or only whitespace, which some
+	// status API versions report for TiDB internal objects. The tables of such databases are skipped if it is
+	// empty, rather than labeled with an empty database name.
+	EmptyDBName string
+	// CaseInsensitiveLookups makes the lookups by name, i.e. LookupLabel and CheckAgainstSQL, compare the
+	// database and table names case-insensitively, e.g. for clusters with different `lower_case_table_names`
+	// or clients folding the case of the names. The names stored and shown in the labels keep their case.
+	CaseInsensitiveLookups bool
+	// SkipPartitions stores only the tables, not their partitions, which saves most of the memory of TableMap
+	// on clusters with many partitions. The partitions are still resolved to their tables through the key
+	// index, but are labeled as the tables, e.g. `db, t` instead of `db, t/p0`, so the partitions of a table
+	// can no longer be told apart.
+	SkipPartitions bool
+	// Redirects decides which redirects of the status API are followed. Defaults to RedirectSameHost.
+	Redirects RedirectPolicy
+	// DuplicateTableIDs decides which table is kept for a table ID reported more than once in a sync.
+	// Defaults to DuplicateKeepLast.
+	DuplicateTableIDs DuplicateTableIDPolicy
+	// InconsistentIndexIDs decides how the indexes sharing an index ID are labeled. Defaults to
+	// InconsistentIndexesByName.
+	InconsistentIndexIDs InconsistentIndexIDPolicy
+	// TokenProvider, if set, provides the bearer token sent with each status API request.
+	TokenProvider TokenProvider
+	// MaxResponseSize is the maximum size in bytes of a status API response body. A larger response fails the
+	// sync, instead of being buffered in full. Values below 1 mean defaultMaxResponseSize.
+	MaxResponseSize int64
+	// MaxTableMapEntries, if positive, bounds TableMap to that many tables and partitions, evicting the least
+	// recently looked up ones, which are labeled by ID until fetched again by `/db-table/{id}` on their next
+	// lookup. It bounds the memory of huge schemas at the cost of a request for each evicted table labeled,
+	// and of the snapshots, so the lookups take the lock of the store. It is set by applyConfig, which
+	// installs the bounded store.
+	MaxTableMapEntries int
+	// MemoryBudget, if positive, is the approximate bytes TableMap may hold, including its snapshot, key index
+	// and RetainedVersions. A sync leaving TableMap over the budget degrades it a level at a time, logging each
+	// level: first the index names are dropped, then the partitions are folded into their tables like under
+	// SkipPartitions, then the entries fetched the longest ago are evicted. The degradation lasts until restart.
+	MemoryBudget int64
+	degradation  atomic.Int32
+	// Limiter, if set, is waited for before each status API request, so that the syncs are throttled by a
+	// budget shared with other components of the dashboard. Unlimited if not set.
+	Limiter RequestLimiter
+	// ResyncMissThreshold forces the next sync to walk the whole schema even if the schema version has not
+	// changed, once keys of this many distinct table IDs are labeled without being found in TableMap since
+	// the last successful sync. Zero disables it.
+	ResyncMissThreshold int
+	misses              missCounter
+	// MaxVersionStableInterval forces a sync to walk the whole schema even if the schema version has not
+	// changed, once no schema version has been applied for this long, to heal a drift on clusters where the
+	// version is not reliably bumped. Zero disables it.
+	MaxVersionStableInterval time.Duration
+	// versionAppliedAt is when a schema version was last applied, by a sync, a Preload or SetSchemaVersion.
+	versionAppliedAt atomic.Time
+	// SyncConcurrency is the number of databases whose tables are requested at the same time during a sync.
+	// Values below 1 mean one by one.
+	SyncConcurrency int
+	// WarmupConcurrency, if positive, replaces SyncConcurrency until a sync succeeds, i.e. while no schema
+	// version is applied, so that the labels are available soon after a restart without loading TiDB as much
+	// in the later syncs. A Preload with a schema version counts as applied.
+	WarmupConcurrency int
+	// LookupSampleRate is the fraction of the table keys labeled by Labelers whose table IDs are counted,
+	// to find out the tables looked up the most. See HotTables. Zero disables it.
+	LookupSampleRate float64
+	// LookupSampleWindow is the length of the rolling window of the lookup samples. Defaults to 10 minutes.
+	LookupSampleWindow time.Duration
+	samples            lookupSampler
+	// HotTablesFirst fetches the databases of each sync in the descending order of the sampled lookups of
+	// their tables, see LookupSampleRate, so that under StreamingApply the hottest tables are labeled first
+	// during a slow cold sync. Only the tables already in TableMap, e.g. preloaded, are known by database.
+	HotTablesFirst bool
+	// PriorityHint, if not nil, is called before each sync under HotTablesFirst to get the priorities of the
+	// tables by ID, e.g. by the hotness of their regions, which are added to their sampled lookups.
+	PriorityHint func() map[int64]int
+	// LogSyncSummary logs a one-line summary at info level after each successful sync.
+	LogSyncSummary bool
+	// OnSchemaFetched, if set, is called with the schema fetched by each successful sync that has requested
+	// the tables, so that other caches of the schema can be warmed without requesting TiDB again. It is called
+	// in a separate goroutine, one call at a time, and a schema is skipped if a newer one is fetched before
+	// the previous call returns.
+	OnSchemaFetched func(schema FetchedSchema)
+	notifier        schemaNotifier
+	// SnapshotStore, if set, keeps the tables across restarts: Run restores them before the first sync, and
+	// each sync applying a schema version stores them again.
+	SnapshotStore SnapshotStore
+	// AuditSink, if set, receives a record of each table and partition added, changed, renamed or removed in
+	// TableMap by the syncs, Revalidate and DropTable, with the tables before and after, as a durable record
+	// of the label changes distinct from the metrics. It is called synchronously while applying, so a slow
+	// sink delays the syncs. The changes by Preload, a restored snapshot or MemoryBudget are not recorded.
+	AuditSink AuditSink
+}
+
+// NewTableResolver creates a TableResolver with the default tunables.
+func NewTableResolver(etcdClient *clientv3.Client, tidbClient *tidb.Client) *TableResolver {
+	registerMetrics()
+
+	r := &TableResolver{
+		EtcdClient:  etcdClient,
+		etcdWatcher: etcdClient,
+		TableMap:    newSyncMapTableStore(),
+		tidbClient:  tidbStatusAPIClient{tidbClient},
+	}
+	r.applyConfig(DefaultLabelStrategyConfig())
+	r.schemaVersion.Store(-1)
+	return r
+}
+
+// Run syncs the schema periodically until ctx is done.
+func (r *TableResolver) Run(ctx context.Context) {
+	if r.lastSyncSuccess.Load().IsZero() {
+		r.lastSyncSuccess.Store(time.Now())
+	}
+	syncAgeMetrics.add(r)
+	defer syncAgeMetrics.remove(r)
+	if r.TableInfoMetricsLimit > 0 {
+		tableInfoMetrics.add(r)
+		defer tableInfoMetrics.remove(r)
+	}
+	if r.Lazy {
+		return
+	}
+	r.restoreSnapshot(ctx)
+	timer := time.NewTimer(r.nextSyncDelay())
+	defer timer.Stop()
+	var deadline <-chan time.Time
+	if r.ColdSyncDeadline > 0 && !r.initialized.Load() {
+		deadlineTimer := time.NewTimer(r.ColdSyncDeadline)
+		defer deadlineTimer.Stop()
+		deadline = deadlineTimer.C
+	}
+	ownerChanged := r.watchDDLOwner(ctx)
+	versionChanged := r.watchSchemaVersion(ctx)
+	resyncRequested := r.resyncRequests()
+	// debouncing is whether the timer is set to the end of the window coalescing the schema version changes.
+	debouncing := false
+	var staleC <-chan time.Time
+	if r.StaleRevalidateSize > 0 && r.StaleRevalidateInterval > 0 {
+		ticker := time.NewTicker(r.StaleRevalidateInterval)
+		defer ticker.Stop()
+		staleC = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			deadline = nil
+			r.servePartial()
+		case <-staleC:
+			if !r.paused.Load() {
+				r.revalidateStale(ctx)
+			}
+		case <-ownerChanged:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(r.OwnerChangeDelay)
+		case <-resyncRequested:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			debouncing = false
+			timer.Reset(0)
+		case <-versionChanged:
+			if debouncing {
+				break
+			}
+			debouncing = true
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(r.SchemaVersionDebounce)
+		case <-timer.C:
+			debouncing = false
+			if !r.paused.Load() {
+				r.persistSnapshot(ctx, r.Sync(ctx))
+				if r.ConsistencyCheckSize > 0 {
+					r.checkConsistency(ctx)
+				}
+			}
+			timer.Reset(r.nextSyncDelay())
+		}
+	}
+}
+
+// watchDDLOwner returns a channel notified when the DDL owner changes, or nil if SyncOnDDLOwnerChange is off.
+func (r *TableResolver) watchDDLOwner(ctx context.Context) <-chan struct{} {
+	if !r.SyncOnDDLOwnerChange || r.etcdWatcher == nil {
+		return nil
+	}
+	return r.watchEtcd(ctx, ddlOwnerPrefix, clientv3.WithPrefix())
+}
+
+// watchSchemaVersion returns a channel notified when the global schema version changes, or nil if
+// SyncOnSchemaVersionChange is off.
+func (r *TableResolver) watchSchemaVersion(ctx context.Context) <-chan struct{} {
+	if !r.SyncOnSchemaVersionChange || r.etcdWatcher == nil {
+		return nil
+	}
+	return r.watchEtcd(ctx, schemaVersionPath)
+}
+
+// watchEtcd returns a channel notified when the watched key changes. Notifications are coalesced, so a burst
+// of changes is seen as one. A watch closed, failed or found unresponsive is re-established, which notifies
+// the channel as well, since a change may have been missed.
+func (r *TableResolver) watchEtcd(ctx context.Context, key string, opts ...clientv3.OpOption) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	go func() {
+		for {
+			r.watchEtcdOnce(ctx, key, notify, opts...)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryDelay):
+			}
+			watchReconnections.WithLabelValues(r.Cluster, key).Inc()
+			notify()
+		}
+	}()
+	return ch
+}
+
+// watchEtcdOnce watches the key until the watch is unhealthy or ctx is done. The watch requires a leader, so
+// that it fails on an etcd member partitioned from the others, and is probed every WatchKeepAliveInterval by a
+// progress request, whose response is a progress notification.
+func (r *TableResolver) watchEtcdOnce(ctx context.Context, key string, notify func(), opts ...clientv3.OpOption) {
+	wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	watchCh := r.etcdWatcher.Watch(wctx, key, append(opts, clientv3.WithProgressNotify())...)
+	var keepAlive <-chan time.Time
+	if r.WatchKeepAliveInterval > 0 {
+		ticker := time.NewTicker(r.WatchKeepAliveInterval)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+	responded := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case resp, ok := <-watchCh:
+			if !ok {
+				log.Warn("the watch of etcd is closed, re-establish it", zap.String("key", key))
+				return
+			}
+			if err := resp.Err(); err != nil {
+				log.Warn("failed to watch etcd, re-establish it", zap.String("key", key), zap.Error(err))
+				return
+			}
+			responded = true
+			if !resp.IsProgressNotify() {
+				notify()
+			}
+		case <-keepAlive:
+			if !responded {
+				log.Warn("the watch of etcd is unresponsive, re-establish it",
+					zap.String("key", key), zap.Duration("keepalive-interval", r.WatchKeepAliveInterval))
+				return
+			}
+			responded = false
+			if err := r.etcdWatcher.RequestProgress(wctx); err != nil {
+				log.Debug("failed to request the progress of the watch of etcd", zap.String("key", key), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Sync syncs the schema once. It does nothing if the schema version has not changed since the last sync.
+// It must not be called concurrently with Run.
+func (r *TableResolver) Sync(ctx context.Context) SyncResult {
+	result := r.updateMap(ctx)
+	r.observeSyncDuration(result)
+	if result.Path != "" {
+		tableMapEntries.WithLabelValues(r.Cluster).Set(float64(r.tableCount()))
+	}
+	r.lastError.Store(result.Err)
+	r.lastResult.Store(result)
+	r.history.add(result)
+	return result
+}
+
+// Preload stores the tables known from elsewhere, e.g. a prior SQL dump, so that keys can be labeled before
+// the first sync finishes. schemaVersion is the schema version the tables are taken at: the syncs are skipped
+// until TiDB reports another version, after which the normal sync reconciles TableMap. Pass -1 to sync as
+// soon as Run starts. It should be called before Run.
+func (r *TableResolver) Preload(tables []TableInfo, schemaVersion int64) {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+	partitionIDs := make(map[int64][]int64)
+	if r.SkipPartitions {
+		for _, table := range tables {
+			if table.ParentID != 0 {
+				partitionIDs[table.ParentID] = append(partitionIDs[table.ParentID], table.ID)
+			}
+		}
+	}
+	for _, table := range tables {
+		if r.SkipPartitions && table.ParentID != 0 {
+			continue
+		}
+		indices := make(map[int64]string, len(table.Indices))
+		for id, name := range table.Indices {
+			indices[id] = name
+		}
+		r.TableMap.Store(table.ID, &tableDetail{
+			Name:     table.Name,
+			DB:       table.DB,
+			ID:       table.ID,
+			Indices:  indices,
+			ParentID: table.ParentID,
+			RawName:  table.Name,
+			RawDB:    table.DB,
+
+			PartitionPath:  append([]string(nil), table.PartitionPath...),
+			PartitionBound: table.PartitionBound,
+			TTL:            table.TTL,
+
+			PartitionIDs: partitionIDs[table.ID],
+		})
+	}
+	r.tableMapGen.Inc()
+	r.rebuildKeyIndex()
+	r.commitSnapshot()
+	r.schemaVersion.Store(schemaVersion)
+	if schemaVersion != -1 {
+		r.retainVersion(schemaVersion)
+		r.markInitialized()
+	} else {
+		r.initialized.Store(false)
+	}
+}
+
+// DropTable removes a table or partition from TableMap, with all partitions of a table, to remedy an entry
+// left behind by mistake. It returns the IDs removed, sorted, or none if the ID is not in TableMap. A table
+// still in TiDB is added back by the next sync of a new schema version.
+func (r *TableResolver) DropTable(id int64) []int64 {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+	if _, ok := r.TableMap.Load(id); !ok {
+		return nil
+	}
+	ids := []int64{id}
+	r.TableMap.Range(func(partitionID int64, detail *tableDetail) bool {
+		if detail.ParentID == id {
+			ids = append(ids, partitionID)
+		}
+		return true
+	})
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	summary, now := r.newSyncSummary(), time.Now()
+	for _, dropID := range ids {
+		detail, _ := r.TableMap.Load(dropID)
+		summary.recordAudit(now, AuditRemoved, dropID, detail, nil)
+		r.TableMap.Delete(dropID)
+		delete(r.lastSeen, dropID)
+	}
+	r.writeAudit(r.schemaVersion.Load(), summary.audit)
+	r.tableMapGen.Inc()
+	r.rebuildKeyIndex()
+	r.commitSnapshot()
+	r.changes.publish(TableMapChange{Version: r.schemaVersion.Load(), Removed: ids})
+	return ids
+}
+
+// ColdSynced returns a channel closed once the first schema version is applied, by a sync, a Preload or
+// SetSchemaVersion, after which the keys are labeled with the table names, or once ColdSyncDeadline passes.
+// A readiness gate can wait on it.
+func (r *TableResolver) ColdSynced() <-chan struct{} {
+	r.coldSyncedInit.Do(func() {
+		r.coldSynced = make(chan struct{})
+	})
+	return r.coldSynced
+}
+
+// markInitialized sets initialized, and closes ColdSynced the first time. It is called whenever a schema
+// version is applied, so it also restarts the timer of MaxVersionStableInterval.
+func (r *TableResolver) markInitialized() {
+	r.initialized.Store(true)
+	r.versionAppliedAt.Store(time.Now())
+	if r.partial.Swap(false) {
+		// Drop the cached labels tagged partial.
+		r.tableMapGen.Inc()
+		log.Info("the tidb tables are fully synced after being served as partial")
+	}
+	r.closeColdSynced()
+}
+
+func (r *TableResolver) closeColdSynced() {
+	r.ColdSynced()
+	r.coldSyncedDone.Do(func() {
+		close(r.coldSynced)
+	})
+}
+
+// servePartial satisfies ColdSynced with the tables applied so far, unless the cold sync has succeeded.
+func (r *TableResolver) servePartial() {
+	if r.initialized.Load() {
+		return
+	}
+	r.partial.Store(true)
+	r.tableMapGen.Inc()
+	r.closeColdSynced()
+	log.Warn("the cold sync of tidb tables misses its deadline, serve the tables applied so far as partial",
+		zap.Duration("deadline", r.ColdSyncDeadline))
+}
+
+// Partial reports whether the tables are served as partial, because ColdSyncDeadline has passed before the
+// cold sync succeeds.
+func (r *TableResolver) Partial() bool {
+	return r.partial.Load()
+}
+
+// SchemaVersion returns the schema version applied by the last successful sync, or -1 if there is none.
+func (r *TableResolver) SchemaVersion() int64 {
+	return r.schemaVersion.Load()
+}
+
+// SetSchemaVersion overrides the schema version applied by the last successful sync, so that the next sync
+// is skipped if TiDB still reports the given version, or forced if the version is -1.
+//
+// It bypasses the normal safety: TableMap is left untouched and may no longer match the version. It is
+// intended for tests and controlled migrations only.
+func (r *TableResolver) SetSchemaVersion(version int64) {
+	r.schemaVersion.Store(version)
+	if version != -1 {
+		r.markInitialized()
+	} else {
+		r.initialized.Store(false)
+	}
+}
+
+// Pause stops the requests to TiDB, e.g. during a maintenance window, until Resume is called: Run launches no
+// sync, no revalidation of stale tables, and a lazy resolver fetches no table. TableMap and the schema version
+// are kept, so the keys are still labeled with the tables known so far. A sync already running is not
+// interrupted, and Sync and Revalidate called directly are not paused.
+func (r *TableResolver) Pause() {
+	if !r.paused.Swap(true) {
+		log.Info("the schema sync of tidb tables is paused", zap.Int64("schema-version", r.SchemaVersion()))
+	}
+}
+
+// Resume undoes Pause. The next sync scheduled by Run compares the schema version with the one kept since
+// the pause, so only a schema changed in the meantime is synced again.
+func (r *TableResolver) Resume() {
+	if r.paused.Swap(false) {
+		log.Info("the schema sync of tidb tables is resumed", zap.Int64("schema-version", r.SchemaVersion()))
+	}
+}
+
+// ForceResync makes the next sync fetch all tables again even if the schema version has not changed, e.g.
+// after TableMap is found wrong, and wakes Run up to sync at once. It does not wait for the sync. A sync already
+// running is not affected, so the one after it resyncs, and a resync failing half way is retried by the next
+// sync, neither of which resumes from the databases fetched by the syncs failing before.
+func (r *TableResolver) ForceResync() {
+	r.forceResync.Store(true)
+	select {
+	case r.resyncRequests() <- struct{}{}:
+	default:
+	}
+}
+
+func (r *TableResolver) resyncRequests() chan struct{} {
+	r.resyncRequestedInit.Do(func() {
+		r.resyncRequested = make(chan struct{}, 1)
+	})
+	return r.resyncRequested
+}
+
+// Paused reports whether the resolver is paused by Pause.
+func (r *TableResolver) Paused() bool {
+	return r.paused.Load()
+}
+
+// LastError returns the error of the last sync, or nil if it succeeded. The error is one of
+// ErrEtcdUnavailable, ErrTiDBUnavailable, ErrParseFailed and ErrDuplicateTableID.
+func (r *TableResolver) LastError() error {
+	return r.lastError.Load()
+}
+
+// SyncHistory returns the results of the recent syncs, the latest first, including the skipped ones, so that
+// a pattern of intermittent failures can be seen. At most syncHistorySize results are kept.
+func (r *TableResolver) SyncHistory() []SyncResult {
+	return r.history.list()
+}
+
+const syncHistorySize = 32
+
+// syncHistory is a ring buffer of the recent SyncResults.
+type syncHistory struct {
+	mu      sync.Mutex
+	results []SyncResult
+	// next is the index to write the next result once results is full.
+	next int
+}
+
+func (h *syncHistory) add(result SyncResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.results) < syncHistorySize {
+		h.results = append(h.results, result)
+		return
+	}
+	h.results[h.next] = result
+	h.next = (h.next + 1) % syncHistorySize
+}
+
+func (h *syncHistory) list() []SyncResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	results := make([]SyncResult, 0, len(h.results))
+	for i := len(h.results) - 1; i >= 0; i-- {
+		results = append(results, h.results[(h.next+i)%len(h.results)])
+	}
+	return results
+}
+
+// Resolve returns the information of a table or a partition by its ID. A lazy resolver fetches it if it is not
+// in TableMap.
+func (r *TableResolver) Resolve(id int64) (TableInfo, bool) {
+	detail, ok := r.tables().Load(id)
+	if !ok {
+		return TableInfo{}, false
+	}
+	info := detail.toTableInfo()
+	info.Cluster = r.Cluster
+	return info, true
+}
+
+// ListTables returns the information of all tables and partitions in TableMap, sorted by ID. Like SupportBundle,
+// they are never half updated by a sync unless StreamingApply is set. It never fetches the tables of a lazy
+// resolver, and it sorts all tables in each call, so it is meant for tools rather than labeling.
+func (r *TableResolver) ListTables() []TableInfo {
+	r.applyMu.RLock()
+	snapshot := newTableSnapshot(r.TableMap, 0)
+	r.applyMu.RUnlock()
+	infos := make([]TableInfo, 0, len(snapshot.details))
+	for _, detail := range snapshot.details {
+		info := detail.toTableInfo()
+		info.Cluster = r.Cluster
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// ResolvedTables is the result of ResolveIDs.
+type ResolvedTables struct {
+	// Cluster is the TableResolver.Cluster the tables are resolved by.
+	Cluster string `json:"cluster,omitempty"`
+	// SchemaVersion is the schema version applied by the last successful sync, or -1 if there is none.
+	SchemaVersion int64 `json:"schema_version"`
+	// Tables are the tables and partitions found, in the order of the IDs asked, each once.
+	Tables []TableInfo `json:"tables"`
+	// Missing are the IDs not found, in the order asked, each once.
+	Missing []int64 `json:"missing"`
+}
+
+// ResolveIDs resolves a batch of table and partition IDs, like Resolve for each, so that other services can
+// share the tables synced by the resolver instead of syncing their own.
+func (r *TableResolver) ResolveIDs(ids []int64) ResolvedTables {
+	resolved := ResolvedTables{
+		Cluster:       r.Cluster,
+		SchemaVersion: r.SchemaVersion(),
+		Tables:        []TableInfo{},
+		Missing:       []int64{},
+	}
+	seen := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if info, ok := r.Resolve(id); ok {
+			resolved.Tables = append(resolved.Tables, info)
+		} else {
+			resolved.Missing = append(resolved.Missing, id)
+		}
+	}
+	return resolved
+}
+
+// LabelRelativeKey returns the labels of a key of a known table whose table prefix has been stripped, like
+// the ones shown by Key Visualizer for the full key. See DecodeRelativeKey for the form of relativeKey.
+func (r *TableResolver) LabelRelativeKey(tableID int64, relativeKey []byte) ([]string, error) {
+	if _, err := DecodeRelativeKey(tableID, relativeKey); err != nil {
+		return nil, err
+	}
+	labeler := &tidbLabeler{
+		Cluster:  r.Cluster,
+		TableMap: r.tables(),
+		Decoder:  NewTiDBKeyDecoder(),
+		OnMiss:   r.recordMiss,
+		OnLookup: r.recordLookup,
+	}
+	key := model.EncodeKey(model.GenerateRawTableKey(tableID, relativeKey))
+	return labeler.label(string(key)).Labels, nil
+}
+
+// IndexLabel returns the label of a decoded index key in the `db.table.index` form, or `db.table/partition.index`
+// for a partition, prefixed by `cluster.` if Cluster is set. The names of the indexes of a partition are looked
+// up in its partitioned table. It falls back to the label of the table, as LookupLabel takes, for a row key,
+// whose indexID is 0, or for an index not found. It returns false if the table is not in TableMap.
+func (r *TableResolver) IndexLabel(tableID, indexID int64) (string, bool) {
+	detail, ok := r.tables().Load(tableID)
+	if !ok {
+		return "", false
+	}
+	label := detail.DB + "." + detail.Name
+	if r.Cluster != "" {
+		label = r.Cluster + "." + label
+	}
+	if indexID == 0 {
+		return label, true
+	}
+	indices := detail.Indices
+	if detail.ParentID != 0 {
+		if parent, ok := r.tables().Load(detail.ParentID); ok {
+			indices = parent.Indices
+		}
+	}
+	if name, ok := indices[indexID]; ok {
+		label += "." + name
+	}
+	return label, true
+}
+
+// LookupLabel returns the tables and partitions whose label, in the `db.table` or `db.table/partition` form
+// shown by Key Visualizer, equals the given one. As database and table names may contain `.` or `/`, a label
+// can be ambiguous, in which case all matches are returned, sorted by ID. If Cluster is set, the label must be
+// prefixed by `cluster.`, like the labels of the keys. The names are compared case-insensitively under
+// CaseInsensitiveLookups.
+func (r *TableResolver) LookupLabel(label string) []TableInfo {
+	var infos []TableInfo
+	if r.Cluster != "" {
+		if !strings.HasPrefix(label, r.Cluster+".") {
+			return infos
+		}
+		label = label[len(r.Cluster)+1:]
+	}
+	match := func(detail *tableDetail) {
+		if len(detail.DB)+1+len(detail.Name) == len(label) &&
+			r.namesEqual(label[:len(detail.DB)], detail.DB) &&
+			label[len(detail.DB)] == '.' &&
+			r.namesEqual(label[len(detail.DB)+1:], detail.Name) {
+			info := detail.toTableInfo()
+			info.Cluster = r.Cluster
+			infos = append(infos, info)
+		}
+	}
+	if snapshot, ok := r.tables().(*tableSnapshot); ok {
+		for _, detail := range snapshot.details {
+			match(detail)
+		}
+	} else {
+		r.TableMap.Range(func(_ int64, detail *tableDetail) bool {
+			match(detail)
+			return true
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+// namesEqual compares two database or table names, case-insensitively under CaseInsensitiveLookups.
+func (r *TableResolver) namesEqual(a, b string) bool {
+	if r.CaseInsensitiveLookups {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// missCounter counts the distinct table IDs missed by Labelers since the last successful sync.
+type missCounter struct {
+	mu  sync.Mutex
+	ids map[int64]struct{}
+}
+
+func (m *missCounter) record(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ids == nil {
+		m.ids = make(map[int64]struct{})
+	}
+	m.ids[id] = struct{}{}
+}
+
+func (m *missCounter) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.ids)
+}
+
+func (m *missCounter) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ids = nil
+}
+
+// recordMiss is called by Labelers when a table ID is not found in TableMap.
+func (r *TableResolver) recordMiss(id int64) {
+	if r.ResyncMissThreshold <= 0 || id == 0 {
+		return
+	}
+	r.misses.record(id)
+}
+
+// shouldForceResync reports whether the number of missed table IDs has reached ResyncMissThreshold.
+func (r *TableResolver) shouldForceResync() bool {
+	return r.ResyncMissThreshold > 0 && r.misses.count() >= r.ResyncMissThreshold
+}
+
+// versionStableTooLong reports whether no schema version has been applied for MaxVersionStableInterval.
+func (r *TableResolver) versionStableTooLong() bool {
+	return r.MaxVersionStableInterval > 0 && time.Since(r.versionAppliedAt.Load()) >= r.MaxVersionStableInterval
+}
+
+// tables returns the lookup for a Labeler. It is a snapshot of TableMap, rebuilt only after TableMap is
+// updated. While a sync is applying its tables, the snapshot committed by the last update is returned, so that
+// a half updated TableMap is never looked up through, unless StreamingApply is set. An LRU TableMap is looked up
+// through evictedTables, which keeps the recency of tables and fetches the evicted ones in the background. A lazy
+// resolver returns a lookup fetching the missing tables.
+func (r *TableResolver) tables() tableLookup {
+	if r.Lazy {
+		return lazyTables{r}
+	}
+	if store, ok := r.TableMap.(*lruTableStore); ok {
+		return evictedTables{r: r, store: store}
+	}
+	cached, ok := r.snapshotCache.Load().(*tableSnapshot)
+	if ok && cached.gen == r.tableMapGen.Load() {
+		return cached
+	}
+	if !r.applyMu.TryRLock() {
+		if ok {
+			return cached
+		}
+		return &tableSnapshot{gen: -1}
+	}
+	defer r.applyMu.RUnlock()
+	return r.snapshotLocked()
+}
+
+// snapshotLocked returns the snapshot of TableMap, rebuilding it if TableMap has been updated. It is called
+// with applyMu held, so that the snapshot is never taken half way through an update.
+func (r *TableResolver) snapshotLocked() *tableSnapshot {
+	gen := r.tableMapGen.Load()
+	old, ok := r.snapshotCache.Load().(*tableSnapshot)
+	if ok && old.gen == gen {
+		return old
+	}
+	if invariantChecks && ok {
+		old.checkUnmodified()
+	}
+	snapshot := newTableSnapshot(r.TableMap, gen)
+	r.snapshotCache.Store(snapshot)
+	return snapshot
+}
+
+// commitSnapshot rebuilds the snapshot at the end of an update of TableMap, with applyMu held for writing, so
+// that the lookups during the next update are served by it.
+func (r *TableResolver) commitSnapshot() {
+	if r.Lazy {
+		return
+	}
+	if _, ok := r.TableMap.(*lruTableStore); ok {
+		return
+	}
+	r.snapshotLocked()
+}
+
+// nextSyncDelay returns SyncInterval plus a random jitter in [0, SyncJitter),
+// so that several dashboard replicas do not sync in lockstep. Before the cold sync, it is
+// ColdSyncRetryInterval if set, backed off up to ColdSyncRetryMaxInterval by each call.
+func (r *TableResolver) nextSyncDelay() time.Duration {
+	if r.ColdSyncRetryInterval > 0 && !r.initialized.Load() {
+		delay := r.coldRetryDelay
+		if delay < r.ColdSyncRetryInterval {
+			delay = r.ColdSyncRetryInterval
+		}
+		r.coldRetryDelay = delay * 2
+		if r.coldRetryDelay > r.ColdSyncRetryMaxInterval {
+			r.coldRetryDelay = r.ColdSyncRetryMaxInterval
+		}
+		return delay
+	}
+	if r.SyncJitter <= 0 {
+		return r.SyncInterval
+	}
+	return r.SyncInterval + time.Duration(rand.Int63n(int64(r.SyncJitter))) // #nosec
+}
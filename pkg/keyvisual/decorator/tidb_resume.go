@@ -0,0 +1,78 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// dbKey identifies a database as reported by `/schema`, so that a database renamed, or recreated under the same
+// name, is not taken as the one fetched before.
+type dbKey struct {
+	id    int64
+	name  string
+	state model.SchemaState
+}
+
+func newDBKey(db *model.DBInfo) dbKey {
+	return dbKey{id: db.ID, name: db.Name.O, state: db.State}
+}
+
+// resumedSync is the progress of the syncs failing half way toward a schema version. The tables of the
+// databases fetched by them are already applied to TableMap, so the next sync toward the same version only
+// fetches the rest. It is only used by updateMap, which never runs concurrently.
+type resumedSync struct {
+	version int64
+	fetched map[dbKey]struct{}
+	// seen are the tables and partitions of the fetched databases, see syncSummary.seen.
+	seen map[int64]struct{}
+}
+
+// pending returns the databases still to be fetched toward version, and marks the tables of the others as seen
+// by summary. All databases are pending if the progress is toward another version, or if OnSchemaFetched
+// needs the tables of all databases.
+func (r *TableResolver) pending(version int64, dbInfos []*model.DBInfo, summary *syncSummary) []*model.DBInfo {
+	resumed := r.resumed
+	if resumed == nil || resumed.version != version || r.OnSchemaFetched != nil {
+		r.resumed = nil
+		return dbInfos
+	}
+	pending := make([]*model.DBInfo, 0, len(dbInfos))
+	for _, db := range dbInfos {
+		if _, ok := resumed.fetched[newDBKey(db)]; !ok {
+			pending = append(pending, db)
+		}
+	}
+	for id := range resumed.seen {
+		summary.seen[id] = struct{}{}
+	}
+	return pending
+}
+
+// recordProgress records the databases fetched by a sync toward version which fails to fetch the others, so
+// that the next sync resumes from them. The progress is dropped by a sync fetching every database, or failing
+// for another reason after fetching them, and by a sync toward another version.
+func (r *TableResolver) recordProgress(version int64, dbInfos []*model.DBInfo, fetched []dbTableInfos, summary *syncSummary) {
+	failed := false
+	succeeded := make(map[string]struct{}, len(fetched))
+	for _, res := range fetched {
+		if res.err != nil {
+			failed = true
+		} else {
+			succeeded[res.dbName] = struct{}{}
+		}
+	}
+	if !failed || summary.duplicateErr != nil || r.OnSchemaFetched != nil {
+		r.resumed = nil
+		return
+	}
+	if r.resumed == nil || r.resumed.version != version {
+		r.resumed = &resumedSync{version: version, fetched: make(map[dbKey]struct{})}
+	}
+	for _, db := range dbInfos {
+		if _, ok := succeeded[db.Name.O]; ok {
+			r.resumed.fetched[newDBKey(db)] = struct{}{}
+		}
+	}
+	r.resumed.seen = summary.seen
+}
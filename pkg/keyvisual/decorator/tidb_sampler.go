@@ -0,0 +1,114 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultLookupSampleWindow = 10 * time.Minute
+
+// LookupSample is the number of sampled label lookups of a table or a partition.
+type LookupSample struct {
+	TableID int64  `json:"table_id"`
+	DB      string `json:"db"`
+	Name    string `json:"name"`
+	Count   int    `json:"count"`
+}
+
+// lookupSampler counts the sampled lookups by table ID over a rolling window. The counts of the current and
+// the previous window are kept, so they cover the last one to two LookupSampleWindow.
+type lookupSampler struct {
+	mu          sync.Mutex
+	current     map[int64]int
+	previous    map[int64]int
+	windowStart time.Time
+}
+
+func (s *lookupSampler) record(id int64, window time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotate(window, now)
+	if s.current == nil {
+		s.current = make(map[int64]int)
+	}
+	s.current[id]++
+}
+
+// rotate moves to a new window if the current one has expired. It must be called with mu held.
+func (s *lookupSampler) rotate(window time.Duration, now time.Time) {
+	elapsed := now.Sub(s.windowStart)
+	if elapsed < window {
+		return
+	}
+	if elapsed < 2*window {
+		s.previous = s.current
+	} else {
+		s.previous = nil
+	}
+	s.current = nil
+	s.windowStart = now
+}
+
+// top returns the n table IDs with the most lookups and their counts, sorted by count in descending order.
+func (s *lookupSampler) top(n int, window time.Duration, now time.Time) []LookupSample {
+	s.mu.Lock()
+	s.rotate(window, now)
+	counts := make(map[int64]int, len(s.current)+len(s.previous))
+	for id, count := range s.previous {
+		counts[id] += count
+	}
+	for id, count := range s.current {
+		counts[id] += count
+	}
+	s.mu.Unlock()
+
+	samples := make([]LookupSample, 0, len(counts))
+	for id, count := range counts {
+		samples = append(samples, LookupSample{TableID: id, Count: count})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Count != samples[j].Count {
+			return samples[i].Count > samples[j].Count
+		}
+		return samples[i].TableID < samples[j].TableID
+	})
+	if len(samples) > n {
+		samples = samples[:n]
+	}
+	return samples
+}
+
+// recordLookup is called by Labelers for each table key they label.
+func (r *TableResolver) recordLookup(id int64) {
+	if r.LookupSampleRate <= 0 || id == 0 {
+		return
+	}
+	if r.LookupSampleRate < 1 && rand.Float64() >= r.LookupSampleRate { // #nosec
+		return
+	}
+	r.samples.record(id, r.lookupSampleWindow(), time.Now())
+}
+
+// HotTables returns the n tables with the most sampled label lookups in the rolling window, sorted by count
+// in descending order. The tables not found in TableMap are returned with empty names. It returns nothing if
+// LookupSampleRate is zero.
+func (r *TableResolver) HotTables(n int) []LookupSample {
+	samples := r.samples.top(n, r.lookupSampleWindow(), time.Now())
+	for i := range samples {
+		if detail, ok := r.TableMap.Load(samples[i].TableID); ok {
+			samples[i].DB, samples[i].Name = detail.DB, detail.Name
+		}
+	}
+	return samples
+}
+
+func (r *TableResolver) lookupSampleWindow() time.Duration {
+	if r.LookupSampleWindow <= 0 {
+		return defaultLookupSampleWindow
+	}
+	return r.LookupSampleWindow
+}
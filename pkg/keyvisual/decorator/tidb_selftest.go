@@ -0,0 +1,71 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"encoding/hex"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// selfTestRowID is the row ID of the row key synthesized by SelfTest.
+const selfTestRowID = 1
+
+// SelfTestResult is the result of SelfTest.
+type SelfTestResult struct {
+	TableID int64 `json:"table_id"`
+	// Key is the synthesized row key, in hex.
+	Key string `json:"key"`
+	// Expected is the table in the `db.table` form that the key should be labeled with, or empty if the table
+	// is not resolved.
+	Expected string `json:"expected"`
+	// DecodedTableID is the table ID decoded from the key.
+	DecodedTableID int64 `json:"decoded_table_id"`
+	// Labels are the labels of the key.
+	Labels []string `json:"labels"`
+	// OK is whether the key is decoded into the table ID and labeled with the expected table.
+	OK bool `json:"ok"`
+	// Reason is why the self test fails, or empty if it is OK.
+	Reason string `json:"reason,omitempty"`
+}
+
+// SelfTest synthesizes a row key of a known table and sends it through the decoder and the lookup of the
+// labels, as a smoke test of the codec and TableMap that needs no live data. The key is an int handle one,
+// which is labeled by table even for a clustered table.
+func (r *TableResolver) SelfTest(tableID int64) SelfTestResult {
+	key := model.GenerateRowKey(tableID, selfTestRowID)
+	result := SelfTestResult{
+		TableID: tableID,
+		Key:     hex.EncodeToString(key),
+		Labels:  []string{},
+	}
+	detail, ok := r.tables().Load(tableID)
+	if !ok {
+		result.Reason = "the table is not resolved"
+		return result
+	}
+	result.Expected = detail.DB + "." + detail.Name
+
+	keyInfo := NewTiDBKeyDecoder().DecodeKey(key)
+	result.DecodedTableID = keyInfo.TableID
+	labeler := &tidbLabeler{
+		Cluster:  r.Cluster,
+		TableMap: r.tables(),
+		Decoder:  NewTiDBKeyDecoder(),
+	}
+	result.Labels = labeler.label(string(key)).Labels
+
+	labels := result.Labels
+	if r.Cluster != "" && len(labels) > 0 && labels[0] == r.Cluster {
+		labels = labels[1:]
+	}
+	switch {
+	case keyInfo.IsMeta || keyInfo.TableID != tableID:
+		result.Reason = "the key is not decoded into the table ID"
+	case len(labels) < 2 || labels[0]+"."+labels[1] != result.Expected:
+		result.Reason = "the key is not labeled with the table"
+	default:
+		result.OK = true
+	}
+	return result
+}
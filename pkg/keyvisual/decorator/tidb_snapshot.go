@@ -0,0 +1,339 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// snapshotFormatV6 is the first byte of a snapshot encoded by encodeTableSnapshot.
+// Bump it whenever the layout below changes.
+const snapshotFormatV6 byte = 6
+
+const (
+	snapshotFlagHidden       byte = 1 << 0
+	snapshotFlagClustered    byte = 1 << 1
+	snapshotFlagSequence     byte = 1 << 2
+	snapshotFlagTTL          byte = 1 << 3
+	snapshotFlagCommonHandle byte = 1 << 4
+)
+
+// encodeTableSnapshot encodes all tables in the store in a compact binary form, sorted by table ID:
+//
+//	version byte | count uvarint | table*
+//	table: id varint | name | db | len(indices) uvarint | (index id varint | index name)* |
+//	       len(pk columns) uvarint | pk column* | raw name | raw db | flags byte | parent id varint |
+//	       len(global indices) uvarint | global index id varint* | len(partition ids) uvarint | partition id varint* |
+//	       ddl state byte | len(partition path) uvarint | partition path component* | partition bound
+//
+// Strings are encoded as a uvarint length followed by the bytes. Bit 0 of flags is tableDetail.Hidden, bit 1
+// is tableDetail.Clustered, bit 2 is set for a sequence, bit 3 is tableDetail.TTL and bit 4 is
+// tableDetail.CommonHandle. The unknown bits are ignored when decoding.
+func encodeTableSnapshot(tableMap tableStore) []byte {
+	var details []*tableDetail
+	tableMap.Range(func(_ int64, detail *tableDetail) bool {
+		details = append(details, detail)
+		return true
+	})
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].ID < details[j].ID
+	})
+
+	e := snapshotEncoder{buf: []byte{snapshotFormatV6}}
+	e.uvarint(uint64(len(details)))
+	for _, detail := range details {
+		e.varint(detail.ID)
+		e.string(detail.Name)
+		e.string(detail.DB)
+		indexIDs := make([]int64, 0, len(detail.Indices))
+		for id := range detail.Indices {
+			indexIDs = append(indexIDs, id)
+		}
+		sort.Slice(indexIDs, func(i, j int) bool {
+			return indexIDs[i] < indexIDs[j]
+		})
+		e.uvarint(uint64(len(indexIDs)))
+		for _, id := range indexIDs {
+			e.varint(id)
+			e.string(detail.Indices[id])
+		}
+		e.uvarint(uint64(len(detail.PKColumns)))
+		for _, col := range detail.PKColumns {
+			e.string(col)
+		}
+		e.string(detail.RawName)
+		e.string(detail.RawDB)
+		var flags byte
+		if detail.Hidden {
+			flags |= snapshotFlagHidden
+		}
+		if detail.Clustered {
+			flags |= snapshotFlagClustered
+		}
+		if detail.Kind == tableKindSequence {
+			flags |= snapshotFlagSequence
+		}
+		if detail.TTL {
+			flags |= snapshotFlagTTL
+		}
+		if detail.CommonHandle {
+			flags |= snapshotFlagCommonHandle
+		}
+		e.buf = append(e.buf, flags)
+		e.varint(detail.ParentID)
+		globalIDs := make([]int64, 0, len(detail.GlobalIndices))
+		for id := range detail.GlobalIndices {
+			globalIDs = append(globalIDs, id)
+		}
+		sort.Slice(globalIDs, func(i, j int) bool {
+			return globalIDs[i] < globalIDs[j]
+		})
+		e.uvarint(uint64(len(globalIDs)))
+		for _, id := range globalIDs {
+			e.varint(id)
+		}
+		e.uvarint(uint64(len(detail.PartitionIDs)))
+		for _, id := range detail.PartitionIDs {
+			e.varint(id)
+		}
+		e.buf = append(e.buf, byte(detail.DDLState))
+		e.uvarint(uint64(len(detail.PartitionPath)))
+		for _, name := range detail.PartitionPath {
+			e.string(name)
+		}
+		e.string(detail.PartitionBound)
+	}
+	return e.buf
+}
+
+// decodeTableSnapshot stores the tables encoded by encodeTableSnapshot into the store.
+// Nothing is stored if the data is invalid.
+func decodeTableSnapshot(data []byte, tableMap tableStore) error {
+	if len(data) == 0 {
+		return ErrParseFailed.New("empty table snapshot")
+	}
+	if data[0] != snapshotFormatV6 {
+		return ErrParseFailed.New("unsupported table snapshot format %d", data[0])
+	}
+
+	d := snapshotDecoder{buf: data[1:]}
+	count := d.length()
+	details := make([]*tableDetail, 0, count)
+	for i := 0; i < count && d.err == nil; i++ {
+		detail := &tableDetail{
+			ID:   d.varint(),
+			Name: d.string(),
+			DB:   d.string(),
+		}
+		indexCount := d.length()
+		detail.Indices = make(map[int64]string, indexCount)
+		for j := 0; j < indexCount && d.err == nil; j++ {
+			id := d.varint()
+			detail.Indices[id] = d.string()
+		}
+		if pkCount := d.length(); pkCount > 0 {
+			detail.PKColumns = make([]string, 0, pkCount)
+			for j := 0; j < pkCount && d.err == nil; j++ {
+				detail.PKColumns = append(detail.PKColumns, d.string())
+			}
+		}
+		detail.RawName = d.string()
+		detail.RawDB = d.string()
+		flags := d.byte()
+		detail.Hidden = flags&snapshotFlagHidden != 0
+		detail.Clustered = flags&snapshotFlagClustered != 0
+		if flags&snapshotFlagSequence != 0 {
+			detail.Kind = tableKindSequence
+		}
+		detail.TTL = flags&snapshotFlagTTL != 0
+		detail.CommonHandle = flags&snapshotFlagCommonHandle != 0
+		detail.ParentID = d.varint()
+		if globalCount := d.length(); globalCount > 0 {
+			detail.GlobalIndices = make(map[int64]struct{}, globalCount)
+			for j := 0; j < globalCount && d.err == nil; j++ {
+				detail.GlobalIndices[d.varint()] = struct{}{}
+			}
+		}
+		if partitionCount := d.length(); partitionCount > 0 {
+			detail.PartitionIDs = make([]int64, 0, partitionCount)
+			for j := 0; j < partitionCount && d.err == nil; j++ {
+				detail.PartitionIDs = append(detail.PartitionIDs, d.varint())
+			}
+		}
+		detail.DDLState = model.SchemaState(d.byte())
+		if pathLen := d.length(); pathLen > 0 {
+			detail.PartitionPath = make([]string, 0, pathLen)
+			for j := 0; j < pathLen && d.err == nil; j++ {
+				detail.PartitionPath = append(detail.PartitionPath, d.string())
+			}
+		}
+		detail.PartitionBound = d.string()
+		details = append(details, detail)
+	}
+	if d.err == nil && len(d.buf) != 0 {
+		d.err = ErrParseFailed.New("%d trailing bytes in table snapshot", len(d.buf))
+	}
+	if d.err != nil {
+		return d.err
+	}
+
+	for _, detail := range details {
+		tableMap.Store(detail.ID, detail)
+	}
+	return nil
+}
+
+type snapshotEncoder struct {
+	buf     []byte
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *snapshotEncoder) uvarint(v uint64) {
+	n := binary.PutUvarint(e.scratch[:], v)
+	e.buf = append(e.buf, e.scratch[:n]...)
+}
+
+func (e *snapshotEncoder) varint(v int64) {
+	n := binary.PutVarint(e.scratch[:], v)
+	e.buf = append(e.buf, e.scratch[:n]...)
+}
+
+func (e *snapshotEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// snapshotDecoder keeps the first error, after which all reads return zero values.
+type snapshotDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *snapshotDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = ErrParseFailed.New("invalid uvarint in table snapshot")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *snapshotDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = ErrParseFailed.New("invalid varint in table snapshot")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *snapshotDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.buf) == 0 {
+		d.err = ErrParseFailed.New("unexpected end of table snapshot")
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+// length reads a count or a string length, which can not exceed the remaining bytes.
+func (d *snapshotDecoder) length() int {
+	v := d.uvarint()
+	if d.err == nil && v > uint64(len(d.buf)) {
+		d.err = ErrParseFailed.New("invalid length %d in table snapshot", v)
+		return 0
+	}
+	return int(v)
+}
+
+func (d *snapshotDecoder) string() string {
+	n := d.length()
+	if d.err != nil {
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+// SnapshotDiff is the difference between two snapshots of TableMap, each list sorted by ID. A table dropped and
+// created again, e.g. by TRUNCATE TABLE, has a new ID, so it is both removed and added.
+type SnapshotDiff struct {
+	Added   []TableInfo `json:"added"`
+	Removed []TableInfo `json:"removed"`
+	// Renamed are the tables and partitions keeping their IDs under other names.
+	Renamed []SnapshotRename `json:"renamed"`
+	// Changed are the IDs of the tables and partitions keeping their names but changed otherwise, e.g. in their
+	// indexes.
+	Changed []int64 `json:"changed"`
+}
+
+// SnapshotRename is a table or a partition renamed between two snapshots.
+type SnapshotRename struct {
+	ID      int64  `json:"id"`
+	OldDB   string `json:"old_db"`
+	OldName string `json:"old_name"`
+	NewDB   string `json:"new_db"`
+	NewName string `json:"new_name"`
+}
+
+// Snapshot encodes all tables in TableMap, never half updated by a sync unless StreamingApply is set. It can
+// be compared with a snapshot taken at another time by DiffSnapshots.
+func (r *TableResolver) Snapshot() []byte {
+	r.applyMu.RLock()
+	defer r.applyMu.RUnlock()
+	return encodeTableSnapshot(r.TableMap)
+}
+
+// DiffSnapshots reports the tables added, removed, renamed and changed from the older snapshot to the newer
+// one, both encoded by Snapshot.
+func DiffSnapshots(older, newer []byte) (SnapshotDiff, error) {
+	oldMap, newMap := newSyncMapTableStore(), newSyncMapTableStore()
+	if err := decodeTableSnapshot(older, oldMap); err != nil {
+		return SnapshotDiff{}, err
+	}
+	if err := decodeTableSnapshot(newer, newMap); err != nil {
+		return SnapshotDiff{}, err
+	}
+	oldSnapshot, newSnapshot := newTableSnapshot(oldMap, 0), newTableSnapshot(newMap, 0)
+
+	diff := SnapshotDiff{Added: []TableInfo{}, Removed: []TableInfo{}, Renamed: []SnapshotRename{}, Changed: []int64{}}
+	for _, old := range oldSnapshot.details {
+		detail, ok := newSnapshot.Load(old.ID)
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, old.toTableInfo())
+		case detail.DB != old.DB || detail.Name != old.Name:
+			diff.Renamed = append(diff.Renamed, SnapshotRename{
+				ID:      old.ID,
+				OldDB:   old.DB,
+				OldName: old.Name,
+				NewDB:   detail.DB,
+				NewName: detail.Name,
+			})
+		case !detail.equal(old):
+			diff.Changed = append(diff.Changed, old.ID)
+		}
+	}
+	for _, detail := range newSnapshot.details {
+		if _, ok := oldSnapshot.Load(detail.ID); !ok {
+			diff.Added = append(diff.Added, detail.toTableInfo())
+		}
+	}
+	return diff, nil
+}
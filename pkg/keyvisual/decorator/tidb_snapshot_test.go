@@ -0,0 +1,157 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/joomcode/errorx"
+	. "github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+var _ = Suite(&testSnapshotSuite{})
+
+type testSnapshotSuite struct{}
+
+func newTestSnapshotStore(n int) *syncMapTableStore {
+	tableMap := newSyncMapTableStore()
+	for i := 0; i < n; i++ {
+		id := int64(i + 100)
+		tableMap.Store(id, &tableDetail{
+			ID:      id,
+			Name:    fmt.Sprintf("table_%d", i),
+			DB:      fmt.Sprintf("db_%d", i%100),
+			Indices: map[int64]string{1: "PRIMARY", 2: fmt.Sprintf("idx_%d", i)},
+			RawName: fmt.Sprintf("table_%d", i),
+			RawDB:   fmt.Sprintf("db_%d", i%100),
+		})
+	}
+	return tableMap
+}
+
+func (s *testSnapshotSuite) TestRoundTrip(c *C) {
+	tableMap := newTestSnapshotStore(10)
+	tableMap.Store(-5, &tableDetail{
+		ID: -5, Name: "名字", DB: "", Indices: map[int64]string{3: "uk"}, GlobalIndices: map[int64]struct{}{3: {}},
+		PKColumns: []string{"a", "b"}, Clustered: true, Hidden: true, ParentID: 7, DDLState: model.StateWriteReorganization,
+	})
+	tableMap.Store(20, &tableDetail{ID: 20, Name: "p", DB: "test", Indices: map[int64]string{}, PartitionIDs: []int64{22, 21}})
+	tableMap.Store(23, &tableDetail{ID: 23, Name: "p/p0/sp1", DB: "test", Indices: map[int64]string{}, ParentID: 20, PartitionPath: []string{"p0", "sp1"}, PartitionBound: "RANGE[10,20)"})
+	tableMap.Store(30, &tableDetail{ID: 30, Name: "seq", DB: "test", Indices: map[int64]string{}, Kind: tableKindSequence})
+	tableMap.Store(31, &tableDetail{ID: 31, Name: "events", DB: "test", Indices: map[int64]string{}, TTL: true})
+
+	data := encodeTableSnapshot(tableMap)
+	c.Assert(data[0], Equals, snapshotFormatV6)
+	decoded := newSyncMapTableStore()
+	c.Assert(decodeTableSnapshot(data, decoded), IsNil)
+
+	count := 0
+	tableMap.Range(func(id int64, detail *tableDetail) bool {
+		count++
+		other, ok := decoded.Load(id)
+		c.Assert(ok, IsTrue)
+		c.Assert(other.equal(detail), IsTrue, Commentf("table %d", id))
+		return true
+	})
+	c.Assert(count, Equals, 15)
+}
+
+func (s *testSnapshotSuite) TestInvalidData(c *C) {
+	data := encodeTableSnapshot(newTestSnapshotStore(3))
+
+	testcases := [][]byte{
+		nil,
+		{snapshotFormatV6 + 1},
+		data[:len(data)-1],
+		append(append([]byte{}, data...), 0),
+		{snapshotFormatV6, 0xff, 0xff, 0xff, 0xff, 0x0f},
+	}
+	for i, data := range testcases {
+		decoded := newSyncMapTableStore()
+		err := decodeTableSnapshot(data, decoded)
+		c.Assert(errorx.IsOfType(err, ErrParseFailed), IsTrue, Commentf("case %d", i))
+		_, ok := decoded.Load(100)
+		c.Assert(ok, IsFalse)
+	}
+}
+
+func (s *testSnapshotSuite) TestDiffSnapshots(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{
+		newTestTableInfo(10, "orders", newTestIndexInfo(1, "idx")),
+		newTestTableInfo(20, "users"),
+		newTestTableInfo(30, "logs"),
+		newTestTableInfo(40, "items"),
+	}, newSyncSummary())
+	older := resolver.Snapshot()
+
+	// Table 10 is renamed, table 20 is truncated, which replaces it with a new ID, table 30 loses its index
+	// and table 40 is dropped.
+	resolver.TableMap = newSyncMapTableStore()
+	resolver.updateTableMap("test", []*model.TableInfo{
+		newTestTableInfo(10, "orders_v2", newTestIndexInfo(1, "idx")),
+		newTestTableInfo(21, "users"),
+		newTestTableInfo(30, "logs", newTestIndexInfo(1, "idx")),
+	}, newSyncSummary())
+	newer := resolver.Snapshot()
+
+	diff, err := DiffSnapshots(older, newer)
+	c.Assert(err, IsNil)
+	c.Assert(diff.Renamed, DeepEquals, []SnapshotRename{{ID: 10, OldDB: "test", OldName: "orders", NewDB: "test", NewName: "orders_v2"}})
+	c.Assert(diff.Added, HasLen, 1)
+	c.Assert(diff.Added[0].ID, Equals, int64(21))
+	c.Assert(diff.Removed, HasLen, 2)
+	c.Assert(diff.Removed[0].ID, Equals, int64(20))
+	c.Assert(diff.Removed[1].ID, Equals, int64(40))
+	c.Assert(diff.Changed, DeepEquals, []int64{30})
+
+	diff, err = DiffSnapshots(newer, newer)
+	c.Assert(err, IsNil)
+	c.Assert(diff, DeepEquals, SnapshotDiff{Added: []TableInfo{}, Removed: []TableInfo{}, Renamed: []SnapshotRename{}, Changed: []int64{}})
+
+	_, err = DiffSnapshots(older, newer[:len(newer)-1])
+	c.Assert(errorx.IsOfType(err, ErrParseFailed), IsTrue)
+}
+
+const benchmarkSnapshotTables = 1000000
+
+func BenchmarkSnapshotBinary(b *testing.B) {
+	tableMap := newTestSnapshotStore(benchmarkSnapshotTables)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data := encodeTableSnapshot(tableMap)
+		if err := decodeTableSnapshot(data, newSyncMapTableStore()); err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(len(data)), "bytes")
+	}
+}
+
+func BenchmarkSnapshotJSON(b *testing.B) {
+	tableMap := newTestSnapshotStore(benchmarkSnapshotTables)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var details []*tableDetail
+		tableMap.Range(func(_ int64, detail *tableDetail) bool {
+			details = append(details, detail)
+			return true
+		})
+		data, err := json.Marshal(details)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var out []*tableDetail
+		if err := json.Unmarshal(data, &out); err != nil {
+			b.Fatal(err)
+		}
+		decoded := newSyncMapTableStore()
+		for _, detail := range out {
+			decoded.Store(detail.ID, detail)
+		}
+		b.ReportMetric(float64(len(data)), "bytes")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// SQLTable is a table or a partition listed by SQL, e.g. from `INFORMATION_SCHEMA.TABLES` and
+// `INFORMATION_SCHEMA.PARTITIONS`, which back `SHOW TABLE STATUS`.
+type SQLTable struct {
+	ID int64  `json:"id"`
+	DB string `json:"db"`
+	// Name is the name of the table, or of the partitioned table for a partition.
+	Name string `json:"name"`
+	// Partition is the name of a partition, or empty for a table.
+	Partition string `json:"partition,omitempty"`
+}
+
+// SQLDiscrepancyKind is how TableMap disagrees with SQL on a table ID.
+type SQLDiscrepancyKind string
+
+const (
+	// SQLDiscrepancyMissing is a table listed by SQL but not in TableMap.
+	SQLDiscrepancyMissing SQLDiscrepancyKind = "missing"
+	// SQLDiscrepancyExtra is a table in TableMap but not listed by SQL.
+	SQLDiscrepancyExtra SQLDiscrepancyKind = "extra"
+	// SQLDiscrepancyName is a table whose database or name differs between TableMap and SQL.
+	SQLDiscrepancyName SQLDiscrepancyKind = "name"
+)
+
+// SQLDiscrepancy is a table ID on which TableMap disagrees with SQL. The names are the raw ones reported by
+// TiDB, before NormalizeName is applied, in the `t/p0` form for a partition, and empty on the side missing it.
+type SQLDiscrepancy struct {
+	Kind    SQLDiscrepancyKind `json:"kind"`
+	ID      int64              `json:"id"`
+	MapDB   string             `json:"map_db,omitempty"`
+	MapName string             `json:"map_name,omitempty"`
+	SQLDB   string             `json:"sql_db,omitempty"`
+	SQLName string             `json:"sql_name,omitempty"`
+}
+
+// SQLCheckReport is the result of CheckAgainstSQL.
+type SQLCheckReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// SchemaVersion is the schema version of TableMap when checked. A DDL running between the SQL query and
+	// the last sync shows up as discrepancies, which go away when checked again after the next sync.
+	SchemaVersion int64 `json:"schema_version"`
+	// Tables is the number of tables and partitions listed by SQL which are compared.
+	Tables int `json:"tables"`
+	// Discrepancies are sorted by ID.
+	Discrepancies []SQLDiscrepancy `json:"discrepancies"`
+}
+
+// CheckAgainstSQL compares TableMap with the tables listed by SQL, to catch the status API and the SQL view
+// disagreeing, e.g. during an upgrade. It is an on-demand diagnostic: the caller queries SQL, and the syncs
+// are not involved.
+//
+// The tables TableMap leaves out on purpose are not reported: the system databases under HiddenTablesSkip,
+// except the statistics tables kept by StatsTables, and the databases with empty names without EmptyDBName.
+// The partitions of SkipPartitions are checked against their tables. Under Lazy or with an LRU TableMap, only
+// the tables fetched so far are known, so the missing ones are not reported either. The names are compared
+// case-insensitively under CaseInsensitiveLookups.
+func (r *TableResolver) CheckAgainstSQL(tables []SQLTable) SQLCheckReport {
+	r.applyMu.RLock()
+	report := SQLCheckReport{
+		CheckedAt:     time.Now(),
+		SchemaVersion: r.SchemaVersion(),
+		Discrepancies: []SQLDiscrepancy{},
+	}
+	snapshot := newTableSnapshot(r.TableMap, 0)
+	r.applyMu.RUnlock()
+	_, lru := r.TableMap.(*lruTableStore)
+	partial := r.Lazy || lru
+
+	// skippedParents maps the skipped partitions of SkipPartitions to their tables.
+	skippedParents := make(map[int64]*tableDetail)
+	for _, detail := range snapshot.details {
+		for _, id := range detail.PartitionIDs {
+			skippedParents[id] = detail
+		}
+	}
+	seen := make(map[int64]struct{}, len(tables))
+	for _, table := range tables {
+		if r.skippedBySync(table.DB, table.Name) {
+			continue
+		}
+		report.Tables++
+		seen[table.ID] = struct{}{}
+		sqlName := table.Name
+		if table.Partition != "" {
+			sqlName = partitionName(table.Name, []string{table.Partition})
+		}
+		discrepancy := SQLDiscrepancy{ID: table.ID, SQLDB: table.DB, SQLName: sqlName}
+		if parent, ok := skippedParents[table.ID]; ok && table.Partition != "" {
+			if !r.namesEqual(parent.RawDB, table.DB) || !r.namesEqual(parent.RawName, table.Name) {
+				discrepancy.Kind = SQLDiscrepancyName
+				discrepancy.MapDB, discrepancy.MapName = parent.RawDB, partitionName(parent.RawName, []string{table.Partition})
+				report.Discrepancies = append(report.Discrepancies, discrepancy)
+			}
+			continue
+		}
+		detail, ok := snapshot.Load(table.ID)
+		switch {
+		case !ok:
+			if partial {
+				continue
+			}
+			discrepancy.Kind = SQLDiscrepancyMissing
+		case !r.namesEqual(detail.RawDB, table.DB) || !r.namesEqual(detail.RawName, sqlName):
+			discrepancy.Kind = SQLDiscrepancyName
+			discrepancy.MapDB, discrepancy.MapName = detail.RawDB, detail.RawName
+		default:
+			continue
+		}
+		report.Discrepancies = append(report.Discrepancies, discrepancy)
+	}
+	for _, detail := range snapshot.details {
+		if _, ok := seen[detail.ID]; !ok {
+			report.Discrepancies = append(report.Discrepancies, SQLDiscrepancy{
+				Kind:    SQLDiscrepancyExtra,
+				ID:      detail.ID,
+				MapDB:   detail.RawDB,
+				MapName: detail.RawName,
+			})
+		}
+	}
+	sort.SliceStable(report.Discrepancies, func(i, j int) bool {
+		return report.Discrepancies[i].ID < report.Discrepancies[j].ID
+	})
+	return report
+}
+
+// skippedBySync reports whether the syncs leave the table out of TableMap.
+func (r *TableResolver) skippedBySync(dbName, tableName string) bool {
+	if isHiddenSchema(dbName) && r.HiddenTables == HiddenTablesSkip {
+		return !r.keepsStatsTables(dbName) || !isMySQLTableName(dbName, tableName, statsTables)
+	}
+	return strings.TrimSpace(dbName) == "" && r.EmptyDBName == ""
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// SupportBundle is the state of a TableResolver collected for troubleshooting wrong labels.
+type SupportBundle struct {
+	CollectedAt time.Time `json:"collected_at"`
+	// SchemaVersion is the schema version applied by the last successful sync, or -1 if there is none.
+	SchemaVersion int64 `json:"schema_version"`
+	// EtcdSchemaVersion is the schema version read from etcd while collecting, or -1 if it can not be read.
+	EtcdSchemaVersion int64  `json:"etcd_schema_version"`
+	EtcdError         string `json:"etcd_error,omitempty"`
+	// LastSync is the result of the last sync, or nil if no sync has run.
+	LastSync      *SyncResult `json:"last_sync"`
+	LastSyncError string      `json:"last_sync_error,omitempty"`
+	// Partial is whether the tables are served as partial, see TableResolver.Partial.
+	Partial bool `json:"partial"`
+	// Degradation is how far the tables are degraded to stay within TableResolver.MemoryBudget: `none`,
+	// `indices`, `partitions` or `eviction`.
+	Degradation string              `json:"degradation"`
+	Config      SupportBundleConfig `json:"config"`
+	// Tables are all tables and partitions in TableMap, sorted by ID.
+	Tables []SupportBundleTable `json:"tables"`
+}
+
+// SupportBundleConfig is the tunables of a TableResolver. Secrets like the token are never included, only
+// whether they are set.
+type SupportBundleConfig struct {
+	SyncInterval              time.Duration                    `json:"sync_interval"`
+	SyncJitter                time.Duration                    `json:"sync_jitter"`
+	SyncTimeout               time.Duration                    `json:"sync_timeout"`
+	ColdSyncTimeout           time.Duration                    `json:"cold_sync_timeout"`
+	ColdSyncRetryInterval     time.Duration                    `json:"cold_sync_retry_interval"`
+	ColdSyncRetryMaxInterval  time.Duration                    `json:"cold_sync_retry_max_interval"`
+	ColdSyncDeadline          time.Duration                    `json:"cold_sync_deadline"`
+	RequestTimeout            time.Duration                    `json:"request_timeout"`
+	EndpointTimeouts          map[StatusEndpoint]time.Duration `json:"endpoint_timeouts,omitempty"`
+	EtcdGetTimeout            time.Duration                    `json:"etcd_get_timeout"`
+	SyncOnDDLOwnerChange      bool                             `json:"sync_on_ddl_owner_change"`
+	OwnerChangeDelay          time.Duration                    `json:"owner_change_delay"`
+	SyncOnSchemaVersionChange bool                             `json:"sync_on_schema_version_change"`
+	SchemaVersionDebounce     time.Duration                    `json:"schema_version_debounce"`
+	WatchKeepAliveInterval    time.Duration                    `json:"watch_keepalive_interval"`
+	SyncConcurrency           int                              `json:"sync_concurrency"`
+	WarmupConcurrency         int                              `json:"warmup_concurrency"`
+	HiddenTables              HiddenTablePolicy                `json:"hidden_tables"`
+	StatsTables               bool                             `json:"stats_tables"`
+	ReorgPartitions           bool                             `json:"reorg_partitions"`
+	SkipPartitions            bool                             `json:"skip_partitions"`
+	EmptyDBName               string                           `json:"empty_db_name"`
+	CaseInsensitiveLookups    bool                             `json:"case_insensitive_lookups"`
+	Cluster                   string                           `json:"cluster"`
+	StreamingApply            bool                             `json:"streaming_apply"`
+	ConsistencyCheckSize      int                              `json:"consistency_check_size"`
+	RetainedVersions          int                              `json:"retained_versions"`
+	TableInfoMetricsLimit     int                              `json:"table_info_metrics_limit"`
+	SkipDeleteOnlyTables      bool                             `json:"skip_delete_only_tables"`
+	PruneDroppedTables        bool                             `json:"prune_dropped_tables"`
+	DroppedTableGrace         time.Duration                    `json:"dropped_table_grace"`
+	ClockSkewThreshold        time.Duration                    `json:"clock_skew_threshold"`
+	StaleRevalidateSize       int                              `json:"stale_revalidate_size"`
+	StaleRevalidateInterval   time.Duration                    `json:"stale_revalidate_interval"`
+	SchemaPathName            SchemaNameForm                   `json:"schema_path_name"`
+	Redirects                 RedirectPolicy                   `json:"redirects"`
+	DuplicateTableIDs         DuplicateTableIDPolicy           `json:"duplicate_table_ids"`
+	InconsistentIndexIDs      InconsistentIndexIDPolicy        `json:"inconsistent_index_ids"`
+	ResyncMissThreshold       int                              `json:"resync_miss_threshold"`
+	MaxVersionStableInterval  time.Duration                    `json:"max_version_stable_interval"`
+	MaxResponseSize           int64                            `json:"max_response_size"`
+	MemoryBudget              int64                            `json:"memory_budget"`
+	LookupSampleRate          float64                          `json:"lookup_sample_rate"`
+	LookupSampleWindow        time.Duration                    `json:"lookup_sample_window"`
+	HotTablesFirst            bool                             `json:"hot_tables_first"`
+	LogSyncSummary            bool                             `json:"log_sync_summary"`
+	Lazy                      bool                             `json:"lazy"`
+	MaxTableMapEntries        int                              `json:"max_table_map_entries"`
+	HasNormalizeName          bool                             `json:"has_normalize_name"`
+	HasTokenProvider          bool                             `json:"has_token_provider"`
+	HasLimiter                bool                             `json:"has_limiter"`
+	HasPriorityHint           bool                             `json:"has_priority_hint"`
+	HasSnapshotStore          bool                             `json:"has_snapshot_store"`
+	HasAuditSink              bool                             `json:"has_audit_sink"`
+	LRUTableMap               bool                             `json:"lru_table_map"`
+}
+
+// SupportBundleTable is a table or a partition in TableMap.
+type SupportBundleTable struct {
+	TableInfo
+	RawDB        string   `json:"raw_db"`
+	RawName      string   `json:"raw_name"`
+	PKColumns    []string `json:"pk_columns"`
+	Clustered    bool     `json:"clustered"`
+	CommonHandle bool     `json:"common_handle"`
+	Hidden       bool     `json:"hidden"`
+	// UpdatedAt is when the table was last fetched from TiDB, or zero if it is preloaded.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DroppedAt is when the table was found dropped, see TableResolver.DroppedTableGrace, or zero if it is not.
+	DroppedAt time.Time `json:"dropped_at"`
+	// PartitionIDs are the skipped partitions of SkipPartitions.
+	PartitionIDs []int64 `json:"partition_ids,omitempty"`
+}
+
+// SupportBundle collects the state of the resolver. It is safe to be called while the resolver is running.
+//
+// The tables and SchemaVersion are collected together between the updates of two syncs. So the tables are
+// either all from before or all from after a concurrent sync, and they are the tables of SchemaVersion unless
+// that sync failed half way, in which case SchemaVersion is still the older one. LastSync is read separately
+// and may be the result of the sync before the one the tables are from. Under StreamingApply, the tables may
+// be half updated by a sync instead.
+func (r *TableResolver) SupportBundle(ctx context.Context) SupportBundle {
+	bundle := SupportBundle{
+		CollectedAt:       time.Now(),
+		EtcdSchemaVersion: -1,
+		Tables:            []SupportBundleTable{},
+	}
+
+	ectx, cancel := context.WithTimeout(ctx, r.etcdGetTimeout())
+	resp, err := r.EtcdClient.Get(ectx, schemaVersionPath)
+	cancel()
+	switch {
+	case err != nil:
+		bundle.EtcdError = err.Error()
+	case len(resp.Kvs) != 1:
+		bundle.EtcdError = "schema version is not found"
+	default:
+		if bundle.EtcdSchemaVersion, err = strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64); err != nil {
+			bundle.EtcdSchemaVersion = -1
+			bundle.EtcdError = err.Error()
+		}
+	}
+
+	bundle.Partial = r.Partial()
+	bundle.Degradation = r.degraded().String()
+	if result, ok := r.lastResult.Load().(SyncResult); ok {
+		bundle.LastSync = &result
+		if result.Err != nil {
+			bundle.LastSyncError = result.Err.Error()
+		}
+	}
+
+	_, lru := r.TableMap.(*lruTableStore)
+	bundle.Config = SupportBundleConfig{
+		SyncInterval:              r.SyncInterval,
+		SyncJitter:                r.SyncJitter,
+		SyncTimeout:               r.SyncTimeout,
+		ColdSyncTimeout:           r.ColdSyncTimeout,
+		ColdSyncRetryInterval:     r.ColdSyncRetryInterval,
+		ColdSyncRetryMaxInterval:  r.ColdSyncRetryMaxInterval,
+		ColdSyncDeadline:          r.ColdSyncDeadline,
+		RequestTimeout:            r.RequestTimeout,
+		EndpointTimeouts:          copyEndpointTimeouts(r.EndpointTimeouts),
+		EtcdGetTimeout:            r.EtcdGetTimeout,
+		SyncOnDDLOwnerChange:      r.SyncOnDDLOwnerChange,
+		OwnerChangeDelay:          r.OwnerChangeDelay,
+		SyncOnSchemaVersionChange: r.SyncOnSchemaVersionChange,
+		SchemaVersionDebounce:     r.SchemaVersionDebounce,
+		WatchKeepAliveInterval:    r.WatchKeepAliveInterval,
+		SyncConcurrency:           r.SyncConcurrency,
+		WarmupConcurrency:         r.WarmupConcurrency,
+		HiddenTables:              r.HiddenTables,
+		StatsTables:               r.StatsTables,
+		ReorgPartitions:           r.ReorgPartitions,
+		SkipPartitions:            r.SkipPartitions,
+		EmptyDBName:               r.EmptyDBName,
+		CaseInsensitiveLookups:    r.CaseInsensitiveLookups,
+		Cluster:                   r.Cluster,
+		StreamingApply:            r.StreamingApply,
+		ConsistencyCheckSize:      r.ConsistencyCheckSize,
+		RetainedVersions:          r.RetainedVersions,
+		TableInfoMetricsLimit:     r.TableInfoMetricsLimit,
+		SkipDeleteOnlyTables:      r.SkipDeleteOnlyTables,
+		PruneDroppedTables:        r.PruneDroppedTables,
+		DroppedTableGrace:         r.DroppedTableGrace,
+		ClockSkewThreshold:        r.ClockSkewThreshold,
+		StaleRevalidateSize:       r.StaleRevalidateSize,
+		StaleRevalidateInterval:   r.StaleRevalidateInterval,
+		SchemaPathName:            r.SchemaPathName,
+		Redirects:                 r.Redirects,
+		DuplicateTableIDs:         r.DuplicateTableIDs,
+		InconsistentIndexIDs:      r.InconsistentIndexIDs,
+		ResyncMissThreshold:       r.ResyncMissThreshold,
+		MaxVersionStableInterval:  r.MaxVersionStableInterval,
+		MaxResponseSize:           r.MaxResponseSize,
+		MemoryBudget:              r.MemoryBudget,
+		LookupSampleRate:          r.LookupSampleRate,
+		LookupSampleWindow:        r.LookupSampleWindow,
+		HotTablesFirst:            r.HotTablesFirst,
+		LogSyncSummary:            r.LogSyncSummary,
+		Lazy:                      r.Lazy,
+		MaxTableMapEntries:        r.MaxTableMapEntries,
+		HasNormalizeName:          r.NormalizeName != nil,
+		HasTokenProvider:          r.TokenProvider != nil,
+		HasLimiter:                r.Limiter != nil,
+		HasPriorityHint:           r.PriorityHint != nil,
+		HasSnapshotStore:          r.SnapshotStore != nil,
+		HasAuditSink:              r.AuditSink != nil,
+		LRUTableMap:               lru,
+	}
+
+	r.applyMu.RLock()
+	bundle.SchemaVersion = r.SchemaVersion()
+	snapshot := newTableSnapshot(r.TableMap, 0)
+	r.applyMu.RUnlock()
+	for _, detail := range snapshot.details {
+		bundle.Tables = append(bundle.Tables, SupportBundleTable{
+			TableInfo:    detail.toTableInfo(),
+			RawDB:        detail.RawDB,
+			RawName:      detail.RawName,
+			PKColumns:    append([]string(nil), detail.PKColumns...),
+			Clustered:    detail.Clustered,
+			CommonHandle: detail.CommonHandle,
+			Hidden:       detail.Hidden,
+			UpdatedAt:    detail.UpdatedAt,
+			DroppedAt:    detail.DroppedAt,
+
+			PartitionIDs: append([]int64(nil), detail.PartitionIDs...),
+		})
+	}
+	return bundle
+}
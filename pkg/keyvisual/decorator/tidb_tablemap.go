@@ -0,0 +1,215 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// tableLookup is the read-only part of tableStore used by Labelers.
+type tableLookup interface {
+	Load(id int64) (*tableDetail, bool)
+}
+
+// tableStore stores the detail of tables keyed by table ID. It must be safe for concurrent use.
+type tableStore interface {
+	Load(id int64) (*tableDetail, bool)
+	Store(id int64, detail *tableDetail)
+	Delete(id int64)
+	// Range calls f for each table until f returns false.
+	Range(f func(id int64, detail *tableDetail) bool)
+}
+
+// syncMapTableStore is the default unbounded tableStore.
+type syncMapTableStore struct {
+	m sync.Map
+}
+
+func newSyncMapTableStore() *syncMapTableStore {
+	return &syncMapTableStore{}
+}
+
+func (s *syncMapTableStore) Load(id int64) (*tableDetail, bool) {
+	v, ok := s.m.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return v.(*tableDetail), true
+}
+
+func (s *syncMapTableStore) Store(id int64, detail *tableDetail) {
+	s.m.Store(id, detail)
+}
+
+func (s *syncMapTableStore) Delete(id int64) {
+	s.m.Delete(id)
+}
+
+func (s *syncMapTableStore) Range(f func(id int64, detail *tableDetail) bool) {
+	s.m.Range(func(key, value interface{}) bool {
+		return f(key.(int64), value.(*tableDetail))
+	})
+}
+
+// lruTableStore keeps at most `capacity` tables, evicting the least recently looked-up ones. The IDs of at most
+// `capacity` evicted tables are remembered, so that TableResolver fetches an evicted table again on its next
+// lookup.
+type lruTableStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[int64]*list.Element
+	// order holds *tableDetail, the most recently used one at the front.
+	order *list.List
+	// evicted holds the IDs evicted and not stored or deleted since. Once it is full, an arbitrary ID is forgotten
+	// for each one evicted.
+	evicted map[int64]struct{}
+}
+
+func newLRUTableStore(capacity int) *lruTableStore {
+	return &lruTableStore{
+		capacity: capacity,
+		entries:  make(map[int64]*list.Element),
+		order:    list.New(),
+		evicted:  make(map[int64]struct{}),
+	}
+}
+
+// wasEvicted reports whether the table is missing since it is evicted.
+func (s *lruTableStore) wasEvicted(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.evicted[id]
+	return ok
+}
+
+func (s *lruTableStore) Load(id int64) (*tableDetail, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*tableDetail), true
+}
+
+func (s *lruTableStore) Store(id int64, detail *tableDetail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[id]; ok {
+		e.Value = detail
+		s.order.MoveToFront(e)
+		return
+	}
+	delete(s.evicted, id)
+	s.entries[id] = s.order.PushFront(detail)
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		oldestID := oldest.Value.(*tableDetail).ID
+		delete(s.entries, oldestID)
+		for forgotten := range s.evicted {
+			if len(s.evicted) < s.capacity {
+				break
+			}
+			delete(s.evicted, forgotten)
+		}
+		s.evicted[oldestID] = struct{}{}
+	}
+}
+
+// forgetEvicted forgets the evicted IDs not in live, e.g. the tables found dropped by a complete sync.
+func (s *lruTableStore) forgetEvicted(live map[int64]struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.evicted {
+		if _, ok := live[id]; !ok {
+			delete(s.evicted, id)
+		}
+	}
+}
+
+// replace replaces a stored table without touching its recency, and does nothing if it is not stored.
+func (s *lruTableStore) replace(id int64, detail *tableDetail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[id]; ok {
+		e.Value = detail
+	}
+}
+
+func (s *lruTableStore) Delete(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.evicted, id)
+	if e, ok := s.entries[id]; ok {
+		s.order.Remove(e)
+		delete(s.entries, id)
+	}
+}
+
+// Range iterates over a copy of the entries, so f is free to access the store.
+// It does not affect the recency of the entries.
+func (s *lruTableStore) Range(f func(id int64, detail *tableDetail) bool) {
+	s.mu.Lock()
+	details := make([]*tableDetail, 0, s.order.Len())
+	for e := s.order.Front(); e != nil; e = e.Next() {
+		details = append(details, e.Value.(*tableDetail))
+	}
+	s.mu.Unlock()
+
+	for _, detail := range details {
+		if !f(detail.ID, detail) {
+			return
+		}
+	}
+}
+
+// replaceTable replaces a table in the tableStore, keeping its recency in an LRU one.
+func replaceTable(tableMap tableStore, id int64, detail *tableDetail) {
+	if store, ok := tableMap.(*lruTableStore); ok {
+		store.replace(id, detail)
+		return
+	}
+	tableMap.Store(id, detail)
+}
+
+// tableSnapshot is an immutable view of a tableStore sorted by table ID. Lookups are binary searches without
+// any lock, so it is cheap to label all keys of a heatmap frame against one snapshot.
+type tableSnapshot struct {
+	gen     int64
+	details []*tableDetail
+	// copies are the deep copies of details taken under invariantChecks, to catch the writes to them.
+	copies []*tableDetail
+}
+
+func newTableSnapshot(tableMap tableStore, gen int64) *tableSnapshot {
+	var details []*tableDetail
+	tableMap.Range(func(_ int64, detail *tableDetail) bool {
+		details = append(details, detail)
+		return true
+	})
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].ID < details[j].ID
+	})
+	snapshot := &tableSnapshot{gen: gen, details: details}
+	if invariantChecks {
+		snapshot.checkOrder()
+		for _, detail := range details {
+			snapshot.copies = append(snapshot.copies, detail.clone())
+		}
+	}
+	return snapshot
+}
+
+func (s *tableSnapshot) Load(id int64) (*tableDetail, bool) {
+	i := sort.Search(len(s.details), func(i int) bool {
+		return s.details[i].ID >= id
+	})
+	if i < len(s.details) && s.details[i].ID == id {
+		return s.details[i], true
+	}
+	return nil, false
+}
@@ -3,9 +3,3810 @@
 package decorator
 
 import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joomcode/errorx"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
 )
 
 var _ = Suite(&testTiDBSuite{})
 
 type testTiDBSuite struct{}
+
+// testEtcdKV serves the schema version key only.
+type testEtcdKV struct {
+	clientv3.KV
+	SchemaVersion string
+	// Gets is notified on each Get if not nil.
+	Gets chan struct{}
+	Err  error
+}
+
+func (kv *testEtcdKV) Get(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	if kv.Gets != nil {
+		kv.Gets <- struct{}{}
+	}
+	if kv.Err != nil {
+		return nil, kv.Err
+	}
+	resp := &clientv3.GetResponse{}
+	if key == schemaVersionPath && kv.SchemaVersion != "" {
+		resp.Kvs = []*mvccpb.KeyValue{{Key: []byte(key), Value: []byte(kv.SchemaVersion)}}
+	}
+	return resp, nil
+}
+
+// testEtcdWatcher serves the watch responses sent to it. Progress requests are counted but never answered.
+type testEtcdWatcher struct {
+	clientv3.Watcher
+	Responses chan clientv3.WatchResponse
+
+	watches  int64
+	progress int64
+}
+
+func (w *testEtcdWatcher) RequestProgress(context.Context) error {
+	atomic.AddInt64(&w.progress, 1)
+	return nil
+}
+
+func (w *testEtcdWatcher) Watch(ctx context.Context, _ string, _ ...clientv3.OpOption) clientv3.WatchChan {
+	atomic.AddInt64(&w.watches, 1)
+	ch := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case resp := <-w.Responses:
+				ch <- resp
+			}
+		}
+	}()
+	return ch
+}
+
+// testStatusAPIClient serves canned responses of the TiDB status API, keyed by the request path.
+type testStatusAPIClient struct {
+	Responses map[string]string
+	// ContentTypes are the Content-Type headers of the responses, keyed by the request path.
+	ContentTypes map[string]string
+
+	mu       sync.Mutex
+	Requests []string
+}
+
+func (c *testStatusAPIClient) WithHeader(string, string) statusAPIClient {
+	return c
+}
+
+func (c *testStatusAPIClient) WithCheckRedirect(func(*http.Request, []*http.Request) error) statusAPIClient {
+	return c
+}
+
+func (c *testStatusAPIClient) Get(_ context.Context, relativeURI string) (*httpc.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Requests = append(c.Requests, relativeURI)
+	resp, ok := c.Responses[relativeURI]
+	if !ok {
+		return nil, errors.New("Request failed with status code 404")
+	}
+	header := http.Header{}
+	if contentType, ok := c.ContentTypes[relativeURI]; ok {
+		header.Set("Content-Type", contentType)
+	}
+	return &httpc.Response{Response: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(resp)),
+	}}, nil
+}
+
+func newTestResolver(schemaVersion string, responses map[string]string) *TableResolver {
+	r := &TableResolver{
+		EtcdClient: &testEtcdKV{SchemaVersion: schemaVersion},
+		tidbClient: &testStatusAPIClient{Responses: responses},
+		TableMap:   newSyncMapTableStore(),
+	}
+	r.SetSchemaVersion(-1)
+	return r
+}
+
+func (s *testTiDBSuite) TestTiDBKeyDecoder(c *C) {
+	decoder := NewTiDBKeyDecoder()
+
+	testcases := []struct {
+		Key  model.Key
+		Info KeyInfo
+	}{
+		{model.GenerateRowKey(1, 1), KeyInfo{TableID: 1, RowID: 1}},
+		{model.GenerateRowKey(1, 1<<40), KeyInfo{TableID: 1, RowID: 1 << 40}},
+		{model.GenerateRowKey(1<<40, -1), KeyInfo{TableID: 1 << 40, RowID: -1}},
+		{model.GenerateIndexKey(42, 1), KeyInfo{TableID: 42, IndexID: 1}},
+		{model.GenerateIndexKey(42, 7), KeyInfo{TableID: 42, IndexID: 7}},
+		{[]byte("not a key"), KeyInfo{}},
+	}
+	for _, t := range testcases {
+		c.Assert(decoder.DecodeKey(t.Key), Equals, t.Info)
+	}
+}
+
+func newTestTableInfo(id int64, name string, indices ...*model.IndexInfo) *model.TableInfo {
+	return &model.TableInfo{
+		ID:      id,
+		Name:    model.CIStr{O: name, L: strings.ToLower(name)},
+		Indices: indices,
+	}
+}
+
+func newTestIndexInfo(id int64, name string) *model.IndexInfo {
+	return &model.IndexInfo{
+		ID:   id,
+		Name: model.CIStr{O: name, L: strings.ToLower(name)},
+	}
+}
+
+func loadTestDetail(c *C, r *TableResolver, id int64) *tableDetail {
+	detail, ok := r.TableMap.Load(id)
+	c.Assert(ok, IsTrue)
+	return detail
+}
+
+func (s *testTiDBSuite) TestUpdateTableMapReplacesIndices(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+
+	resolver.updateTableMap("db", []*model.TableInfo{
+		newTestTableInfo(10, "t", newTestIndexInfo(1, "idx_a")),
+	}, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 10).Indices, DeepEquals, map[int64]string{1: "idx_a"})
+
+	// DROP INDEX idx_a; ADD INDEX idx_b
+	summary := newSyncSummary()
+	resolver.updateTableMap("db", []*model.TableInfo{
+		newTestTableInfo(10, "t", newTestIndexInfo(2, "idx_b")),
+	}, summary)
+	c.Assert(loadTestDetail(c, resolver, 10).Indices, DeepEquals, map[int64]string{2: "idx_b"})
+	c.Assert(summary.Changed, Equals, 1)
+}
+
+func (s *testTiDBSuite) TestUpdateMapColdStartWithoutDatabases(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/schema": `[]`,
+	})
+	result := resolver.updateMap(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Version, Equals, int64(-1))
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+
+	resolver.tidbClient = &testStatusAPIClient{Responses: map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}}
+	result = resolver.updateMap(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Version, Equals, int64(100))
+	c.Assert(result.Path, Equals, syncPathSchema)
+	c.Assert(result.Added, Equals, 1)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(100))
+
+	// The version has not changed.
+	lastID := result.ID
+	result = resolver.updateMap(context.Background())
+	c.Assert(result.Version, Equals, int64(-1))
+	c.Assert(result.Path, Equals, "")
+	c.Assert(result.ID, Not(Equals), "")
+	c.Assert(result.ID, Not(Equals), lastID)
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t")
+}
+
+func (s *testTiDBSuite) TestUpdateMapErrorTypes(c *C) {
+	resolver := newTestResolver("100", nil)
+	resolver.EtcdClient = &testEtcdKV{Err: errors.New("etcdserver: request timed out")}
+	c.Assert(errorx.IsOfType(resolver.Sync(context.Background()).Err, ErrEtcdUnavailable), IsTrue)
+	c.Assert(errorx.IsOfType(resolver.LastError(), ErrEtcdUnavailable), IsTrue)
+
+	resolver = newTestResolver("100", nil)
+	c.Assert(errorx.IsOfType(resolver.Sync(context.Background()).Err, ErrTiDBUnavailable), IsTrue)
+
+	resolver = newTestResolver("100", map[string]string{"/schema": `{"id":`})
+	c.Assert(errorx.IsOfType(resolver.Sync(context.Background()).Err, ErrParseFailed), IsTrue)
+
+	resolver = newTestResolver("not a version", nil)
+	c.Assert(errorx.IsOfType(resolver.Sync(context.Background()).Err, ErrParseFailed), IsTrue)
+
+	resolver = newTestResolver("100", map[string]string{"/schema": `[]`})
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(resolver.LastError(), IsNil)
+}
+
+func (s *testTiDBSuite) TestHTMLResponse(c *C) {
+	page := "\n<html><head><title>502 Bad Gateway</title></head><body>502 Bad Gateway</body></html>"
+	resolver := newTestResolver("100", map[string]string{"/schema": page})
+	err := resolver.Sync(context.Background()).Err
+	c.Assert(errorx.IsOfType(err, ErrParseFailed), IsTrue)
+	c.Assert(err, ErrorMatches,
+		".*non-JSON response from TiDB status API, got content-type text/html; charset=utf-8 \\(sniffed\\)")
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+
+	// The Content-Type claimed by the proxy is reported.
+	resolver.tidbClient.(*testStatusAPIClient).ContentTypes = map[string]string{"/schema": "application/json"}
+	err = resolver.Sync(context.Background()).Err
+	c.Assert(errorx.IsOfType(err, ErrParseFailed), IsTrue)
+	c.Assert(err, ErrorMatches, ".*non-JSON response from TiDB status API, got content-type application/json")
+
+	resolver = newTestResolver("100", map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": page,
+	})
+	c.Assert(errorx.IsOfType(resolver.Sync(context.Background()).Err, ErrParseFailed), IsTrue)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+}
+
+func (s *testTiDBSuite) TestSyncConcurrency(c *C) {
+	responses := map[string]string{}
+	var dbs []string
+	for i := 0; i < 10; i++ {
+		dbs = append(dbs, fmt.Sprintf(`{"id":%d,"db_name":{"O":"db%d","L":"db%d"},"state":5}`, i, i, i))
+		responses[fmt.Sprintf("/schema/db%d", i)] = fmt.Sprintf(`[{"id":%d,"name":{"O":"t","L":"t"}}]`, 100+i)
+	}
+	responses["/schema"] = "[" + strings.Join(dbs, ",") + "]"
+
+	resolver := newTestResolver("100", responses)
+	resolver.SyncConcurrency = 3
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Added, Equals, 10)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(100))
+	for i := 0; i < 10; i++ {
+		c.Assert(loadTestDetail(c, resolver, int64(100+i)).DB, Equals, fmt.Sprintf("db%d", i))
+	}
+
+	// A failed database prevents the version from advancing, while the others are still applied.
+	delete(responses, "/schema/db4")
+	resolver = newTestResolver("100", responses)
+	resolver.SyncConcurrency = 3
+	result = resolver.Sync(context.Background())
+	c.Assert(errorx.IsOfType(result.Err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(result.Added, Equals, 9)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+	_, ok := resolver.TableMap.Load(104)
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testTiDBSuite) TestSetSchemaVersion(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	})
+	client := resolver.tidbClient.(*testStatusAPIClient)
+
+	resolver.SetSchemaVersion(100)
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(-1))
+	c.Assert(client.Requests, HasLen, 0)
+
+	resolver.SetSchemaVersion(-1)
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(100))
+	c.Assert(resolver.SchemaVersion(), Equals, int64(100))
+	c.Assert(client.Requests, HasLen, 2)
+}
+
+func (s *testTiDBSuite) TestRelativeKeys(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.TableMap.Store(10, &tableDetail{ID: 10, DB: "test", Name: "t", Indices: map[int64]string{1: "PRIMARY", 3: "idx"}})
+
+	testcases := []struct {
+		Key    string
+		Info   KeyInfo
+		Labels []string
+	}{
+		{"", KeyInfo{TableID: 10}, []string{"test", "t"}},
+		{"_r\x80\x00\x00\x00\x00\x00\x00\x05", KeyInfo{TableID: 10, RowID: 5}, []string{"test", "t", "row_5"}},
+		{"_r\x01\x02\x03\x04\x05\x06\x07\x08\x09", KeyInfo{TableID: 10, IsCommonHandle: true, Handle: "\x01\x02\x03\x04\x05\x06\x07\x08\x09"}, []string{"test", "t", "row"}},
+		{"_i\x80\x00\x00\x00\x00\x00\x00\x03\x01", KeyInfo{TableID: 10, IndexID: 3}, []string{"test", "t", "idx"}},
+		{"_i\x80\x00", KeyInfo{TableID: 10, IndexID: 0, IsIndexTruncated: true}, []string{"test", "t", "PRIMARY"}},
+	}
+	for _, t := range testcases {
+		info, err := DecodeRelativeKey(10, []byte(t.Key))
+		c.Assert(err, IsNil)
+		c.Assert(info, DeepEquals, t.Info, Commentf("%q", t.Key))
+		labels, err := resolver.LabelRelativeKey(10, []byte(t.Key))
+		c.Assert(err, IsNil)
+		c.Assert(labels, DeepEquals, t.Labels, Commentf("%q", t.Key))
+	}
+
+	for _, key := range []string{"_r\x80\x00", "_x", "\x80\x00\x00\x00\x00\x00\x00\x0b"} {
+		_, err := resolver.LabelRelativeKey(10, []byte(key))
+		c.Assert(errorx.IsOfType(err, ErrInvalidKey), IsTrue, Commentf("%q", key))
+	}
+	_, err := DecodeRelativeKey(0, nil)
+	c.Assert(errorx.IsOfType(err, ErrInvalidKey), IsTrue)
+}
+
+func (s *testTiDBSuite) TestHotTables(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore(), LookupSampleRate: 1}
+	resolver.TableMap.Store(10, &tableDetail{ID: 10, DB: "test", Name: "t1"})
+	resolver.TableMap.Store(11, &tableDetail{ID: 11, DB: "test", Name: "t2"})
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder(), OnLookup: resolver.recordLookup}
+	var keys []string
+	for _, id := range []int64{10, 11, 10, 12, 10, 11} {
+		keys = append(keys, string(model.GenerateRowKey(id, 1)))
+	}
+	labeler.Label(keys)
+
+	c.Assert(resolver.HotTables(2), DeepEquals, []LookupSample{
+		{TableID: 10, DB: "test", Name: "t1", Count: 3},
+		{TableID: 11, DB: "test", Name: "t2", Count: 2},
+	})
+	c.Assert(resolver.HotTables(10)[2], DeepEquals, LookupSample{TableID: 12, Count: 1})
+
+	// The counts of the previous window are kept, while the older ones are dropped.
+	sampler := &lookupSampler{}
+	start := time.Now()
+	sampler.record(1, time.Minute, start)
+	sampler.record(2, time.Minute, start.Add(time.Minute))
+	c.Assert(sampler.top(10, time.Minute, start.Add(90*time.Second)), HasLen, 2)
+	c.Assert(sampler.top(10, time.Minute, start.Add(2*time.Minute)), DeepEquals, []LookupSample{{TableID: 2, Count: 1}})
+	c.Assert(sampler.top(10, time.Minute, start.Add(5*time.Minute)), HasLen, 0)
+
+	resolver = &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.recordLookup(10)
+	c.Assert(resolver.HotTables(10), HasLen, 0)
+}
+
+// testLimiter counts the requests it allows, and rejects all requests after Budget ones.
+type testLimiter struct {
+	Waits  int32
+	Budget int32
+}
+
+func (l *testLimiter) Wait(context.Context) error {
+	if atomic.AddInt32(&l.Waits, 1) > l.Budget {
+		return errors.New("budget exhausted")
+	}
+	return nil
+}
+
+func (s *testTiDBSuite) TestLimiter(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}
+	resolver := newTestResolver("100", responses)
+	limiter := &testLimiter{Budget: 2}
+	resolver.Limiter = limiter
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(limiter.Waits, Equals, int32(2))
+
+	resolver = newTestResolver("100", responses)
+	limiter = &testLimiter{Budget: 1}
+	resolver.Limiter = limiter
+	c.Assert(errorx.IsOfType(resolver.Sync(context.Background()).Err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(resolver.tidbClient.(*testStatusAPIClient).Requests, DeepEquals, []string{"/schema"})
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+}
+
+func (s *testTiDBSuite) TestMaxResponseSize(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}
+	resolver := newTestResolver("100", responses)
+	resolver.MaxResponseSize = int64(len(responses["/schema/test"]))
+	err := resolver.Sync(context.Background()).Err
+	c.Assert(errorx.IsOfType(err, ErrParseFailed), IsTrue)
+	c.Assert(err, ErrorMatches, ".*TiDB status API response exceeds the limit of 36 bytes")
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+
+	// A response of exactly the limit is accepted.
+	resolver = newTestResolver("100", responses)
+	resolver.MaxResponseSize = int64(len(responses["/schema"]))
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+}
+
+func (s *testTiDBSuite) TestPreload(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t2","L":"t2"}}]`,
+	}
+	resolver := newTestResolver("100", responses)
+	resolver.Preload([]TableInfo{{ID: 10, DB: "test", Name: "t", Indices: map[int64]string{1: "idx"}}}, 100)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(100))
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "idx"})
+
+	// The sync is skipped until the schema version changes.
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(-1))
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t")
+	resolver.EtcdClient = &testEtcdKV{SchemaVersion: "101"}
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(101))
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t2")
+}
+
+func (s *testTiDBSuite) TestResolveIDs(c *C) {
+	resolver := newTestResolver("100", nil)
+	resolver.Cluster = "c1"
+	resolver.Preload([]TableInfo{
+		{ID: 10, DB: "test", Name: "t"},
+		{ID: 11, DB: "test", Name: "t/p0", ParentID: 10},
+	}, 100)
+	resolved := resolver.ResolveIDs([]int64{11, 99, 10, 11})
+	c.Assert(resolved.Cluster, Equals, "c1")
+	c.Assert(resolved.SchemaVersion, Equals, int64(100))
+	c.Assert(resolved.Tables, HasLen, 2)
+	c.Assert(resolved.Tables[0].Name, Equals, "t/p0")
+	c.Assert(resolved.Tables[0].Cluster, Equals, "c1")
+	c.Assert(resolved.Tables[1].Name, Equals, "t")
+	c.Assert(resolved.Missing, DeepEquals, []int64{99})
+}
+
+func (s *testTiDBSuite) TestStatsTableLabels(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("mysql", []*model.TableInfo{
+		newTestTableInfo(10, "stats_buckets"),
+		newTestTableInfo(11, "user"),
+	}, newSyncSummary())
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(12, "stats_buckets")}, newSyncSummary())
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals,
+		[]string{"mysql", "stats_buckets", "row_1", hiddenLabel, statsLabel})
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals,
+		[]string{"mysql", "user", "row_1", hiddenLabel})
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals,
+		[]string{"test", "stats_buckets", "row_1"})
+}
+
+func (s *testTiDBSuite) TestStatsTablesUnderSkip(c *C) {
+	tables := []*model.TableInfo{newTestTableInfo(10, "stats_meta"), newTestTableInfo(11, "user")}
+	resolver := &TableResolver{TableMap: newSyncMapTableStore(), HiddenTables: HiddenTablesSkip}
+	resolver.updateTableMap("mysql", tables, newSyncSummary())
+	_, ok := resolver.TableMap.Load(10)
+	c.Assert(ok, IsFalse)
+
+	resolver.StatsTables = true
+	resolver.updateTableMap("mysql", tables, newSyncSummary())
+	resolver.updateTableMap("information_schema", []*model.TableInfo{newTestTableInfo(12, "stats_meta")}, newSyncSummary())
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals,
+		[]string{"mysql", "stats_meta", "row_1", hiddenLabel, statsLabel})
+	for _, id := range []int64{11, 12} {
+		_, ok = resolver.TableMap.Load(id)
+		c.Assert(ok, IsFalse, Commentf("table %d", id))
+	}
+
+	// The SQL check expects the statistics tables only.
+	report := resolver.CheckAgainstSQL([]SQLTable{
+		{ID: 10, DB: "mysql", Name: "stats_meta"},
+		{ID: 11, DB: "mysql", Name: "user"},
+	})
+	c.Assert(report.Tables, Equals, 1)
+	c.Assert(report.Discrepancies, HasLen, 0)
+}
+
+func (s *testTiDBSuite) TestResourceControlLabels(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("mysql", []*model.TableInfo{
+		newTestTableInfo(10, "tidb_runaway_watch"),
+		newTestTableInfo(11, "user"),
+	}, newSyncSummary())
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(12, "tidb_runaway_watch")}, newSyncSummary())
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals,
+		[]string{"mysql", "tidb_runaway_watch", "row_1", hiddenLabel})
+
+	labeler.ResourceControlLabels = true
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals,
+		[]string{"mysql", "tidb_runaway_watch", "row_1", hiddenLabel, resourceControlLabel})
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals,
+		[]string{"mysql", "user", "row_1", hiddenLabel})
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals,
+		[]string{"test", "tidb_runaway_watch", "row_1"})
+}
+
+func (s *testTiDBSuite) TestBindingLabels(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("mysql", []*model.TableInfo{
+		newTestTableInfo(10, "bind_info"),
+		newTestTableInfo(11, "user"),
+	}, newSyncSummary())
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(12, "bind_info")}, newSyncSummary())
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals,
+		[]string{"mysql", "bind_info", "row_1", hiddenLabel})
+
+	labeler.BindingLabels = true
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals,
+		[]string{"mysql", "bind_info", "row_1", hiddenLabel, bindingLabel})
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals,
+		[]string{"mysql", "user", "row_1", hiddenLabel})
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals,
+		[]string{"test", "bind_info", "row_1"})
+}
+
+func (s *testTiDBSuite) TestUnresolvedLabelFormat(c *C) {
+	key := string(model.GenerateRowKey(10, 1))
+	testcases := []struct {
+		Format string
+		Labels []string
+	}{
+		{"", []string{"table_10", "row_1"}},
+		{"tableID=%d", []string{"tableID=10", "row_1"}},
+		{"unknown", []string{"unknown", "row_1"}},
+	}
+	for _, t := range testcases {
+		labeler := &tidbLabeler{TableMap: newSyncMapTableStore(), Decoder: NewTiDBKeyDecoder(), UnresolvedLabelFormat: t.Format}
+		c.Assert(labeler.label(key).Labels, DeepEquals, t.Labels, Commentf("%q", t.Format))
+	}
+}
+
+func (s *testTiDBSuite) TestSupportBundle(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":11,"name":{"O":"t2","L":"t2"}},{"id":10,"name":{"O":"t1","L":"t1"}}]`,
+	})
+	resolver.TokenProvider = func(bool) (string, error) { return "secret", nil }
+	bundle := resolver.SupportBundle(context.Background())
+	c.Assert(bundle.SchemaVersion, Equals, int64(-1))
+	c.Assert(bundle.EtcdSchemaVersion, Equals, int64(100))
+	c.Assert(bundle.LastSync, IsNil)
+	c.Assert(bundle.Tables, HasLen, 0)
+
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	bundle = resolver.SupportBundle(context.Background())
+	c.Assert(bundle.SchemaVersion, Equals, int64(100))
+	c.Assert(bundle.LastSync.Version, Equals, int64(100))
+	c.Assert(bundle.LastSyncError, Equals, "")
+	c.Assert(bundle.Config.HasTokenProvider, IsTrue)
+	c.Assert(bundle.Tables, HasLen, 2)
+	c.Assert(bundle.Tables[0].ID, Equals, int64(10))
+	c.Assert(bundle.Tables[1].RawName, Equals, "t2")
+	data, err := json.Marshal(bundle)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "secret"), IsFalse)
+
+	resolver.EtcdClient = &testEtcdKV{Err: errors.New("etcdserver: request timed out")}
+	resolver.Sync(context.Background())
+	bundle = resolver.SupportBundle(context.Background())
+	c.Assert(bundle.EtcdSchemaVersion, Equals, int64(-1))
+	c.Assert(bundle.EtcdError, Equals, "etcdserver: request timed out")
+	c.Assert(bundle.LastSyncError, Matches, "error.keyvisual.decorator.etcd_unavailable.*")
+}
+
+// testFlipStatusAPIClient serves Clients[0] and Clients[1] in turn, switched by Flip.
+type testFlipStatusAPIClient struct {
+	Clients [2]*testStatusAPIClient
+	flipped int32
+}
+
+func (c *testFlipStatusAPIClient) Flip() {
+	atomic.AddInt32(&c.flipped, 1)
+}
+
+func (c *testFlipStatusAPIClient) WithHeader(string, string) statusAPIClient {
+	return c
+}
+
+func (c *testFlipStatusAPIClient) WithCheckRedirect(func(*http.Request, []*http.Request) error) statusAPIClient {
+	return c
+}
+
+func (c *testFlipStatusAPIClient) Get(ctx context.Context, relativeURI string) (*httpc.Response, error) {
+	if atomic.LoadInt32(&c.flipped)%2 == 1 {
+		return c.Clients[1].Get(ctx, relativeURI)
+	}
+	return c.Clients[0].Get(ctx, relativeURI)
+}
+
+func (s *testTiDBSuite) TestSupportBundleConsistency(c *C) {
+	newClient := func(prefix string) *testStatusAPIClient {
+		return &testStatusAPIClient{Responses: map[string]string{
+			"/schema":     `[{"id":1,"db_name":{"O":"db1","L":"db1"},"state":5},{"id":2,"db_name":{"O":"db2","L":"db2"},"state":5}]`,
+			"/schema/db1": fmt.Sprintf(`[{"id":10,"name":{"O":"%s1","L":"%s1"}}]`, prefix, prefix),
+			"/schema/db2": fmt.Sprintf(`[{"id":20,"name":{"O":"%s2","L":"%s2"}}]`, prefix, prefix),
+		}}
+	}
+	client := &testFlipStatusAPIClient{Clients: [2]*testStatusAPIClient{newClient("a"), newClient("b")}}
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = client
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+
+	// Pause the next sync after it has applied db1 but not db2.
+	applying := make(chan struct{})
+	resolver.NormalizeName = func(db, table string) (string, string) {
+		if table == "b2" {
+			close(applying)
+			time.Sleep(100 * time.Millisecond)
+		}
+		return db, table
+	}
+	client.Flip()
+	resolver.SetSchemaVersion(-1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resolver.Sync(context.Background())
+	}()
+	<-applying
+	bundle := resolver.SupportBundle(context.Background())
+	<-done
+	c.Assert(bundle.Tables, HasLen, 2)
+	c.Assert(bundle.Tables[0].Name, Equals, "b1")
+	c.Assert(bundle.Tables[1].Name, Equals, "b2")
+	c.Assert(bundle.SchemaVersion, Equals, int64(100))
+}
+
+func (s *testTiDBSuite) TestOnSchemaFetched(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"Test","L":"test"},"state":5},{"id":2,"db_name":{"O":"gone","L":"gone"},"state":0}]`,
+		"/schema/Test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	})
+	fetched := make(chan FetchedSchema, 1)
+	resolver.OnSchemaFetched = func(schema FetchedSchema) {
+		fetched <- schema
+	}
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	schema := <-fetched
+	c.Assert(schema.Version, Equals, int64(100))
+	c.Assert(schema.DBs, HasLen, 2)
+	c.Assert(schema.Tables, HasLen, 1)
+	c.Assert(schema.Tables["Test"], HasLen, 1)
+	c.Assert(schema.Tables["Test"][0].ID, Equals, int64(10))
+
+	// Neither a skipped nor a failed sync calls it.
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	resolver.SetSchemaVersion(-1)
+	resolver.tidbClient = &testStatusAPIClient{}
+	c.Assert(resolver.Sync(context.Background()).Err, NotNil)
+	select {
+	case schema := <-fetched:
+		c.Fatalf("unexpected call with version %d", schema.Version)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (s *testTiDBSuite) TestSchemaNotifier(c *C) {
+	var notifier schemaNotifier
+	started, release := make(chan struct{}), make(chan struct{})
+	versions := make(chan int64, 3)
+	callback := func(schema FetchedSchema) {
+		if schema.Version == 1 {
+			close(started)
+			<-release
+		}
+		versions <- schema.Version
+	}
+	notifier.notify(&FetchedSchema{Version: 1}, callback)
+	<-started
+	// The schemas fetched while a call is running are coalesced into the latest one.
+	notifier.notify(&FetchedSchema{Version: 2}, callback)
+	notifier.notify(&FetchedSchema{Version: 3}, callback)
+	close(release)
+	c.Assert(<-versions, Equals, int64(1))
+	c.Assert(<-versions, Equals, int64(3))
+	select {
+	case v := <-versions:
+		c.Fatalf("unexpected call with version %d", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (s *testTiDBSuite) TestGroupPartitions(c *C) {
+	table := newTestTableInfo(10, "t", newTestIndexInfo(1, "idx"))
+	table.Partition = &model.PartitionInfo{
+		Enable: true,
+		Definitions: []*model.PartitionDefinition{
+			{ID: 11, Name: model.CIStr{O: "p0", L: "p0"}},
+			{ID: 12, Name: model.CIStr{O: "p1", L: "p1"}},
+		},
+	}
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{table, newTestTableInfo(13, "t2")}, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 11).ParentID, Equals, int64(10))
+	c.Assert(loadTestDetail(c, resolver, 10).ParentID, Equals, int64(0))
+
+	p0Row, p1Row := string(model.GenerateRowKey(11, 1)), string(model.GenerateRowKey(12, 1))
+	p1Index := string(model.GenerateIndexKey(12, 1))
+	t2Row := string(model.GenerateRowKey(13, 1))
+
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(p0Row).Labels, DeepEquals, []string{"test", "t/p0", "row_1"})
+	c.Assert(labeler.CrossBorder(p0Row, p1Row), IsTrue)
+
+	labeler.GroupPartitions = true
+	c.Assert(labeler.label(p0Row).Labels, DeepEquals, []string{"test", "t", "row_1"})
+	c.Assert(labeler.label(p1Index).Labels, DeepEquals, []string{"test", "t", "idx"})
+	c.Assert(labeler.CrossBorder(p0Row, p1Row), IsFalse)
+	c.Assert(labeler.CrossBorder(p0Row, p1Index), IsTrue)
+	c.Assert(labeler.CrossBorder(p1Row, t2Row), IsTrue)
+
+	strategy := &tidbLabelStrategy{TableResolver: resolver, NewKeyDecoder: NewTiDBKeyDecoder, GroupPartitions: true}
+	c.Assert(strategy.NewLabeler().Label([]string{p0Row})[0].Labels, DeepEquals, []string{"test", "t", "row_1"})
+}
+
+func (s *testTiDBSuite) TestInconsistentIndexIDs(c *C) {
+	table := newTestTableInfo(10, "t", newTestIndexInfo(0, "a"), newTestIndexInfo(0, "b"), newTestIndexInfo(1, "c"), newTestIndexInfo(1, "d"))
+	testcases := []struct {
+		Policy  InconsistentIndexIDPolicy
+		Indices map[int64]string
+	}{
+		{"", map[int64]string{0: "a|b", 1: "c|d"}},
+		{InconsistentIndexesByName, map[int64]string{0: "a|b", 1: "c|d"}},
+		{InconsistentIndexesKeepLast, map[int64]string{0: "b", 1: "d"}},
+	}
+	for _, t := range testcases {
+		core, logs := observer.New(zapcore.WarnLevel)
+		restore := log.ReplaceGlobals(zap.New(core), nil)
+		resolver := &TableResolver{TableMap: newSyncMapTableStore(), InconsistentIndexIDs: t.Policy}
+		resolver.updateTableMap("test", []*model.TableInfo{table}, newSyncSummary())
+		restore()
+
+		c.Assert(loadTestDetail(c, resolver, 10).Indices, DeepEquals, t.Indices, Commentf("policy %q", t.Policy))
+		entries := logs.FilterMessage("tidb reports inconsistent index ids").All()
+		c.Assert(entries, HasLen, 1)
+		c.Assert(entries[0].ContextMap()["index-ids"], DeepEquals, []interface{}{int64(0), int64(1)})
+	}
+
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{table}, newSyncSummary())
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "c|d"})
+}
+
+func (s *testTiDBSuite) TestDuplicateTableIDs(c *C) {
+	responses := map[string]string{
+		"/schema":   `[{"id":1,"db_name":{"O":"a","L":"a"},"state":5},{"id":2,"db_name":{"O":"b","L":"b"},"state":5}]`,
+		"/schema/a": `[{"id":10,"name":{"O":"t1","L":"t1"}}]`,
+		"/schema/b": `[{"id":10,"name":{"O":"t2","L":"t2"}},{"id":11,"name":{"O":"t3","L":"t3"}}]`,
+	}
+	testcases := []struct {
+		Policy DuplicateTableIDPolicy
+		DB     string
+		Name   string
+	}{
+		{"", "b", "t2"},
+		{DuplicateKeepLast, "b", "t2"},
+		{DuplicateKeepFirst, "a", "t1"},
+		{DuplicateError, "b", "t2"},
+	}
+	for _, t := range testcases {
+		core, logs := observer.New(zapcore.WarnLevel)
+		restore := log.ReplaceGlobals(zap.New(core), nil)
+		resolver := newTestResolver("100", responses)
+		resolver.DuplicateTableIDs = t.Policy
+		result := resolver.updateMap(context.Background())
+		restore()
+
+		detail := loadTestDetail(c, resolver, 10)
+		c.Assert(detail.DB+"."+detail.Name, Equals, t.DB+"."+t.Name, Commentf("policy %q", t.Policy))
+		loadTestDetail(c, resolver, 11)
+		c.Assert(logs.FilterMessage("tidb reports a duplicated table id").Len(), Equals, 1)
+		if t.Policy == DuplicateError {
+			c.Assert(errorx.IsOfType(result.Err, ErrDuplicateTableID), IsTrue)
+			c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+		} else {
+			c.Assert(result.Err, IsNil)
+			c.Assert(resolver.SchemaVersion(), Equals, int64(100))
+		}
+	}
+}
+
+func (s *testTiDBSuite) TestReorgPartitions(c *C) {
+	// p1 is being reorganized into p1a and p1b.
+	var table model.TableInfo
+	err := json.Unmarshal([]byte(`{"id":10,"name":{"O":"t","L":"t"},
+		"partition":{"enable":true,"ddl_state":3,
+			"definitions":[{"id":11,"name":{"O":"p0","L":"p0"}},{"id":12,"name":{"O":"p1","L":"p1"}}],
+			"adding_definitions":[{"id":13,"name":{"O":"p1a","L":"p1a"}},{"id":14,"name":{"O":"p1b","L":"p1b"}}],
+			"dropping_definitions":[{"id":12,"name":{"O":"p1","L":"p1"}}]}}`), &table)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, newSyncSummary())
+	_, ok := resolver.TableMap.Load(13)
+	c.Assert(ok, IsFalse)
+
+	resolver = &TableResolver{TableMap: newSyncMapTableStore(), ReorgPartitions: true}
+	summary := newSyncSummary()
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, summary)
+	c.Assert(summary.Partitions, Equals, 4)
+	labeler := &tidbLabeler{TableMap: resolver.TableMap, Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"test", "t/p1", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(13, 1))).Labels, DeepEquals,
+		[]string{"test", "t/p1a", "row_1", "DDL(write reorganization)"})
+	c.Assert(loadTestDetail(c, resolver, 14).ParentID, Equals, int64(10))
+
+	resolver = &TableResolver{TableMap: newSyncMapTableStore(), ReorgPartitions: true, SkipPartitions: true}
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 10).PartitionIDs, DeepEquals, []int64{11, 12, 13, 14})
+}
+
+func (s *testTiDBSuite) TestSubPartitions(c *C) {
+	var table model.TableInfo
+	err := json.Unmarshal([]byte(`{"id":10,"name":{"O":"t","L":"t"},
+		"partition":{"enable":true,"definitions":[
+			{"id":11,"name":{"O":"p0","L":"p0"},"sub_partitions":[
+				{"id":12,"name":{"O":"sp0","L":"sp0"}},
+				{"id":13,"name":{"O":"sp1","L":"sp1"},"sub_partitions":[{"id":14,"name":{"O":"x","L":"x"}}]}]},
+			{"id":15,"name":{"O":"p1","L":"p1"}}]}}`), &table)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	summary := newSyncSummary()
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, summary)
+	c.Assert(summary.Partitions, Equals, 5)
+
+	for id, path := range map[int64][]string{11: {"p0"}, 12: {"p0", "sp0"}, 14: {"p0", "sp1", "x"}, 15: {"p1"}} {
+		detail := loadTestDetail(c, resolver, id)
+		c.Assert(detail.PartitionPath, DeepEquals, path)
+		c.Assert(detail.Name, Equals, "t/"+strings.Join(path, "/"))
+		c.Assert(detail.ParentID, Equals, int64(10))
+	}
+	c.Assert(loadTestDetail(c, resolver, 10).PartitionPath, IsNil)
+
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder(), GroupPartitions: true}
+	c.Assert(labeler.label(string(model.GenerateRowKey(14, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+	labeler.GroupPartitions = false
+	c.Assert(labeler.label(string(model.GenerateRowKey(14, 1))).Labels, DeepEquals, []string{"test", "t/p0/sp1/x", "row_1"})
+	info := loadTestDetail(c, resolver, 14).toTableInfo()
+	c.Assert(info.PartitionPath, DeepEquals, []string{"p0", "sp1", "x"})
+
+	// A partition preloaded without its path keeps the flat name.
+	preloaded := &TableResolver{TableMap: newSyncMapTableStore()}
+	preloaded.Preload([]TableInfo{{ID: 11, DB: "test", Name: "t/p0", ParentID: 10}}, 1)
+	detail := loadTestDetail(c, preloaded, 11)
+	c.Assert(detail.Name, Equals, "t/p0")
+	c.Assert(detail.PartitionPath, IsNil)
+
+	resolver.SkipPartitions = true
+	resolver.TableMap = newSyncMapTableStore()
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 10).PartitionIDs, DeepEquals, []int64{11, 12, 13, 14, 15})
+}
+
+func (s *testTiDBSuite) TestPartitionBoundLabels(c *C) {
+	var table model.TableInfo
+	err := json.Unmarshal([]byte(`{"id":10,"name":{"O":"t","L":"t"},
+		"partition":{"enable":true,"type":1,"definitions":[
+			{"id":11,"name":{"O":"p0","L":"p0"},"less_than":["10"]},
+			{"id":12,"name":{"O":"p1","L":"p1"},"less_than":["20"],"sub_partitions":[{"id":13,"name":{"O":"sp0","L":"sp0"}}]},
+			{"id":14,"name":{"O":"p2","L":"p2"},"less_than":["MAXVALUE"]}]}}`), &table)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, newSyncSummary())
+	for id, bound := range map[int64]string{10: "", 11: "RANGE(-inf,10)", 12: "RANGE[10,20)", 13: "RANGE[10,20)", 14: "RANGE[20,+inf)"} {
+		c.Assert(loadTestDetail(c, resolver, id).PartitionBound, Equals, bound, Commentf("partition %d", id))
+	}
+
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"test", "t/p1", "row_1"})
+	labeler.PartitionBoundLabels = true
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals,
+		[]string{"test", "t/p1", "RANGE[10,20)", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+	labeler.GroupPartitions = true
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+
+	// The bounds of RANGE COLUMNS are tuples, whose long values are truncated.
+	long := strings.Repeat("x", maxPartitionBoundLen+8)
+	bounds := rangePartitionBounds(&model.PartitionInfo{Type: model.PartitionTypeRange, Definitions: []*model.PartitionDefinition{
+		{ID: 1, LessThan: []string{"1", "'a'"}},
+		{ID: 2, LessThan: []string{"2", "'" + long + "'"}},
+		{ID: 3, LessThan: []string{"MAXVALUE", "MAXVALUE"}},
+	}})
+	truncated := "'" + long[:maxPartitionBoundLen-1] + "..."
+	c.Assert(bounds, DeepEquals, map[int64]string{
+		1: "RANGE(-inf,(1,'a'))",
+		2: "RANGE[(1,'a'),(2," + truncated + "))",
+		3: "RANGE[(2," + truncated + "),+inf)",
+	})
+	// No bound is known for another partitioning, or if TiDB does not report them.
+	c.Assert(rangePartitionBounds(&model.PartitionInfo{Definitions: []*model.PartitionDefinition{{ID: 1}}}), IsNil)
+	c.Assert(rangePartitionBounds(&model.PartitionInfo{
+		Type:        model.PartitionTypeRange,
+		Definitions: []*model.PartitionDefinition{{ID: 1}},
+	}), IsNil)
+}
+
+func (s *testTiDBSuite) TestIndexLabelTables(c *C) {
+	tableMap := newSyncMapTableStore()
+	tableMap.Store(10, &tableDetail{ID: 10, DB: "db", Name: "Wide", Indices: map[int64]string{1: "idx_a", 2: "idx_b"}})
+	tableMap.Store(11, &tableDetail{ID: 11, DB: "db", Name: "Wide/p0", ParentID: 10, Indices: map[int64]string{1: "idx_a", 2: "idx_b"}})
+	tableMap.Store(20, &tableDetail{ID: 20, DB: "db", Name: "hot", Indices: map[int64]string{1: "idx_c", 2: "idx_d"}})
+	labeler := &tidbLabeler{
+		TableMap:         tableMap,
+		Decoder:          NewTiDBKeyDecoder(),
+		IndexLabelTables: newIndexLabelTables([]string{"DB.hot"}),
+	}
+
+	c.Assert(labeler.label(string(model.GenerateIndexKey(20, 2))).Labels, DeepEquals, []string{"db", "hot", "idx_d"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 2))).Labels, DeepEquals, []string{"db", "Wide", indexesLabel})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(11, 1))).Labels, DeepEquals, []string{"db", "Wide/p0", indexesLabel})
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"db", "Wide", "row_1"})
+	// The indexes of a table not labeled by index are one range, still apart from the rows.
+	c.Assert(labeler.CrossBorder(string(model.GenerateIndexKey(10, 1)), string(model.GenerateIndexKey(10, 2))), IsFalse)
+	c.Assert(labeler.CrossBorder(string(model.GenerateIndexKey(10, 2)), string(model.GenerateRowKey(10, 1))), IsTrue)
+	c.Assert(labeler.CrossBorder(string(model.GenerateIndexKey(20, 1)), string(model.GenerateIndexKey(20, 2))), IsTrue)
+
+	cfg := LabelStrategyConfig{IndexLabelTables: []string{"hot"}}
+	c.Assert(cfg.Validate(), NotNil)
+}
+
+func (s *testTiDBSuite) TestSelfTest(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore(), Cluster: "c1"}
+	resolver.TableMap.Store(10, &tableDetail{ID: 10, DB: "db", Name: "t"})
+	resolver.TableMap.Store(11, &tableDetail{ID: 11, DB: "db", Name: "t/p0", ParentID: 10})
+
+	result := resolver.SelfTest(11)
+	c.Assert(result.OK, IsTrue, Commentf("%s", result.Reason))
+	c.Assert(result.Expected, Equals, "db.t/p0")
+	c.Assert(result.DecodedTableID, Equals, int64(11))
+	c.Assert(result.Labels, DeepEquals, []string{"c1", "db", "t/p0", "row_1"})
+
+	result = resolver.SelfTest(20)
+	c.Assert(result.OK, IsFalse)
+	c.Assert(result.Reason, Equals, "the table is not resolved")
+	c.Assert(result.Key, Not(Equals), "")
+}
+
+// testLabelFormatter renders a table as a single `db`.`table` label, its partitions as the table.
+type testLabelFormatter struct {
+	DefaultLabelFormatter
+}
+
+func (testLabelFormatter) Table(table TableLabel) []string {
+	return []string{fmt.Sprintf("`%s`.`%s`", table.DB, table.Table)}
+}
+
+func (testLabelFormatter) Index(index IndexLabel) string {
+	return fmt.Sprintf("index(%d)", index.ID)
+}
+
+func (s *testTiDBSuite) TestLabelFormatter(c *C) {
+	tableMap := newSyncMapTableStore()
+	tableMap.Store(10, &tableDetail{ID: 10, DB: "db", Name: "t", Indices: map[int64]string{1: "idx_a"}, GlobalIndices: map[int64]struct{}{1: {}}})
+	tableMap.Store(11, &tableDetail{ID: 11, DB: "db", Name: "t/p0/sp0", ParentID: 10, PartitionPath: []string{"p0", "sp0"}})
+	labeler := &tidbLabeler{TableMap: tableMap, Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"db", "t", "idx_a (global)"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1|tempIndexPrefix))).Labels, DeepEquals,
+		[]string{"db", "t", "idx_a (global) (building)"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 2))).Labels, DeepEquals, []string{"db", "t", "index_2"})
+
+	strategy := &tidbLabelStrategy{TableResolver: &TableResolver{TableMap: tableMap}, NewKeyDecoder: NewTiDBKeyDecoder}
+	strategy.SetLabelFormatter(testLabelFormatter{})
+	labeler = strategy.NewLabeler().(*tidbLabeler)
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals, []string{"`db`.`t`", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"`db`.`t`", "index(1)"})
+	// The unresolved tables are not rendered by the formatter.
+	c.Assert(labeler.label(string(model.GenerateRowKey(20, 1))).Labels, DeepEquals, []string{"table_20", "row_1"})
+}
+
+func (s *testTiDBSuite) TestResolvePartitions(c *C) {
+	var table model.TableInfo
+	err := json.Unmarshal([]byte(`{"id":10,"name":{"O":"t","L":"t"},
+		"partition":{"enable":true,"definitions":[
+			{"id":11,"name":{"O":"p0","L":"p0"},"sub_partitions":[
+				{"id":12,"name":{"O":"sp0","L":"sp0"}},
+				{"id":13,"name":{"O":"sp1","L":"sp1"}}]},
+			{"id":14,"name":{"O":"p1","L":"p1"}}]}}`), &table)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{&table, newTestTableInfo(20, "other")}, newSyncSummary())
+
+	involved := func(pruning PartitionPruning) map[int64]bool {
+		m := make(map[int64]bool)
+		for _, partition := range pruning.Partitions {
+			m[partition.ID] = partition.Involved
+		}
+		return m
+	}
+	pruning, ok := resolver.ResolvePartitions(10, []int64{11, 20, 99})
+	c.Assert(ok, IsTrue)
+	c.Assert(pruning.Table.Name, Equals, "t")
+	c.Assert(pruning.Partitions, HasLen, 4)
+	c.Assert(pruning.Partitions[1].Name, Equals, "t/p0/sp0")
+	// The subpartitions of a target are involved as well.
+	c.Assert(involved(pruning), DeepEquals, map[int64]bool{11: true, 12: true, 13: true, 14: false})
+	c.Assert(pruning.Unknown, DeepEquals, []int64{20, 99})
+
+	// A subpartition alone does not involve its siblings or parent, and a partition ID resolves its table.
+	pruning, ok = resolver.ResolvePartitions(14, []int64{13})
+	c.Assert(ok, IsTrue)
+	c.Assert(pruning.Table.ID, Equals, int64(10))
+	c.Assert(involved(pruning), DeepEquals, map[int64]bool{11: false, 12: false, 13: true, 14: false})
+	c.Assert(pruning.Unknown, HasLen, 0)
+
+	// A table without partitions has none to resolve.
+	pruning, ok = resolver.ResolvePartitions(20, nil)
+	c.Assert(ok, IsTrue)
+	c.Assert(pruning.Partitions, HasLen, 0)
+	_, ok = resolver.ResolvePartitions(99, nil)
+	c.Assert(ok, IsFalse)
+
+	resolver.SkipPartitions = true
+	resolver.TableMap = newSyncMapTableStore()
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, newSyncSummary())
+	pruning, ok = resolver.ResolvePartitions(10, []int64{12})
+	c.Assert(ok, IsTrue)
+	c.Assert(involved(pruning), DeepEquals, map[int64]bool{11: false, 12: true, 13: false, 14: false})
+	c.Assert(pruning.Partitions[1].Name, Equals, "t")
+	c.Assert(pruning.Partitions[1].ParentID, Equals, int64(10))
+}
+
+func (s *testTiDBSuite) TestGlobalIndexLabels(c *C) {
+	var table model.TableInfo
+	err := json.Unmarshal([]byte(`{"id":10,"name":{"O":"t","L":"t"},
+		"index_info":[{"id":1,"idx_name":{"O":"idx","L":"idx"}},{"id":2,"idx_name":{"O":"uk","L":"uk"},"is_global":true}],
+		"partition":{"enable":true,"definitions":[{"id":11,"name":{"O":"p0","L":"p0"}}]}}`), &table)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, newSyncSummary())
+
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 2))).Labels, DeepEquals, []string{"test", "t", "uk (global)"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(11, 1))).Labels, DeepEquals, []string{"test", "t/p0", "idx"})
+
+	// Dropping the global flag is a change.
+	table.Indices[1].Global = false
+	table.Indices[1], table.Indices[0] = table.Indices[0], table.Indices[1]
+	table.Indices[0].Global = false
+	summary := newSyncSummary()
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, summary)
+	c.Assert(summary.Changed, Equals, 2)
+}
+
+func (s *testTiDBSuite) TestTableIDOfKey(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	_, ok := resolver.TableIDOfKey(model.GenerateRowKey(10, 1))
+	c.Assert(ok, IsFalse)
+
+	resolver.Preload([]TableInfo{
+		{ID: 10, Name: "t1", DB: "test"},
+		{ID: 12, Name: "t2", DB: "test"},
+		{ID: 300, Name: "t3", DB: "test"},
+	}, 1)
+	for _, tc := range []struct {
+		key []byte
+		id  int64
+		ok  bool
+	}{
+		{model.EncodeKey([]byte("m")), 0, false},
+		{model.EncodeKey(model.GenerateRawTableKey(9, []byte("_r"))), 0, false},
+		{model.EncodeKey(model.GenerateRawTableKey(10, nil)), 10, true},
+		{model.GenerateRowKey(10, 1<<40), 10, true},
+		{model.GenerateIndexKey(10, 3), 10, true},
+		{model.GenerateRowKey(11, 1), 0, false},
+		{model.GenerateIndexKey(12, 1), 12, true},
+		{model.GenerateRowKey(299, 1), 0, false},
+		{model.GenerateRowKey(300, -1), 300, true},
+		{model.EncodeKey(model.GenerateRawTableKey(301, nil)), 0, false},
+		{[]byte{0xff}, 0, false},
+	} {
+		id, ok := resolver.TableIDOfKey(tc.key)
+		c.Assert(ok, Equals, tc.ok, Commentf("key %x", tc.key))
+		c.Assert(id, Equals, tc.id, Commentf("key %x", tc.key))
+	}
+}
+
+func (s *testTiDBSuite) TestKeyIndexSnapshot(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore(), SkipPartitions: true}
+	c.Assert(resolver.KeyIndex().Len(), Equals, 0)
+	c.Assert(resolver.KeyIndex().Ranges(), HasLen, 0)
+
+	resolver.Preload([]TableInfo{
+		{ID: 12, Name: "t2", DB: "test"},
+		{ID: 10, Name: "t1", DB: "test"},
+		{ID: 11, Name: "t1/p0", DB: "test", ParentID: 10},
+	}, 1)
+	snapshot := resolver.KeyIndex()
+	ranges := snapshot.Ranges()
+	c.Assert(ranges, HasLen, 3)
+	for i, id := range []int64{10, 10, 12} {
+		c.Assert(ranges[i].TableID, Equals, id)
+	}
+	c.Assert(ranges[1].StartKey, DeepEquals, []byte(model.EncodeKey(model.GenerateRawTableKey(11, nil))))
+	c.Assert(ranges[1].EndKey, DeepEquals, []byte(model.EncodeKey(model.GenerateRawTableKey(12, nil))))
+
+	// Modifying the copies does not corrupt the index.
+	ranges[0].StartKey[0] = 0xff
+	ranges[0].TableID = 99
+	id, ok := snapshot.Lookup(model.GenerateRowKey(10, 1))
+	c.Assert(ok, IsTrue)
+	c.Assert(id, Equals, int64(10))
+
+	// The snapshot is not updated by a later sync.
+	resolver.DropTable(12)
+	c.Assert(resolver.KeyIndex().Len(), Equals, 2)
+	id, ok = snapshot.Lookup(model.GenerateRowKey(12, 1))
+	c.Assert(ok, IsTrue)
+	c.Assert(id, Equals, int64(12))
+}
+
+func (s *testTiDBSuite) TestCheckAgainstSQL(c *C) {
+	var partitioned model.TableInfo
+	err := json.Unmarshal([]byte(`{"id":20,"name":{"O":"p","L":"p"},
+		"partition":{"enable":true,"definitions":[{"id":21,"name":{"O":"p0","L":"p0"}}]}}`), &partitioned)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore(), HiddenTables: HiddenTablesSkip}
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(10, "t1"), newTestTableInfo(11, "t2"), &partitioned}, newSyncSummary())
+	resolver.updateTableMap("mysql", []*model.TableInfo{newTestTableInfo(5, "user")}, newSyncSummary())
+	resolver.SetSchemaVersion(100)
+
+	tables := []SQLTable{
+		{ID: 10, DB: "test", Name: "t1"},
+		{ID: 11, DB: "test", Name: "t2_renamed"},
+		{ID: 12, DB: "test", Name: "t3"},
+		{ID: 20, DB: "test", Name: "p"},
+		{ID: 21, DB: "test", Name: "p", Partition: "p0"},
+		{ID: 5, DB: "mysql", Name: "user"},
+	}
+	report := resolver.CheckAgainstSQL(tables)
+	c.Assert(report.SchemaVersion, Equals, int64(100))
+	c.Assert(report.Tables, Equals, 5)
+	c.Assert(report.Discrepancies, DeepEquals, []SQLDiscrepancy{
+		{Kind: SQLDiscrepancyName, ID: 11, MapDB: "test", MapName: "t2", SQLDB: "test", SQLName: "t2_renamed"},
+		{Kind: SQLDiscrepancyMissing, ID: 12, SQLDB: "test", SQLName: "t3"},
+	})
+
+	// A table not listed by SQL is extra.
+	report = resolver.CheckAgainstSQL(tables[:3])
+	c.Assert(report.Discrepancies, HasLen, 4)
+	c.Assert(report.Discrepancies[2], DeepEquals, SQLDiscrepancy{Kind: SQLDiscrepancyExtra, ID: 20, MapDB: "test", MapName: "p"})
+	c.Assert(report.Discrepancies[3].MapName, Equals, "p/p0")
+
+	// The skipped partitions are checked against their tables.
+	resolver.SkipPartitions = true
+	resolver.TableMap = newSyncMapTableStore()
+	resolver.updateTableMap("test", []*model.TableInfo{&partitioned}, newSyncSummary())
+	report = resolver.CheckAgainstSQL([]SQLTable{{ID: 20, DB: "test", Name: "p"}, {ID: 21, DB: "test", Name: "p", Partition: "p0"}})
+	c.Assert(report.Discrepancies, HasLen, 0)
+	report = resolver.CheckAgainstSQL([]SQLTable{{ID: 20, DB: "test", Name: "p"}, {ID: 21, DB: "test", Name: "q", Partition: "p0"}})
+	c.Assert(report.Discrepancies, DeepEquals, []SQLDiscrepancy{
+		{Kind: SQLDiscrepancyName, ID: 21, MapDB: "test", MapName: "p/p0", SQLDB: "test", SQLName: "q/p0"},
+	})
+}
+
+func (s *testTiDBSuite) TestSkipPartitions(c *C) {
+	var table model.TableInfo
+	err := json.Unmarshal([]byte(`{"id":10,"name":{"O":"t","L":"t"},
+		"index_info":[{"id":1,"idx_name":{"O":"idx","L":"idx"}}],
+		"partition":{"enable":true,"definitions":[{"id":11,"name":{"O":"p0","L":"p0"}},{"id":13,"name":{"O":"p1","L":"p1"}}]}}`), &table)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore(), SkipPartitions: true}
+	summary := newSyncSummary()
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, summary)
+	resolver.rebuildKeyIndex()
+	c.Assert(summary.Added, Equals, 1)
+	c.Assert(summary.Partitions, Equals, 0)
+	_, ok := resolver.TableMap.Load(11)
+	c.Assert(ok, IsFalse)
+	detail, ok := resolver.TableMap.Load(10)
+	c.Assert(ok, IsTrue)
+	c.Assert(detail.PartitionIDs, DeepEquals, []int64{11, 13})
+
+	strategy := &tidbLabelStrategy{TableResolver: resolver, NewKeyDecoder: NewTiDBKeyDecoder}
+	labeler := strategy.NewLabeler().(*tidbLabeler)
+	c.Assert(labeler.label(string(model.GenerateIndexKey(11, 1))).Labels, DeepEquals, []string{"test", "t", "idx"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(13, 5))).Labels, DeepEquals, []string{"test", "t", "row_5"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 5))).Labels, DeepEquals, []string{"table_12", "row_5"})
+
+	// A new partition changes the table, and is resolved after the key index is rebuilt.
+	table.Partition.Definitions = append(table.Partition.Definitions, &model.PartitionDefinition{ID: 12, Name: model.CIStr{O: "p2", L: "p2"}})
+	summary = newSyncSummary()
+	resolver.updateTableMap("test", []*model.TableInfo{&table}, summary)
+	resolver.rebuildKeyIndex()
+	c.Assert(summary.Changed, Equals, 1)
+	labeler = strategy.NewLabeler().(*tidbLabeler)
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 5))).Labels, DeepEquals, []string{"test", "t", "row_5"})
+
+	// Preload keeps the partitions on their tables too.
+	preloaded := &TableResolver{TableMap: newSyncMapTableStore(), SkipPartitions: true}
+	preloaded.Preload([]TableInfo{{ID: 21, Name: "t/p0", DB: "test", ParentID: 20}, {ID: 20, Name: "t", DB: "test"}}, 1)
+	_, ok = preloaded.TableMap.Load(21)
+	c.Assert(ok, IsFalse)
+	id, ok := preloaded.TableIDOfKey(model.GenerateRowKey(21, 1))
+	c.Assert(ok, IsTrue)
+	c.Assert(id, Equals, int64(20))
+}
+
+func (s *testTiDBSuite) TestUpdateTableMapRename(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	strategy := &tidbLabelStrategy{TableResolver: resolver, NewKeyDecoder: NewTiDBKeyDecoder, labelCache: newLabelCache(16)}
+	key := string(model.GenerateRowKey(10, 1))
+
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(10, "t1")}, newSyncSummary())
+	c.Assert(strategy.NewLabeler().(*tidbLabeler).label(key).Labels, DeepEquals, []string{"test", "t1", "row_1"})
+
+	// RENAME TABLE keeps the ID, so the new name overwrites the old one, and the cached label is dropped.
+	summary := newSyncSummary()
+	resolver.updateTableMap("test2", []*model.TableInfo{newTestTableInfo(10, "t2")}, summary)
+	c.Assert(summary.Added, Equals, 0)
+	c.Assert(summary.Changed, Equals, 1)
+	detail := loadTestDetail(c, resolver, 10)
+	c.Assert(detail.DB, Equals, "test2")
+	c.Assert(detail.Name, Equals, "t2")
+	c.Assert(strategy.NewLabeler().(*tidbLabeler).label(key).Labels, DeepEquals, []string{"test2", "t2", "row_1"})
+}
+
+func (s *testTiDBSuite) TestNewLabelerWhileApplying(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	strategy := &tidbLabelStrategy{TableResolver: resolver, NewKeyDecoder: NewTiDBKeyDecoder, labelCache: newLabelCache(16)}
+	key := string(model.GenerateRowKey(10, 1))
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(10, "old")}, newSyncSummary())
+	resolver.rebuildKeyIndex()
+	resolver.commitSnapshot()
+	c.Assert(strategy.NewLabeler().(*tidbLabeler).label(key).Labels, DeepEquals, []string{"test", "old", "row_1"})
+
+	// A Labeler created while a sync is applied labels against the old snapshot, which is not cached as the
+	// labels of the new tables.
+	resolver.applyMu.Lock()
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(10, "new")}, newSyncSummary())
+	resolver.rebuildKeyIndex()
+	c.Assert(strategy.NewLabeler().(*tidbLabeler).label(key).Labels, DeepEquals, []string{"test", "old", "row_1"})
+	resolver.commitSnapshot()
+	resolver.applyMu.Unlock()
+	c.Assert(strategy.NewLabeler().(*tidbLabeler).label(key).Labels, DeepEquals, []string{"test", "new", "row_1"})
+}
+
+func (s *testTiDBSuite) TestSubscribeChanges(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t1","L":"t1"}},{"id":11,"name":{"O":"t2","L":"t2"}}]`,
+	})
+	changes, unsubscribe := resolver.SubscribeChanges(1)
+	slow, _ := resolver.SubscribeChanges(1)
+
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(<-changes, DeepEquals, TableMapChange{Version: 1, Added: []int64{10, 11}})
+
+	// t2 is dropped, t1 is renamed and t3 is created.
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}},{"id":12,"name":{"O":"t3","L":"t3"}}]`
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(<-changes, DeepEquals, TableMapChange{Version: 2, Added: []int64{12}, Changed: []int64{10}, Removed: []int64{11}})
+
+	// The slow subscriber never received the second change, so it is dropped.
+	c.Assert(<-slow, DeepEquals, TableMapChange{Version: 1, Added: []int64{10, 11}})
+	_, ok := <-slow
+	c.Assert(ok, IsFalse)
+
+	// A sync without any changed table publishes nothing.
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "3"
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	select {
+	case change := <-changes:
+		c.Fatalf("unexpected change %+v", change)
+	default:
+	}
+
+	unsubscribe()
+	unsubscribe()
+	_, ok = <-changes
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testTiDBSuite) TestMemoryBudget(c *C) {
+	newResolver := func() *TableResolver {
+		return newTestResolver("1", map[string]string{
+			"/schema": `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+			"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}},
+				{"id":20,"name":{"O":"p","L":"p"},"index_info":[
+					{"id":1,"idx_name":{"O":"idx_with_a_rather_long_name_to_be_dropped_first","L":"idx"}}],
+				"partition":{"enable":true,"definitions":[
+					{"id":21,"name":{"O":"p0","L":"p0"}},{"id":22,"name":{"O":"p1","L":"p1"}}]}}]`,
+		})
+	}
+	unbounded := newResolver()
+	c.Assert(unbounded.Sync(context.Background()).Err, IsNil)
+	full := unbounded.tableMapSize()
+	c.Assert(unbounded.degraded(), Equals, degradeNone)
+
+	// Dropping the index names is enough to fit in the budget.
+	core, logs := observer.New(zapcore.WarnLevel)
+	restore := log.ReplaceGlobals(zap.New(core), nil)
+	defer restore()
+	resolver := newResolver()
+	resolver.MemoryBudget = full - 1
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(resolver.degraded(), Equals, degradeIndices)
+	c.Assert(resolver.tableMapSize() <= resolver.MemoryBudget, IsTrue)
+	entries := logs.FilterMessage("the tidb tables exceed the memory budget, degrade the labels").All()
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].ContextMap()["degradation"], Equals, "indices")
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(21, 1))).Labels, DeepEquals, []string{"test", "p/p0", "index_1"})
+	// The next sync keeps the degradation, rather than changing the tables back and forth.
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Changed, Equals, 0)
+	c.Assert(resolver.SupportBundle(context.Background()).Degradation, Equals, "indices")
+
+	// Folding the partitions is not enough either, so the tables fetched the longest ago are evicted.
+	resolver = newResolver()
+	resolver.MemoryBudget = loadTestDetail(c, unbounded, 10).approxSize()
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(resolver.degraded(), Equals, degradeEviction)
+	c.Assert(resolver.tableMapSize() <= resolver.MemoryBudget, IsTrue)
+	_, ok := resolver.TableMap.Load(21)
+	c.Assert(ok, IsFalse)
+	c.Assert(logs.FilterMessage("the tidb tables exceed the memory budget, evict the tables fetched the longest ago").All(), HasLen, 1)
+}
+
+func (s *testTiDBSuite) TestPruneDroppedTables(c *C) {
+	newResolver := func() *TableResolver {
+		return newTestResolver("1", map[string]string{
+			"/schema": `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+			"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}},
+				{"id":20,"name":{"O":"p","L":"p"},"partition":{"enable":true,"definitions":[
+					{"id":21,"name":{"O":"p0","L":"p0"},"sub_partitions":[{"id":23,"name":{"O":"sp0","L":"sp0"}}]},
+					{"id":22,"name":{"O":"p1","L":"p1"}}]}}]`,
+		})
+	}
+	dropPartitionedTable := func(resolver *TableResolver) SyncResult {
+		resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+		resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}}]`
+		return resolver.Sync(context.Background())
+	}
+
+	resolver := newResolver()
+	resolver.PruneDroppedTables = true
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	loadTestDetail(c, resolver, 23)
+	// A stale partition of the dropped table, e.g. restored from a snapshot, goes with it as well.
+	resolver.TableMap.Store(24, &tableDetail{ID: 24, DB: "test", Name: "p/p2", ParentID: 20})
+	result := dropPartitionedTable(resolver)
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Removed, Equals, 5)
+	for _, id := range []int64{20, 21, 22, 23, 24} {
+		_, ok := resolver.TableMap.Load(id)
+		c.Assert(ok, IsFalse, Commentf("table %d", id))
+	}
+	loadTestDetail(c, resolver, 10)
+	_, ok := resolver.Resolve(21)
+	c.Assert(ok, IsFalse)
+
+	// A sync failing to fetch a database prunes nothing, as its tables are not seen.
+	resolver = newResolver()
+	resolver.PruneDroppedTables = true
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema"] = `[{"db_name":{"O":"test","L":"test"},"state":5},
+		{"db_name":{"O":"broken","L":"broken"},"state":5}]`
+	result = dropPartitionedTable(resolver)
+	c.Assert(result.Err, NotNil)
+	c.Assert(result.Removed, Equals, 0)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(1))
+	loadTestDetail(c, resolver, 23)
+
+	// By default the dropped tables are kept.
+	resolver = newResolver()
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	result = dropPartitionedTable(resolver)
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Removed, Equals, 4)
+	loadTestDetail(c, resolver, 21)
+}
+
+func (s *testTiDBSuite) TestPruneDroppedTablesByDefault(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}},{"id":11,"name":{"O":"u","L":"u"}}]`,
+	})
+	resolver.applyConfig(DefaultLabelStrategyConfig())
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}}]`
+	c.Assert(resolver.Sync(context.Background()).Removed, Equals, 1)
+	c.Assert(loadTestDetail(c, resolver, 11).DroppedAt.IsZero(), IsFalse)
+
+	detail := *loadTestDetail(c, resolver, 11)
+	detail.DroppedAt = detail.DroppedAt.Add(-defaultDroppedTableGrace)
+	resolver.TableMap.Store(11, &detail)
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "3"
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	_, ok := resolver.TableMap.Load(11)
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testTiDBSuite) TestPruneDroppedKeepsRecency(c *C) {
+	store := newLRUTableStore(3)
+	for id := int64(10); id < 13; id++ {
+		store.Store(id, &tableDetail{ID: id})
+	}
+	summary := newSyncSummary()
+	summary.seen[11], summary.seen[12] = struct{}{}, struct{}{}
+	summary.pruneDropped(store, time.Now(), time.Hour)
+	c.Assert(summary.Removed, Equals, 1)
+
+	// The table marked as dropped stays the least recently used one.
+	store.Store(13, &tableDetail{ID: 13})
+	_, ok := store.Load(10)
+	c.Assert(ok, IsFalse)
+	c.Assert(store.wasEvicted(10), IsTrue)
+}
+
+func (s *testTiDBSuite) TestDroppedTableGrace(c *C) {
+	tables := `[{"id":10,"name":{"O":"t","L":"t"}},
+		{"id":20,"name":{"O":"p","L":"p"},"partition":{"enable":true,"definitions":[
+			{"id":21,"name":{"O":"p0","L":"p0"}}]}}]`
+	resolver := newTestResolver("1", map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": tables,
+	})
+	resolver.PruneDroppedTables = true
+	resolver.DroppedTableGrace = time.Hour
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}}]`
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Removed, Equals, 2)
+	c.Assert(loadTestDetail(c, resolver, 20).DroppedAt.IsZero(), IsFalse)
+	c.Assert(loadTestDetail(c, resolver, 10).DroppedAt.IsZero(), IsTrue)
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(20, 1))).Labels, DeepEquals,
+		[]string{"test", "p (dropped)", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(21, 1))).Labels, DeepEquals,
+		[]string{"test", "p/p0 (dropped)", "row_1"})
+
+	// A table reported again, e.g. by RECOVER TABLE, is no longer dropped.
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "3"
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = tables
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Changed, Equals, 2)
+	c.Assert(loadTestDetail(c, resolver, 21).DroppedAt.IsZero(), IsTrue)
+
+	// The dropped tables are counted as removed once, and removed once the grace has passed.
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "4"
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}}]`
+	c.Assert(resolver.Sync(context.Background()).Removed, Equals, 2)
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "5"
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Removed, Equals, 0)
+	c.Assert(loadTestDetail(c, resolver, 20).DroppedAt.IsZero(), IsFalse)
+	for _, id := range []int64{20, 21} {
+		detail := *loadTestDetail(c, resolver, id)
+		detail.DroppedAt = time.Now().Add(-2 * time.Hour)
+		resolver.TableMap.Store(id, &detail)
+	}
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "6"
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Removed, Equals, 0)
+	for _, id := range []int64{20, 21} {
+		_, ok := resolver.TableMap.Load(id)
+		c.Assert(ok, IsFalse, Commentf("table %d", id))
+	}
+}
+
+type testAuditSink struct {
+	records []AuditRecord
+}
+
+func (s *testAuditSink) Write(records []AuditRecord) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *testTiDBSuite) TestAuditSink(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema": `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}},
+			{"id":20,"name":{"O":"p","L":"p"},"partition":{"enable":true,"definitions":[
+				{"id":21,"name":{"O":"p0","L":"p0"}}]}}]`,
+	})
+	resolver.PruneDroppedTables = true
+	sink := &testAuditSink{}
+	resolver.AuditSink = sink
+	actions := func() []string {
+		var actions []string
+		for _, record := range sink.records {
+			actions = append(actions, fmt.Sprintf("%d %s %d", record.Version, record.Action, record.TableID))
+		}
+		sink.records = nil
+		return actions
+	}
+
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(actions(), DeepEquals, []string{"1 added 10", "1 added 20", "1 added 21"})
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(sink.records, HasLen, 0)
+
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = `[{"id":10,"name":{"O":"t2","L":"t2"}}]`
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	records := sink.records
+	c.Assert(records[0].Before.Name, Equals, "t")
+	c.Assert(records[0].After.Name, Equals, "t2")
+	c.Assert(records[1].Before.Name, Equals, "p")
+	c.Assert(records[1].After, IsNil)
+	c.Assert(actions(), DeepEquals, []string{"2 renamed 10", "2 removed 20", "2 removed 21"})
+
+	c.Assert(resolver.DropTable(10), DeepEquals, []int64{10})
+	c.Assert(actions(), DeepEquals, []string{"2 removed 10"})
+}
+
+func (s *testTiDBSuite) TestResumeFailedDatabases(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema": `[{"id":1,"db_name":{"O":"db1","L":"db1"},"state":5},{"id":2,"db_name":{"O":"db2","L":"db2"},"state":5},
+			{"id":3,"db_name":{"O":"db3","L":"db3"},"state":5}]`,
+		"/schema/db1": `[{"id":10,"name":{"O":"t1","L":"t1"}}]`,
+		"/schema/db3": `[{"id":30,"name":{"O":"t3","L":"t3"}}]`,
+	})
+	resolver.SyncConcurrency = 1
+	resolver.PruneDroppedTables = true
+	client := resolver.tidbClient.(*testStatusAPIClient)
+	requested := func() []string {
+		requests := client.Requests
+		client.Requests = nil
+		return requests
+	}
+
+	// db2 fails for two cycles, in which only db2 is fetched again after the first one.
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, NotNil)
+	c.Assert(result.Added, Equals, 2)
+	c.Assert(requested(), DeepEquals, []string{"/schema", "/schema/db1", "/schema/db2", "/schema/db3"})
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, NotNil)
+	c.Assert(result.Resumed, Equals, 2)
+	c.Assert(requested(), DeepEquals, []string{"/schema", "/schema/db2"})
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+
+	client.Responses["/schema/db2"] = `[{"id":20,"name":{"O":"t2","L":"t2"}}]`
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Resumed, Equals, 2)
+	c.Assert(result.Added, Equals, 1)
+	c.Assert(result.Removed, Equals, 0)
+	c.Assert(requested(), DeepEquals, []string{"/schema", "/schema/db2"})
+	c.Assert(resolver.SchemaVersion(), Equals, int64(1))
+	for _, id := range []int64{10, 20, 30} {
+		loadTestDetail(c, resolver, id)
+	}
+
+	// A new version fetches every database again.
+	delete(client.Responses, "/schema/db2")
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+	c.Assert(resolver.Sync(context.Background()).Err, NotNil)
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "3"
+	client.Responses["/schema/db2"] = `[{"id":20,"name":{"O":"t2","L":"t2"}}]`
+	requested()
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Resumed, Equals, 0)
+	c.Assert(requested(), DeepEquals, []string{"/schema", "/schema/db1", "/schema/db2", "/schema/db3"})
+}
+
+func (s *testTiDBSuite) TestUpdateTableMapEmptyDBName(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	summary := newSyncSummary()
+	resolver.updateTableMap("", []*model.TableInfo{newTestTableInfo(10, "t1")}, summary)
+	resolver.updateTableMap(" \t", []*model.TableInfo{newTestTableInfo(11, "t2")}, summary)
+	c.Assert(summary.Added, Equals, 0)
+	_, ok := resolver.TableMap.Load(10)
+	c.Assert(ok, IsFalse)
+
+	resolver.EmptyDBName = "(internal)"
+	resolver.updateTableMap("", []*model.TableInfo{newTestTableInfo(10, "t1")}, summary)
+	c.Assert(summary.Added, Equals, 1)
+	detail := loadTestDetail(c, resolver, 10)
+	c.Assert(detail.DB, Equals, "(internal)")
+	c.Assert(detail.RawDB, Equals, "")
+}
+
+func (s *testTiDBSuite) TestLookupsReturnCopies(c *C) {
+	resolver := newTestResolver("1", nil)
+	resolver.TableMap.Store(10, &tableDetail{
+		ID: 10, DB: "test", Name: "t", Indices: map[int64]string{1: "idx"}, PKColumns: []string{"a"},
+		RawDB: "test", RawName: "t",
+	})
+
+	info, ok := resolver.Resolve(10)
+	c.Assert(ok, IsTrue)
+	info.Name = "changed"
+	info.Indices[1] = "changed"
+	infos := resolver.LookupLabel("test.t")
+	c.Assert(infos, HasLen, 1)
+	infos[0].Indices[2] = "added"
+	bundle := resolver.SupportBundle(context.Background())
+	c.Assert(bundle.Tables, HasLen, 1)
+	bundle.Tables[0].Indices[1] = "changed"
+	bundle.Tables[0].PKColumns[0] = "changed"
+	tables := resolver.ListTables()
+	c.Assert(tables, HasLen, 1)
+	c.Assert(tables[0].Name, Equals, "t")
+	tables[0].Indices[1] = "changed"
+
+	detail := loadTestDetail(c, resolver, 10)
+	c.Assert(detail.Name, Equals, "t")
+	c.Assert(detail.Indices, DeepEquals, map[int64]string{1: "idx"})
+	c.Assert(detail.PKColumns, DeepEquals, []string{"a"})
+}
+
+func (s *testTiDBSuite) TestListTables(c *C) {
+	resolver := newTestResolver("1", nil)
+	resolver.Cluster = "c1"
+	c.Assert(resolver.ListTables(), HasLen, 0)
+	resolver.updateTableMap("test", []*model.TableInfo{
+		newTestTableInfo(11, "t2"),
+		newTestTableInfo(10, "t1", newTestIndexInfo(1, "idx")),
+	}, newSyncSummary())
+	c.Assert(resolver.ListTables(), DeepEquals, []TableInfo{
+		{ID: 10, DB: "test", Name: "t1", Indices: map[int64]string{1: "idx"}, Cluster: "c1"},
+		{ID: 11, DB: "test", Name: "t2", Indices: map[int64]string{}, Cluster: "c1"},
+	})
+}
+
+func (s *testTiDBSuite) TestSyncMetrics(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema": `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+	})
+	resolver.Cluster = "sync-metrics"
+	failures := syncFailures.WithLabelValues("sync-metrics", "tidb_unavailable")
+	paths := syncPaths.WithLabelValues("sync-metrics", syncPathSchema)
+
+	c.Assert(resolver.Sync(context.Background()).Err, NotNil)
+	c.Assert(testutil.ToFloat64(failures), Equals, float64(1))
+	c.Assert(testutil.ToFloat64(paths), Equals, float64(1))
+	c.Assert(testutil.ToFloat64(tableMapEntries.WithLabelValues("sync-metrics")), Equals, float64(0))
+
+	resolver.tidbClient.(*testStatusAPIClient).Responses["/schema/test"] = `[{"id":10,"name":{"O":"t1","L":"t1"}},
+		{"id":11,"name":{"O":"t2","L":"t2"}}]`
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(testutil.ToFloat64(failures), Equals, float64(1))
+	c.Assert(testutil.ToFloat64(paths), Equals, float64(2))
+	c.Assert(testutil.ToFloat64(tableMapEntries.WithLabelValues("sync-metrics")), Equals, float64(2))
+
+	// A sync skipped for the unchanged version counts no path.
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(testutil.ToFloat64(paths), Equals, float64(2))
+	c.Assert(syncFailureReason(errors.New("unknown")), Equals, "other")
+}
+
+func (s *testTiDBSuite) TestLabelRegion(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("test", []*model.TableInfo{
+		newTestTableInfo(10, "t1", newTestIndexInfo(1, "idx")),
+		newTestTableInfo(11, "t2"),
+	}, newSyncSummary())
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+
+	label := LabelRegion(labeler, string(model.GenerateRowKey(10, 1)), string(model.GenerateRowKey(10, 100)))
+	c.Assert(label.Start.Labels, DeepEquals, []string{"test", "t1", "row_1"})
+	c.Assert(label.End.Labels, DeepEquals, []string{"test", "t1", "row_100"})
+	c.Assert(label.Labels, DeepEquals, []string{"test", "t1"})
+
+	label = LabelRegion(labeler, string(model.GenerateIndexKey(10, 1)), string(model.GenerateRowKey(11, 1)))
+	c.Assert(label.Labels, DeepEquals, []string{"spans test.t1.idx..test.t2.row_1"})
+}
+
+func (s *testTiDBSuite) TestSecondsSinceLastSync(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[]`,
+	}
+	healthy, stuck := newTestResolver("1", responses), newTestResolver("1", map[string]string{})
+	healthy.Cluster, stuck.Cluster = "healthy", "stuck"
+	stuck.lastSyncSuccess.Store(time.Now().Add(-time.Hour))
+	c.Assert(healthy.Sync(context.Background()).Err, IsNil)
+	c.Assert(stuck.Sync(context.Background()).Err, NotNil)
+
+	registry := prometheus.NewPedanticRegistry()
+	collector := newSyncAgeCollector()
+	collector.add(healthy)
+	collector.add(stuck)
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	c.Assert(err, IsNil)
+	c.Assert(families, HasLen, 1)
+	ages := make(map[string]float64)
+	for _, metric := range families[0].GetMetric() {
+		ages[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+	}
+	c.Assert(ages, HasLen, 2)
+	c.Assert(ages["healthy"] < 60, IsTrue)
+	c.Assert(ages["stuck"] >= 3600, IsTrue)
+}
+
+func (s *testTiDBSuite) TestTableInfoMetrics(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":12,"name":{"O":"c","L":"c"}},{"id":10,"name":{"O":"a","L":"a"}},{"id":11,"name":{"O":"b","L":"b"}}]`,
+	})
+	resolver.Cluster = "east"
+	resolver.TableInfoMetricsLimit = 2
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+
+	collector := newTableInfoCollector()
+	collector.add(resolver)
+	// The tables with the smallest IDs are exposed.
+	c.Assert(testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP keyvisual_decorator_table_info Tables and partitions in the table map of the TiDB label strategy, always 1.
+# TYPE keyvisual_decorator_table_info gauge
+keyvisual_decorator_table_info{cluster="east",db="test",table="a",table_id="10"} 1
+keyvisual_decorator_table_info{cluster="east",db="test",table="b",table_id="11"} 1
+# HELP keyvisual_decorator_table_info_omitted Tables and partitions not exposed as table_info, since the limit of the TiDB label strategy is reached.
+# TYPE keyvisual_decorator_table_info_omitted gauge
+keyvisual_decorator_table_info_omitted{cluster="east"} 1
+`)), IsNil)
+
+	collector.remove(resolver)
+	c.Assert(testutil.CollectAndCount(collector), Equals, 0)
+}
+
+func (s *testTiDBSuite) TestSyncDurationExemplar(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[]`,
+	})
+	resolver.Cluster = "sync-duration-exemplar"
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+
+	var metric dto.Metric
+	observer := syncDurations.WithLabelValues("sync-duration-exemplar", "ok")
+	c.Assert(observer.(prometheus.Metric).Write(&metric), IsNil)
+	var exemplar *dto.Exemplar
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		if bucket.GetUpperBound() >= result.Duration.Seconds() {
+			exemplar = bucket.GetExemplar()
+			break
+		}
+	}
+	c.Assert(exemplar, NotNil)
+	c.Assert(exemplar.GetLabel(), HasLen, 1)
+	c.Assert(exemplar.GetLabel()[0].GetName(), Equals, "sync_id")
+	c.Assert(exemplar.GetLabel()[0].GetValue(), Equals, result.ID)
+}
+
+func (s *testTiDBSuite) TestSyncPhases(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	})
+	resolver.Cluster = "sync-phases"
+	count := func(phase string) uint64 {
+		var metric dto.Metric
+		c.Assert(syncPhaseDurations.WithLabelValues("sync-phases", phase).(prometheus.Metric).Write(&metric), IsNil)
+		return metric.GetHistogram().GetSampleCount()
+	}
+	etcdCount, applyCount := count(syncPhaseEtcd), count(syncPhaseApply)
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	phases := result.Phases
+	for _, d := range []time.Duration{phases.Etcd, phases.Schema, phases.SchemaDB, phases.Apply} {
+		c.Assert(d > 0, IsTrue, Commentf("%+v", phases))
+	}
+	c.Assert(phases.Etcd+phases.Schema+phases.SchemaDB+phases.Apply <= result.Duration, IsTrue)
+	c.Assert(count(syncPhaseEtcd), Equals, etcdCount+1)
+	c.Assert(count(syncPhaseApply), Equals, applyCount+1)
+
+	// The phases the sync stops before are zero, and not observed.
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Phases.Etcd > 0, IsTrue)
+	c.Assert(result.Phases.Schema, Equals, time.Duration(0))
+	c.Assert(count(syncPhaseApply), Equals, applyCount+1)
+}
+
+func (s *testTiDBSuite) TestDropTable(c *C) {
+	resolver := newTestResolver("1", nil)
+	resolver.Preload([]TableInfo{
+		{ID: 10, Name: "t", DB: "test"},
+		{ID: 11, Name: "t/p0", DB: "test", ParentID: 10},
+		{ID: 12, Name: "t/p1", DB: "test", ParentID: 10},
+		{ID: 20, Name: "t2", DB: "test"},
+	}, 1)
+	strategy := &tidbLabelStrategy{TableResolver: resolver, NewKeyDecoder: NewTiDBKeyDecoder, labelCache: newLabelCache(16)}
+	key := string(model.GenerateRowKey(11, 1))
+	c.Assert(strategy.NewLabeler().(*tidbLabeler).label(key).Labels, DeepEquals, []string{"test", "t/p0", "row_1"})
+	changes, unsubscribe := resolver.SubscribeChanges(1)
+	defer unsubscribe()
+
+	c.Assert(resolver.DropTable(13), HasLen, 0)
+	c.Assert(resolver.DropTable(10), DeepEquals, []int64{10, 11, 12})
+	c.Assert(<-changes, DeepEquals, TableMapChange{Version: 1, Removed: []int64{10, 11, 12}})
+	for _, id := range []int64{10, 11, 12} {
+		_, ok := resolver.Resolve(id)
+		c.Assert(ok, IsFalse)
+		_, ok = resolver.TableIDOfKey(model.GenerateRowKey(id, 1))
+		c.Assert(ok, IsFalse)
+	}
+	c.Assert(strategy.NewLabeler().(*tidbLabeler).label(key).Labels, DeepEquals, []string{"table_11", "row_1"})
+	_, ok := resolver.Resolve(20)
+	c.Assert(ok, IsTrue)
+}
+
+func (s *testTiDBSuite) TestFirstSyncLogging(c *C) {
+	core, logs := observer.New(zap.DebugLevel)
+	defer log.ReplaceGlobals(zap.New(core), &log.ZapProperties{Core: core, Level: zap.NewAtomicLevelAt(zap.DebugLevel)})()
+	levelOf := func(message string) zapcore.Level {
+		entries := logs.FilterMessage(message).TakeAll()
+		c.Assert(entries, HasLen, 1, Commentf("message %q", message))
+		return entries[0].Level
+	}
+
+	// Before any version is applied, the missing version may mean there is no TiDB, so it is not warned.
+	resolver := newTestResolver("", nil)
+	resolver.EtcdClient = &testEtcdKV{}
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(levelOf("tidb schema version is not found in etcd, maybe not a db cluster"), Equals, zap.DebugLevel)
+
+	// -1 is only the value of no version, so a real version -1 is still synced and applied.
+	resolver.EtcdClient = &testEtcdKV{SchemaVersion: "-1"}
+	resolver.tidbClient = &testStatusAPIClient{Responses: map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[]`,
+	}}
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Version, Equals, int64(-1))
+	c.Assert(result.Path, Equals, syncPathSchema)
+
+	// Once initialized, the same failure is warned, even though the version applied is -1.
+	resolver.EtcdClient = &testEtcdKV{}
+	c.Assert(resolver.Sync(context.Background()).Err, NotNil)
+	c.Assert(levelOf("tidb schema version is not found in etcd"), Equals, zap.WarnLevel)
+}
+
+func (s *testTiDBSuite) TestCluster(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore(), Cluster: "east"}
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(10, "t", newTestIndexInfo(1, "idx"))}, newSyncSummary())
+	strategy := &tidbLabelStrategy{TableResolver: resolver, NewKeyDecoder: NewTiDBKeyDecoder}
+	labels := strategy.NewLabeler().Label([]string{"", string(model.GenerateIndexKey(10, 1)), string(model.GenerateRowKey(11, 1)), ""})
+	c.Assert(labels[0].Labels, DeepEquals, []string{"east", "meta"})
+	c.Assert(labels[1].Labels, DeepEquals, []string{"east", "test", "t", "idx"})
+	c.Assert(labels[2].Labels, DeepEquals, []string{"east", "table_11", "row_1"})
+	c.Assert(labels[3].Labels, DeepEquals, []string{"east"})
+	c.Assert(globalStart.Labels, DeepEquals, []string{"meta"})
+
+	info, ok := resolver.Resolve(10)
+	c.Assert(ok, IsTrue)
+	c.Assert(info.Cluster, Equals, "east")
+	infos := resolver.LookupLabel("east.test.t")
+	c.Assert(infos, HasLen, 1)
+	c.Assert(infos[0].Cluster, Equals, "east")
+	c.Assert(resolver.LookupLabel("test.t"), HasLen, 0)
+	c.Assert(resolver.LookupLabel("west.test.t"), HasLen, 0)
+}
+
+func (s *testTiDBSuite) TestSchemaPathName(c *C) {
+	testcases := []struct {
+		Name string
+		Path string
+	}{
+		{"Test", "/schema/Test"},
+		{"a.b", "/schema/a.b"},
+		{"a/b", "/schema/a%2Fb"},
+		{"a b?c#d%e", "/schema/a%20b%3Fc%23d%25e"},
+		{"数据", "/schema/%E6%95%B0%E6%8D%AE"},
+		{".", "/schema/%2E"},
+		{"..", "/schema/%2E%2E"},
+	}
+	for _, t := range testcases {
+		dbInfo := fmt.Sprintf(`[{"id":1,"db_name":{"O":%q,"L":%q},"state":5}]`, t.Name, strings.ToLower(t.Name))
+		resolver := newTestResolver("100", map[string]string{
+			"/schema": dbInfo,
+			t.Path:    `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+		})
+		c.Assert(resolver.Sync(context.Background()).Err, IsNil, Commentf("%q", t.Name))
+		c.Assert(loadTestDetail(c, resolver, 10).DB, Equals, t.Name)
+	}
+
+	resolver := newTestResolver("100", map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"Test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	})
+	resolver.SchemaPathName = SchemaNameLower
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(loadTestDetail(c, resolver, 10).DB, Equals, "Test")
+}
+
+func (s *testTiDBSuite) TestParseLabelStrategyConfig(c *C) {
+	cfg, err := ParseLabelStrategyConfig(nil)
+	c.Assert(err, IsNil)
+	c.Assert(cfg, DeepEquals, DefaultLabelStrategyConfig())
+
+	// The fields omitted keep the defaults, including the ones which are not zero.
+	cfg, err = ParseLabelStrategyConfig([]byte(`{"sync_interval":300000000000,"prune_dropped_tables":false,"skip_partitions":true}`))
+	c.Assert(err, IsNil)
+	c.Assert(cfg.SyncInterval, Equals, 5*time.Minute)
+	c.Assert(cfg.PruneDroppedTables, IsFalse)
+	c.Assert(cfg.SkipPartitions, IsTrue)
+	c.Assert(cfg.SyncJitter, Equals, defaultSyncJitter)
+	c.Assert(cfg.DroppedTableGrace, Equals, defaultDroppedTableGrace)
+
+	_, err = ParseLabelStrategyConfig([]byte(`{"sync_concurrency":-1}`))
+	c.Assert(errorx.IsOfType(err, ErrInvalidConfig), IsTrue)
+	c.Assert(err, ErrorMatches, ".*sync_concurrency must not be negative")
+	_, err = ParseLabelStrategyConfig([]byte(`{"sync_interval":"1m"}`))
+	c.Assert(errorx.IsOfType(err, ErrInvalidConfig), IsTrue)
+	// A duration given in seconds rather than nanoseconds is rejected.
+	_, err = ParseLabelStrategyConfig([]byte(`{"sync_interval":60}`))
+	c.Assert(err, ErrorMatches, ".*sync_interval must be at least 1s, got 60ns")
+}
+
+func (s *testTiDBSuite) TestLabelStrategyConfig(c *C) {
+	var cfg LabelStrategyConfig
+	c.Assert(cfg.Validate(), IsNil)
+	c.Assert(cfg.SyncInterval, Equals, defaultSyncInterval)
+	c.Assert(cfg.SyncJitter, Equals, time.Duration(0))
+	c.Assert(cfg.SyncTimeout, Equals, time.Duration(0))
+	c.Assert(cfg.HiddenTables, Equals, HiddenTablesTag)
+	c.Assert(cfg.UnresolvedLabelFormat, Equals, defaultUnresolvedLabelFormat)
+	c.Assert(cfg.LabelCacheSize, Equals, defaultLabelCacheSize)
+	c.Assert(cfg.EtcdGetTimeout, Equals, defaultEtcdGetTimeout)
+
+	cfg = DefaultLabelStrategyConfig()
+	c.Assert(cfg.SyncJitter, Equals, defaultSyncJitter)
+	c.Assert(cfg.SyncTimeout, Equals, defaultSyncTimeout)
+	c.Assert(cfg.PruneDroppedTables, IsTrue)
+	c.Assert(cfg.DroppedTableGrace, Equals, defaultDroppedTableGrace)
+	resolver := &TableResolver{}
+	resolver.applyConfig(cfg)
+	c.Assert(resolver.MaxResponseSize, Equals, int64(defaultMaxResponseSize))
+	c.Assert(resolver.SyncConcurrency, Equals, defaultSyncConcurrency)
+	c.Assert(resolver.currentConfig(), DeepEquals, LabelStrategyConfig{
+		SyncInterval:             cfg.SyncInterval,
+		SyncJitter:               cfg.SyncJitter,
+		SyncTimeout:              cfg.SyncTimeout,
+		ColdSyncRetryInterval:    cfg.ColdSyncRetryInterval,
+		ColdSyncRetryMaxInterval: cfg.ColdSyncRetryMaxInterval,
+		OwnerChangeDelay:         cfg.OwnerChangeDelay,
+		SchemaVersionDebounce:    cfg.SchemaVersionDebounce,
+		WatchKeepAliveInterval:   cfg.WatchKeepAliveInterval,
+		EtcdGetTimeout:           cfg.EtcdGetTimeout,
+		SyncConcurrency:          cfg.SyncConcurrency,
+		SchemaPathName:           cfg.SchemaPathName,
+		HiddenTables:             cfg.HiddenTables,
+		Redirects:                cfg.Redirects,
+		DuplicateTableIDs:        cfg.DuplicateTableIDs,
+		InconsistentIndexIDs:     cfg.InconsistentIndexIDs,
+		MaxResponseSize:          cfg.MaxResponseSize,
+		LookupSampleWindow:       cfg.LookupSampleWindow,
+		PruneDroppedTables:       cfg.PruneDroppedTables,
+		DroppedTableGrace:        cfg.DroppedTableGrace,
+	})
+
+	strategy := &tidbLabelStrategy{
+		TableResolver:         resolver,
+		labelCache:            newLabelCache(cfg.LabelCacheSize),
+		UnresolvedLabelFormat: cfg.UnresolvedLabelFormat,
+	}
+	resolver.SyncInterval = time.Hour
+	effective := strategy.EffectiveConfig()
+	c.Assert(effective.SyncInterval, Equals, time.Hour)
+	effective.SyncInterval = cfg.SyncInterval
+	c.Assert(effective, DeepEquals, cfg)
+
+	testcases := []struct {
+		Config LabelStrategyConfig
+		Error  string
+	}{
+		{LabelStrategyConfig{SyncJitter: -time.Second}, "sync_jitter must not be negative"},
+		{LabelStrategyConfig{SyncConcurrency: -1}, "sync_concurrency must not be negative"},
+		{LabelStrategyConfig{EtcdGetTimeout: -time.Second}, "etcd_get_timeout must not be negative"},
+		{LabelStrategyConfig{EndpointTimeouts: map[StatusEndpoint]time.Duration{"status": time.Second}}, `unknown endpoint "status" in endpoint_timeouts`},
+		{LabelStrategyConfig{EndpointTimeouts: map[StatusEndpoint]time.Duration{EndpointDBTable: -time.Second}}, "endpoint_timeouts of db_table must not be negative"},
+		{LabelStrategyConfig{LookupSampleRate: 1.5}, `lookup_sample_rate must be in \[0, 1\], got 1.5`},
+		{LabelStrategyConfig{SyncInterval: 60}, "sync_interval must be at least 1s, got 60ns"},
+		{LabelStrategyConfig{SyncTimeout: 30}, "sync_timeout must be zero or at least 100ms, got 30ns"},
+		{LabelStrategyConfig{EtcdGetTimeout: time.Millisecond}, "etcd_get_timeout must be zero or at least 100ms, got 1ms"},
+		{
+			LabelStrategyConfig{EndpointTimeouts: map[StatusEndpoint]time.Duration{EndpointSchema: 5}},
+			"endpoint_timeouts of schema must be zero or at least 100ms, got 5ns",
+		},
+		{LabelStrategyConfig{HiddenTables: "drop"}, `unknown hidden_tables "drop"`},
+		{LabelStrategyConfig{SchemaPathName: "upper"}, `unknown schema_path_name "upper"`},
+		{LabelStrategyConfig{Redirects: "all"}, `unknown redirects "all"`},
+		{LabelStrategyConfig{DuplicateTableIDs: "ignore"}, `unknown duplicate_table_ids "ignore"`},
+		{LabelStrategyConfig{UnresolvedLabelFormat: "table_%s"}, ".*at most one %d.*"},
+		{LabelStrategyConfig{UnresolvedLabelFormat: "%d_%d"}, ".*at most one %d.*"},
+		{
+			LabelStrategyConfig{SyncOnDDLOwnerChange: true, SyncInterval: time.Second},
+			"owner_change_delay 5s must be shorter than sync_interval 1s",
+		},
+	}
+	for _, t := range testcases {
+		err := t.Config.Validate()
+		c.Assert(errorx.IsOfType(err, ErrInvalidConfig), IsTrue, Commentf("%+v", t.Config))
+		c.Assert(errorx.Cast(err).Message(), Matches, t.Error)
+	}
+	cfg = LabelStrategyConfig{UnresolvedLabelFormat: "unknown"}
+	c.Assert(cfg.Validate(), IsNil)
+	cfg = LabelStrategyConfig{UnresolvedLabelFormat: "100%% table_%d"}
+	c.Assert(cfg.Validate(), IsNil)
+}
+
+func (s *testTiDBSuite) TestSyncHistory(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	})
+	c.Assert(resolver.SyncHistory(), HasLen, 0)
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	resolver.EtcdClient = &testEtcdKV{Err: errors.New("etcdserver: request timed out")}
+	resolver.Sync(context.Background())
+	history := resolver.SyncHistory()
+	c.Assert(history, HasLen, 2)
+	c.Assert(errorx.IsOfType(history[0].Err, ErrEtcdUnavailable), IsTrue)
+	c.Assert(history[1].Err, IsNil)
+	c.Assert(history[1].Version, Equals, int64(100))
+	c.Assert(history[1].Added, Equals, 1)
+	c.Assert(history[1].StartedAt.After(history[0].StartedAt), IsFalse)
+
+	// Only the latest syncHistorySize results are kept.
+	var ids []string
+	for i := 0; i < syncHistorySize+3; i++ {
+		ids = append(ids, resolver.Sync(context.Background()).ID)
+	}
+	history = resolver.SyncHistory()
+	c.Assert(history, HasLen, syncHistorySize)
+	for i, result := range history {
+		c.Assert(result.ID, Equals, ids[len(ids)-1-i])
+	}
+}
+
+func (s *testTiDBSuite) TestRevalidate(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/db-table/10": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":10,"name":{"O":"t","L":"t"},"index_info":[{"id":1,"idx_name":{"O":"idx","L":"idx"}}]}}`,
+		"/db-table/12": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":11,"name":{"O":"p","L":"p"},"partition":{"enable":true,"definitions":[{"id":12,"name":{"O":"p0","L":"p0"}}]}}}`,
+		"/db-table/13": `{}`,
+	})
+	resolver.TableMap.Store(10, &tableDetail{ID: 10, DB: "test", Name: "t", Indices: map[int64]string{}, RawName: "t", RawDB: "test"})
+
+	changed, err := resolver.Revalidate(context.Background(), 10)
+	c.Assert(err, IsNil)
+	c.Assert(changed, IsTrue)
+	c.Assert(loadTestDetail(c, resolver, 10).Indices, DeepEquals, map[int64]string{1: "idx"})
+	changed, err = resolver.Revalidate(context.Background(), 10)
+	c.Assert(err, IsNil)
+	c.Assert(changed, IsFalse)
+
+	changed, err = resolver.Revalidate(context.Background(), 12)
+	c.Assert(err, IsNil)
+	c.Assert(changed, IsTrue)
+	c.Assert(loadTestDetail(c, resolver, 11).Name, Equals, "p")
+	c.Assert(loadTestDetail(c, resolver, 12).Name, Equals, "p/p0")
+
+	_, err = resolver.Revalidate(context.Background(), 13)
+	c.Assert(errorx.IsOfType(err, ErrParseFailed), IsTrue)
+	resolver.TableMap.Store(14, &tableDetail{ID: 14, DB: "test", Name: "gone"})
+	_, err = resolver.Revalidate(context.Background(), 14)
+	c.Assert(errorx.IsOfType(err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(loadTestDetail(c, resolver, 14).Name, Equals, "gone")
+}
+
+func (s *testTiDBSuite) TestCheckConsistency(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/db-table/10": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":10,"name":{"O":"t1","L":"t1"},"index_info":[{"id":1,"idx_name":{"O":"idx1","L":"idx1"}},
+			{"id":2,"idx_name":{"O":"idx2","L":"idx2"}}]}}`,
+		"/db-table/20": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":20,"name":{"O":"t2","L":"t2"}}}`,
+	})
+	// Table 10 lost an index, table 20 is intact, and table 30 is gone from TiDB.
+	resolver.updateTableMap("test", []*model.TableInfo{
+		newTestTableInfo(10, "t1", newTestIndexInfo(1, "idx1")),
+		newTestTableInfo(20, "t2"),
+		newTestTableInfo(30, "t3"),
+	}, newSyncSummary())
+
+	client := resolver.tidbClient.(*testStatusAPIClient)
+	resolver.ConsistencyCheckSize = 3
+	c.Assert(resolver.checkConsistency(context.Background()), Equals, 1)
+	c.Assert(client.Requests, HasLen, 3)
+	c.Assert(loadTestDetail(c, resolver, 10).Indices, DeepEquals, map[int64]string{1: "idx1", 2: "idx2"})
+	// A table failed to be fetched is left as is.
+	loadTestDetail(c, resolver, 30)
+
+	resolver.ConsistencyCheckSize = 1
+	client.Requests = nil
+	c.Assert(resolver.checkConsistency(context.Background()), Equals, 0)
+	c.Assert(client.Requests, HasLen, 1)
+}
+
+func (s *testTiDBSuite) TestRevalidateUpdatesKeyIndex(c *C) {
+	partitioned := func(ids ...int) string {
+		var defs []string
+		for _, id := range ids {
+			defs = append(defs, fmt.Sprintf(`{"id":%d,"name":{"O":"p%d","L":"p%d"}}`, id, id, id))
+		}
+		return `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":20,"name":{"O":"p","L":"p"},"partition":{"enable":true,"definitions":[` +
+			strings.Join(defs, ",") + `]}}}`
+	}
+	responses := map[string]string{
+		"/db-table/10": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":10,"name":{"O":"t","L":"t"}}}`,
+		"/db-table/20": partitioned(21),
+	}
+	resolver := newTestResolver("1", responses)
+	resolver.SkipPartitions = true
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(30, "u")}, newSyncSummary())
+	resolver.rebuildKeyIndex()
+
+	for _, id := range []int64{10, 20} {
+		gen := resolver.tableMapGen.Load()
+		_, err := resolver.Revalidate(context.Background(), id)
+		c.Assert(err, IsNil)
+		c.Assert(resolver.tableMapGen.Load(), Equals, gen+1)
+	}
+	// ADD PARTITION changes the table, whose new partition is indexed as well.
+	responses["/db-table/20"] = partitioned(21, 22)
+	_, err := resolver.Revalidate(context.Background(), 20)
+	c.Assert(err, IsNil)
+
+	c.Assert(resolver.loadKeyIndex(), DeepEquals, newTableKeyIndex(resolver.TableMap))
+	for key, id := range map[string]int64{
+		string(model.GenerateRowKey(10, 1)): 10,
+		string(model.GenerateRowKey(22, 1)): 20,
+		string(model.GenerateRowKey(30, 1)): 30,
+	} {
+		found, ok := resolver.TableIDOfKey([]byte(key))
+		c.Assert(ok, IsTrue)
+		c.Assert(found, Equals, id)
+	}
+}
+
+func (s *testTiDBSuite) TestRevalidateStale(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/db-table/10": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":10,"name":{"O":"t1","L":"t1"}}}`,
+		"/db-table/20": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":20,"name":{"O":"renamed","L":"renamed"}}}`,
+	})
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(10, "t1")}, newSyncSummary())
+	fetchedAt := loadTestDetail(c, resolver, 10).UpdatedAt
+	c.Assert(fetchedAt.IsZero(), IsFalse)
+	// Table 20 is preloaded and table 30 is gone from TiDB, so both are older than table 10.
+	resolver.TableMap.Store(20, &tableDetail{ID: 20, DB: "test", Name: "t2"})
+	resolver.TableMap.Store(30, &tableDetail{ID: 30, DB: "test", Name: "t3"})
+	client := resolver.tidbClient.(*testStatusAPIClient)
+	resolver.StaleRevalidateSize = 2
+
+	c.Assert(resolver.revalidateStale(context.Background()), Equals, 1)
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/20", "/db-table/30"})
+	detail := loadTestDetail(c, resolver, 20)
+	c.Assert(detail.Name, Equals, "renamed")
+	c.Assert(detail.UpdatedAt.After(fetchedAt), IsTrue)
+
+	// The failed table 30 waits for its turn after the others.
+	client.Requests = nil
+	c.Assert(resolver.revalidateStale(context.Background()), Equals, 0)
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/10", "/db-table/20"})
+	c.Assert(loadTestDetail(c, resolver, 10).UpdatedAt.After(fetchedAt), IsTrue)
+	client.Requests = nil
+	resolver.revalidateStale(context.Background())
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/30", "/db-table/10"})
+
+	resolver.DropTable(30)
+	client.Requests = nil
+	resolver.revalidateStale(context.Background())
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/20", "/db-table/10"})
+	c.Assert(resolver.staleAttempts, HasLen, 2)
+}
+
+func (s *testTiDBSuite) TestLazy(c *C) {
+	client := &testStatusAPIClient{Responses: map[string]string{
+		"/db-table/10": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":10,"name":{"O":"t","L":"t"},"index_info":[{"id":1,"idx_name":{"O":"idx","L":"idx"}}]}}`,
+	}}
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = client
+	resolver.Lazy = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver.Run(ctx)
+
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "idx"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+	info, ok := resolver.Resolve(10)
+	c.Assert(ok, IsTrue)
+	c.Assert(info.Name, Equals, "t")
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/10"})
+
+	// A table failed to be fetched is not fetched again until lazyRetryInterval passes.
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals, []string{"table_11", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 2))).Labels, DeepEquals, []string{"table_11", "row_2"})
+	_, ok = resolver.Resolve(11)
+	c.Assert(ok, IsFalse)
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/10", "/db-table/11"})
+	resolver.lazy.failed[11] = time.Now().Add(-lazyRetryInterval)
+	labeler.label(string(model.GenerateRowKey(11, 1)))
+	c.Assert(client.Requests, HasLen, 3)
+}
+
+// testBlockingStatusAPIClient pauses each request until Release is closed.
+type testBlockingStatusAPIClient struct {
+	*testStatusAPIClient
+	Started chan string
+	Release chan struct{}
+}
+
+func (c *testBlockingStatusAPIClient) WithHeader(string, string) statusAPIClient {
+	return c
+}
+
+func (c *testBlockingStatusAPIClient) WithCheckRedirect(func(*http.Request, []*http.Request) error) statusAPIClient {
+	return c
+}
+
+func (c *testBlockingStatusAPIClient) Get(ctx context.Context, relativeURI string) (*httpc.Response, error) {
+	c.Started <- relativeURI
+	<-c.Release
+	return c.testStatusAPIClient.Get(ctx, relativeURI)
+}
+
+func (s *testTiDBSuite) TestCancelSync(c *C) {
+	client := &testBlockingStatusAPIClient{
+		testStatusAPIClient: &testStatusAPIClient{Responses: map[string]string{
+			"/schema":     `[{"db_name":{"O":"db1","L":"db1"},"state":5},{"db_name":{"O":"db2","L":"db2"},"state":5}]`,
+			"/schema/db1": `[{"id":10,"name":{"O":"t1","L":"t1"}}]`,
+			"/schema/db2": `[{"id":20,"name":{"O":"t2","L":"t2"}}]`,
+		}},
+		Started: make(chan string, 10),
+		Release: make(chan struct{}),
+	}
+	resolver := newTestResolver("1", nil)
+	resolver.tidbClient = client
+	resolver.SyncConcurrency = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan SyncResult)
+	go func() {
+		done <- resolver.Sync(ctx)
+	}()
+	c.Assert(<-client.Started, Equals, "/schema")
+	client.Release <- struct{}{}
+	c.Assert(<-client.Started, Equals, "/schema/db1")
+	cancel()
+	client.Release <- struct{}{}
+
+	// db2 is never requested, and the version is not applied.
+	result := <-done
+	c.Assert(errorx.IsOfType(result.Err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+	c.Assert(client.Requests, DeepEquals, []string{"/schema", "/schema/db1"})
+}
+
+func (s *testTiDBSuite) TestLazyIndexOnly(c *C) {
+	// TiDB resolves the ID of a partition to its partitioned table.
+	table := `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+		"table_info":{"id":10,"name":{"O":"t","L":"t"},"index_info":[{"id":1,"idx_name":{"O":"idx","L":"idx"}}],
+		"partition":{"enable":true,"definitions":[{"id":11,"name":{"O":"p0","L":"p0"}}]}}}`
+	client := &testStatusAPIClient{Responses: map[string]string{"/db-table/11": table}}
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = client
+	resolver.Lazy = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver.Run(ctx)
+
+	// Only the keys of an index of a partition are hot, so no row key of its table is ever labeled.
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(11, 1))).Labels, DeepEquals, []string{"test", "t/p0", "idx"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(11, 2))).Labels, DeepEquals, []string{"test", "t/p0", "index_2"})
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/11"})
+	info, ok := resolver.Resolve(10)
+	c.Assert(ok, IsTrue)
+	c.Assert(info.Name, Equals, "t")
+}
+
+func (s *testTiDBSuite) TestLazyConcurrency(c *C) {
+	responses := make(map[string]string)
+	for id := 10; id < 13; id++ {
+		responses[fmt.Sprintf("/db-table/%d", id)] = fmt.Sprintf(`{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":%d,"name":{"O":"t%d","L":"t%d"}}}`, id, id, id)
+	}
+	client := &testBlockingStatusAPIClient{
+		testStatusAPIClient: &testStatusAPIClient{Responses: responses},
+		Started:             make(chan string, 10),
+		Release:             make(chan struct{}),
+	}
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = client
+	resolver.Lazy = true
+	resolver.SyncConcurrency = 2
+	lookup := resolver.tables()
+
+	// Two lookups of table 10 share one fetch, and table 11 takes the other worker.
+	var wg sync.WaitGroup
+	results := make([]bool, 3)
+	for i, id := range []int64{10, 10, 11} {
+		i, id := i, id
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = lookup.Load(id)
+		}()
+	}
+	started := []string{<-client.Started, <-client.Started}
+	sort.Strings(started)
+	c.Assert(started, DeepEquals, []string{"/db-table/10", "/db-table/11"})
+
+	// With both workers busy, a miss returns at once instead of waiting.
+	_, ok := lookup.Load(12)
+	c.Assert(ok, IsFalse)
+
+	close(client.Release)
+	wg.Wait()
+	c.Assert(results, DeepEquals, []bool{true, true, true})
+	c.Assert(client.Requests, HasLen, 2)
+
+	// The saturated miss is not a failure, so table 12 is fetched by the next lookup.
+	_, ok = lookup.Load(12)
+	c.Assert(ok, IsTrue)
+	c.Assert(client.Requests, HasLen, 3)
+}
+
+func (s *testTiDBSuite) TestWarmupConcurrency(c *C) {
+	responses := map[string]string{
+		"/schema": `[{"db_name":{"O":"db1","L":"db1"},"state":5},{"db_name":{"O":"db2","L":"db2"},"state":5},
+			{"db_name":{"O":"db3","L":"db3"},"state":5}]`,
+		"/schema/db1": `[]`,
+		"/schema/db2": `[]`,
+		"/schema/db3": `[]`,
+	}
+	resolver := newTestResolver("1", nil)
+	resolver.SyncConcurrency = 1
+	resolver.WarmupConcurrency = 3
+	// assertWorkers runs a sync, and asserts the number of /schema/{db} requests sent before any of them returns.
+	assertWorkers := func(want int) {
+		client := &testBlockingStatusAPIClient{
+			testStatusAPIClient: &testStatusAPIClient{Responses: responses},
+			Started:             make(chan string, 10),
+			Release:             make(chan struct{}),
+		}
+		resolver.tidbClient = client
+		done := make(chan SyncResult)
+		go func() {
+			done <- resolver.Sync(context.Background())
+		}()
+		c.Assert(<-client.Started, Equals, "/schema")
+		client.Release <- struct{}{}
+		for i := 0; i < want; i++ {
+			<-client.Started
+		}
+		select {
+		case path := <-client.Started:
+			c.Fatalf("more than %d workers, %s is requested", want, path)
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(client.Release)
+		c.Assert((<-done).Err, IsNil)
+	}
+
+	assertWorkers(3)
+	resolver.EtcdClient.(*testEtcdKV).SchemaVersion = "2"
+	assertWorkers(1)
+}
+
+func (s *testTiDBSuite) TestStreamingApply(c *C) {
+	responses := map[string]string{
+		"/schema":     `[{"db_name":{"O":"db1","L":"db1"},"state":5},{"db_name":{"O":"db2","L":"db2"},"state":5}]`,
+		"/schema/db1": `[{"id":10,"name":{"O":"t1","L":"t1"}}]`,
+		"/schema/db2": `[{"id":20,"name":{"O":"t2","L":"t2"}}]`,
+	}
+	for _, streaming := range []bool{false, true} {
+		client := &testBlockingStatusAPIClient{
+			testStatusAPIClient: &testStatusAPIClient{Responses: responses},
+			Started:             make(chan string, 10),
+			Release:             make(chan struct{}),
+		}
+		resolver := newTestResolver("1", nil)
+		resolver.tidbClient = client
+		resolver.SyncConcurrency = 1
+		resolver.StreamingApply = streaming
+		done := make(chan SyncResult)
+		go func() {
+			done <- resolver.Sync(context.Background())
+		}()
+		for _, path := range []string{"/schema", "/schema/db1"} {
+			c.Assert(<-client.Started, Equals, path)
+			client.Release <- struct{}{}
+		}
+
+		// db1 is applied before db2 is fetched only when streaming.
+		c.Assert(<-client.Started, Equals, "/schema/db2")
+		_, ok := resolver.TableMap.Load(10)
+		c.Assert(ok, Equals, streaming)
+		close(client.Release)
+		result := <-done
+		c.Assert(result.Err, IsNil)
+		c.Assert(result.Added, Equals, 2)
+		loadTestDetail(c, resolver, 20)
+	}
+}
+
+func (s *testTiDBSuite) TestHotTablesFirst(c *C) {
+	newResolver := func() *TableResolver {
+		resolver := newTestResolver("1", map[string]string{
+			"/schema": `[{"db_name":{"O":"db1","L":"db1"},"state":5},{"db_name":{"O":"db2","L":"db2"},"state":5},
+				{"db_name":{"O":"db3","L":"db3"},"state":5}]`,
+			"/schema/db1": `[{"id":10,"name":{"O":"t1","L":"t1"}}]`,
+			"/schema/db2": `[{"id":20,"name":{"O":"t2","L":"t2"}}]`,
+			"/schema/db3": `[{"id":30,"name":{"O":"t3","L":"t3"}}]`,
+		})
+		resolver.SyncConcurrency = 1
+		resolver.LookupSampleRate = 1
+		resolver.Preload([]TableInfo{
+			{ID: 10, DB: "db1", Name: "t1"}, {ID: 20, DB: "db2", Name: "t2"}, {ID: 30, DB: "db3", Name: "t3"},
+		}, -1)
+		for i := 0; i < 3; i++ {
+			resolver.recordLookup(30)
+		}
+		resolver.recordLookup(20)
+		return resolver
+	}
+	fetched := func(resolver *TableResolver) []string {
+		c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+		return resolver.tidbClient.(*testStatusAPIClient).Requests[1:]
+	}
+
+	c.Assert(fetched(newResolver()), DeepEquals, []string{"/schema/db1", "/schema/db2", "/schema/db3"})
+	resolver := newResolver()
+	resolver.HotTablesFirst = true
+	c.Assert(fetched(resolver), DeepEquals, []string{"/schema/db3", "/schema/db2", "/schema/db1"})
+
+	// The hint is added to the sampled lookups.
+	resolver = newResolver()
+	resolver.HotTablesFirst = true
+	resolver.PriorityHint = func() map[int64]int {
+		return map[int64]int{10: 10, 40: 100}
+	}
+	c.Assert(fetched(resolver), DeepEquals, []string{"/schema/db1", "/schema/db3", "/schema/db2"})
+}
+
+func (s *testTiDBSuite) TestUpdateTableMapNormalizeName(c *C) {
+	resolver := &TableResolver{
+		TableMap: newSyncMapTableStore(),
+		NormalizeName: func(db, table string) (string, string) {
+			return db, strings.TrimPrefix(table, "t123_")
+		},
+	}
+	table := newTestTableInfo(10, "t123_orders")
+	table.Partition = &model.PartitionInfo{
+		Enable:      true,
+		Definitions: []*model.PartitionDefinition{{ID: 11, Name: model.CIStr{O: "p0", L: "p0"}}},
+	}
+	resolver.updateTableMap("db", []*model.TableInfo{table}, newSyncSummary())
+
+	detail := loadTestDetail(c, resolver, 10)
+	c.Assert(detail.Name, Equals, "orders")
+	c.Assert(detail.RawName, Equals, "t123_orders")
+	detail = loadTestDetail(c, resolver, 11)
+	c.Assert(detail.Name, Equals, "orders/p0")
+	c.Assert(detail.RawName, Equals, "t123_orders/p0")
+}
+
+func (s *testTiDBSuite) TestResolveReturnsCopy(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("db", []*model.TableInfo{
+		newTestTableInfo(10, "t", newTestIndexInfo(1, "idx_a")),
+	}, newSyncSummary())
+
+	info, ok := resolver.Resolve(10)
+	c.Assert(ok, IsTrue)
+	c.Assert(info, DeepEquals, TableInfo{ID: 10, DB: "db", Name: "t", Indices: map[int64]string{1: "idx_a"}})
+	info.Indices[1] = "changed"
+	c.Assert(loadTestDetail(c, resolver, 10).Indices[1], Equals, "idx_a")
+
+	_, ok = resolver.Resolve(11)
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testTiDBSuite) TestLRUTableStore(c *C) {
+	store := newLRUTableStore(2)
+	store.Store(1, &tableDetail{ID: 1})
+	store.Store(2, &tableDetail{ID: 2})
+	_, ok := store.Load(1)
+	c.Assert(ok, IsTrue)
+
+	// 2 is the least recently looked-up one.
+	store.Store(3, &tableDetail{ID: 3})
+	_, ok = store.Load(2)
+	c.Assert(ok, IsFalse)
+	_, ok = store.Load(1)
+	c.Assert(ok, IsTrue)
+	_, ok = store.Load(3)
+	c.Assert(ok, IsTrue)
+}
+
+func (s *testTiDBSuite) TestInvariantChecks(c *C) {
+	tableMap := newSyncMapTableStore()
+	tableMap.Store(1, &tableDetail{ID: 1, Name: "t1", Indices: map[int64]string{1: "idx"}})
+	tableMap.Store(2, &tableDetail{ID: 2, Name: "t2", Indices: map[int64]string{}})
+	snapshot := newTableSnapshot(tableMap, 1)
+	snapshot.checkOrder()
+	// The copies are taken by newTableSnapshot only in the builds with the checks.
+	snapshot.copies = nil
+	for _, detail := range snapshot.details {
+		snapshot.copies = append(snapshot.copies, detail.clone())
+	}
+	snapshot.checkUnmodified()
+
+	detail, _ := tableMap.Load(1)
+	detail.Indices[2] = "idx2"
+	c.Assert(snapshot.checkUnmodified, PanicMatches, "the detail of table 1 is modified after it is stored.*")
+
+	snapshot.details[0], snapshot.details[1] = snapshot.details[1], snapshot.details[0]
+	c.Assert(snapshot.checkOrder, PanicMatches, "the snapshot of generation 1 is inconsistent.*")
+}
+
+func (s *testTiDBSuite) TestResolveAt(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t1","L":"t1"}}]`,
+	}
+	resolver := newTestResolver("100", responses)
+	resolver.RetainedVersions = 2
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(100))
+	responses["/schema/test"] = `[{"id":10,"name":{"O":"t2","L":"t2"}},{"id":11,"name":{"O":"t3","L":"t3"}}]`
+	resolver.EtcdClient = &testEtcdKV{SchemaVersion: "105"}
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(105))
+
+	info, version, ok := resolver.ResolveAt(103, 10)
+	c.Assert(ok, IsTrue)
+	c.Assert(version, Equals, int64(100))
+	c.Assert(info.Name, Equals, "t1")
+	_, version, ok = resolver.ResolveAt(103, 11)
+	c.Assert(ok, IsFalse)
+	c.Assert(version, Equals, int64(100))
+	info, version, ok = resolver.ResolveAt(200, 10)
+	c.Assert(ok, IsTrue)
+	c.Assert(version, Equals, int64(105))
+	c.Assert(info.Name, Equals, "t2")
+	_, _, ok = resolver.ResolveAt(99, 10)
+	c.Assert(ok, IsFalse)
+
+	// The oldest version is dropped beyond RetainedVersions.
+	resolver.Preload([]TableInfo{{ID: 10, DB: "test", Name: "t4"}}, 110)
+	_, _, ok = resolver.ResolveAt(103, 10)
+	c.Assert(ok, IsFalse)
+	info, _, ok = resolver.ResolveAt(110, 10)
+	c.Assert(ok, IsTrue)
+	c.Assert(info.Name, Equals, "t4")
+}
+
+func (s *testTiDBSuite) TestRegionLabels(c *C) {
+	var rules []*regionLabelRule
+	err := json.Unmarshal([]byte(`[
+		{"id":"a","labels":[{"key":"schedule","value":"deny"}],"rule_type":"key-range",
+		 "data":[{"start_key":"`+hex.EncodeToString(model.GenerateRowKey(10, 0))+`","end_key":"`+hex.EncodeToString(model.GenerateRowKey(11, 0))+`"}]},
+		{"id":"b","labels":[{"key":"k","value":"v"}],"rule_type":"other","data":"whatever"}
+	]`), &rules)
+	c.Assert(err, IsNil)
+	ranges := parseRegionLabelRules(rules)
+	c.Assert(ranges, HasLen, 1)
+
+	labeler := &tidbLabeler{
+		TableMap:     newSyncMapTableStore(),
+		Decoder:      NewTiDBKeyDecoder(),
+		RegionLabels: ranges,
+	}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 5))).Labels, DeepEquals, []string{"table_10", "row_5", "schedule=deny"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 5))).Labels, DeepEquals, []string{"table_11", "row_5"})
+}
+
+func (s *testTiDBSuite) TestIndexKeyRanges(c *C) {
+	tableMap := newSyncMapTableStore()
+	tableMap.Store(10, &tableDetail{ID: 10, DB: "db", Name: "t", Indices: map[int64]string{1: "idx_a", 3: "idx_b"}})
+	labeler := &tidbLabeler{TableMap: tableMap, Decoder: NewTiDBKeyDecoder()}
+
+	const tablePrefix = "t\x80\x00\x00\x00\x00\x00\x00\x0a"
+	key := func(raw string) string {
+		return string(model.EncodeKey([]byte(raw)))
+	}
+	indexStart := func(id int64) string {
+		return string(model.GenerateIndexKey(10, id))
+	}
+	var (
+		idxAValue   = key(tablePrefix + "_i\x80\x00\x00\x00\x00\x00\x00\x01\x01zzz")
+		idxBValue   = key(tablePrefix + "_i\x80\x00\x00\x00\x00\x00\x00\x03\x01aaa")
+		indexPrefix = key(tablePrefix + "_i")
+		// Truncated inside the index ID, which spans index IDs 0 to 255, so the first index in it is idx_a.
+		truncated = key(tablePrefix + "_i\x80\x00\x00\x00\x00\x00\x00")
+		rowStart  = key(tablePrefix + "_r")
+		row       = string(model.GenerateRowKey(10, 1))
+	)
+
+	c.Assert(labeler.label(indexPrefix).Labels, DeepEquals, []string{"db", "t", "idx_a"})
+	c.Assert(labeler.label(indexStart(1)).Labels, DeepEquals, []string{"db", "t", "idx_a"})
+	c.Assert(labeler.label(truncated).Labels, DeepEquals, []string{"db", "t", "idx_a"})
+	c.Assert(labeler.label(key(tablePrefix+"_i\x80\x00\x00\x00\x00\x00\x00\x02\x01")).Labels, DeepEquals, []string{"db", "t", "index_2"})
+	c.Assert(labeler.label(rowStart).Labels, DeepEquals, []string{"db", "t"})
+
+	// Within the same index.
+	c.Assert(labeler.CrossBorder(indexStart(1), idxAValue), IsFalse)
+	c.Assert(labeler.CrossBorder(indexPrefix, idxAValue), IsFalse)
+	// The last key of an index and the first key of the next one.
+	c.Assert(labeler.CrossBorder(idxAValue, indexStart(3)), IsTrue)
+	c.Assert(labeler.CrossBorder(indexStart(3), idxBValue), IsFalse)
+	// The last index and the row range.
+	c.Assert(labeler.CrossBorder(idxBValue, rowStart), IsTrue)
+	c.Assert(labeler.CrossBorder(rowStart, row), IsFalse)
+	// Past all indices, nothing but rows follows.
+	c.Assert(labeler.CrossBorder(key(tablePrefix+"_i\x80\x00\x00\x00\x00\x00\x01"), row), IsFalse)
+}
+
+func (s *testTiDBSuite) TestSyncOnDDLOwnerChange(c *C) {
+	kv := &testEtcdKV{Gets: make(chan struct{})}
+	watcher := &testEtcdWatcher{Responses: make(chan clientv3.WatchResponse)}
+	resolver := &TableResolver{
+		EtcdClient:           kv,
+		etcdWatcher:          watcher,
+		TableMap:             newSyncMapTableStore(),
+		SyncInterval:         time.Hour,
+		SyncOnDDLOwnerChange: true,
+		OwnerChangeDelay:     time.Millisecond,
+	}
+	resolver.SetSchemaVersion(-1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go resolver.Run(ctx)
+
+	watcher.Responses <- clientv3.WatchResponse{}
+	select {
+	case <-kv.Gets:
+	case <-time.After(10 * time.Second):
+		c.Fatal("sync is not triggered by the owner change")
+	}
+}
+
+func (s *testTiDBSuite) TestSyncOnSchemaVersionChange(c *C) {
+	kv := &testEtcdKV{Gets: make(chan struct{}, 16)}
+	watcher := &testEtcdWatcher{Responses: make(chan clientv3.WatchResponse)}
+	resolver := &TableResolver{
+		EtcdClient:                kv,
+		etcdWatcher:               watcher,
+		TableMap:                  newSyncMapTableStore(),
+		SyncInterval:              time.Hour,
+		SyncOnSchemaVersionChange: true,
+		SchemaVersionDebounce:     100 * time.Millisecond,
+	}
+	resolver.SetSchemaVersion(-1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go resolver.Run(ctx)
+
+	// A burst of bumps is coalesced into one sync.
+	for i := 0; i < 5; i++ {
+		watcher.Responses <- clientv3.WatchResponse{}
+	}
+	select {
+	case <-kv.Gets:
+	case <-time.After(10 * time.Second):
+		c.Fatal("sync is not triggered by the schema version change")
+	}
+	select {
+	case <-kv.Gets:
+		c.Fatal("the burst of schema version changes is not coalesced")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// A bump after the sync triggers the next one.
+	watcher.Responses <- clientv3.WatchResponse{}
+	select {
+	case <-kv.Gets:
+	case <-time.After(10 * time.Second):
+		c.Fatal("sync is not triggered by the schema version change after the sync")
+	}
+}
+
+func (s *testTiDBSuite) TestReestablishDDLOwnerWatch(c *C) {
+	kv := &testEtcdKV{Gets: make(chan struct{}, 16)}
+	watcher := &testEtcdWatcher{Responses: make(chan clientv3.WatchResponse)}
+	resolver := &TableResolver{
+		EtcdClient:           kv,
+		etcdWatcher:          watcher,
+		TableMap:             newSyncMapTableStore(),
+		SyncInterval:         time.Hour,
+		SyncOnDDLOwnerChange: true,
+		OwnerChangeDelay:     time.Millisecond,
+		Cluster:              "watch-reconnections",
+	}
+	resolver.SetSchemaVersion(-1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reconnections := watchReconnections.WithLabelValues("watch-reconnections", ddlOwnerPrefix)
+	go resolver.Run(ctx)
+
+	// A failed watch is re-established, and a sync is triggered for the changes possibly missed.
+	watcher.Responses <- clientv3.WatchResponse{CompactRevision: 1}
+	select {
+	case <-kv.Gets:
+	case <-time.After(10 * time.Second):
+		c.Fatal("sync is not triggered by the re-established watch")
+	}
+	c.Assert(testutil.ToFloat64(reconnections), Equals, float64(1))
+
+	// The owner changes are watched again.
+	watcher.Responses <- clientv3.WatchResponse{}
+	select {
+	case <-kv.Gets:
+	case <-time.After(10 * time.Second):
+		c.Fatal("sync is not triggered by the owner change")
+	}
+	c.Assert(atomic.LoadInt64(&watcher.watches), Equals, int64(2))
+	c.Assert(atomic.LoadInt64(&watcher.progress), Equals, int64(0))
+}
+
+func (s *testTiDBSuite) TestReestablishUnresponsiveWatch(c *C) {
+	watcher := &testEtcdWatcher{Responses: make(chan clientv3.WatchResponse)}
+	resolver := &TableResolver{
+		etcdWatcher:            watcher,
+		SyncOnDDLOwnerChange:   true,
+		WatchKeepAliveInterval: 10 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		resolver.watchEtcdOnce(ctx, ddlOwnerPrefix, func() {}, clientv3.WithPrefix())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		c.Fatal("the unresponsive watch is not given up")
+	}
+	c.Assert(atomic.LoadInt64(&watcher.progress), Equals, int64(1))
+}
+
+func (s *testTiDBSuite) TestPKLabels(c *C) {
+	var tableInfos []*model.TableInfo
+	err := json.Unmarshal([]byte(`[
+		{"id":10,"name":{"O":"t1","L":"t1"},"is_common_handle":true,"index_info":[
+			{"id":1,"idx_name":{"O":"PRIMARY","L":"primary"},"is_primary":true,
+			 "idx_cols":[{"name":{"O":"a","L":"a"}},{"name":{"O":"b","L":"b"}}]}]},
+		{"id":11,"name":{"O":"t2","L":"t2"},"pk_is_handle":true,
+		 "cols":[{"id":1,"name":{"O":"id","L":"id"},"type":{"Flag":3}},{"id":2,"name":{"O":"v","L":"v"},"type":{"Flag":0}}]},
+		{"id":12,"name":{"O":"t3","L":"t3"}}
+	]`), &tableInfos)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("db", tableInfos, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 12).PKColumns, HasLen, 0)
+
+	labeler := &tidbLabeler{TableMap: resolver.TableMap, Decoder: NewTiDBKeyDecoder(), PKLabels: true}
+	commonHandleKey := model.EncodeKey(append(
+		[]byte("t\x80\x00\x00\x00\x00\x00\x00\x0a_r"), "\x01a\x00\x00\x00\x00\x00\x00\x00\xf8"...))
+	c.Assert(labeler.label(string(commonHandleKey)).Labels, DeepEquals, []string{"db", "t1", "PK(a,b)", "row_(a=a)"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals, []string{"db", "t2", "PK(id)", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"db", "t3", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"db", "t1", "PRIMARY"})
+}
+
+func (s *testTiDBSuite) TestCommonHandleLabels(c *C) {
+	var tableInfos []*model.TableInfo
+	err := json.Unmarshal([]byte(`[
+		{"id":10,"name":{"O":"t1","L":"t1"},"is_common_handle":true,"index_info":[
+			{"id":1,"idx_name":{"O":"PRIMARY","L":"primary"},"is_primary":true,
+			 "idx_cols":[{"name":{"O":"a","L":"a"}},{"name":{"O":"b","L":"b"}}]}]}
+	]`), &tableInfos)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("db", tableInfos, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 10).CommonHandle, IsTrue)
+
+	// (a, b) = (-3, "abcdefghij"), as an int and a varchar.
+	handle := append([]byte{3}, model.EncodeIntHandle(-3)...)
+	handle = append(handle, 1)
+	handle = append(handle, model.EncodeKey([]byte("abcdefghij"))...)
+	rowKey := func(handle []byte) string {
+		return string(model.EncodeKey(model.GenerateRawTableKey(10, append([]byte("_r"), handle...))))
+	}
+	labeler := &tidbLabeler{TableMap: resolver.TableMap, Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(rowKey(handle)).Labels, DeepEquals, []string{"db", "t1", "row_(a=-3,b=abcdefghij)"})
+	// A region split key may end inside a value.
+	c.Assert(labeler.label(rowKey(handle[:15])).Labels, DeepEquals, []string{"db", "t1", "row_(a=-3,...)"})
+	// A common handle of 8 bytes is not taken for an integer handle, even if its values are not decoded.
+	c.Assert(labeler.label(rowKey([]byte("\x06\x01\x02\x03\x04\x05\x06\x07"))).Labels, DeepEquals,
+		[]string{"db", "t1", "row"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"db", "t1", "PRIMARY"})
+}
+
+func (s *testTiDBSuite) TestHandleLabels(c *C) {
+	var tableInfos []*model.TableInfo
+	err := json.Unmarshal([]byte(`[
+		{"id":10,"name":{"O":"t1","L":"t1"},"is_common_handle":true},
+		{"id":11,"name":{"O":"t2","L":"t2"},"pk_is_handle":true},
+		{"id":12,"name":{"O":"t3","L":"t3"},"index_info":[{"id":1,"idx_name":{"O":"idx","L":"idx"}}]}
+	]`), &tableInfos)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("db", tableInfos, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 10).Clustered, IsTrue)
+	c.Assert(loadTestDetail(c, resolver, 11).Clustered, IsTrue)
+	c.Assert(loadTestDetail(c, resolver, 12).Clustered, IsFalse)
+
+	labeler := &tidbLabeler{TableMap: resolver.TableMap, Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"db", "t3", "row_1"})
+
+	labeler.HandleLabels = true
+	commonHandleKey := model.EncodeKey(append(
+		[]byte("t\x80\x00\x00\x00\x00\x00\x00\x0a_r"), "\x01a\x00\x00\x00\x00\x00\x00\x00\xf8"...))
+	c.Assert(labeler.label(string(commonHandleKey)).Labels, DeepEquals, []string{"db", "t1", "row_(a)", "clustered"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals, []string{"db", "t2", "row_1", "clustered"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"db", "t3", "row_1", "_tidb_rowid"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(12, 1))).Labels, DeepEquals, []string{"db", "t3", "idx"})
+	// The handle of an unresolved table is unknown.
+	c.Assert(labeler.label(string(model.GenerateRowKey(13, 1))).Labels, DeepEquals, []string{"table_13", "row_1"})
+}
+
+func (s *testTiDBSuite) TestTempIndexLabels(c *C) {
+	tableMap := newSyncMapTableStore()
+	tableMap.Store(10, &tableDetail{ID: 10, DB: "db", Name: "t", Indices: map[int64]string{2: "idx_new"}})
+	labeler := &tidbLabeler{TableMap: tableMap, Decoder: NewTiDBKeyDecoder()}
+
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, tempIndexPrefix|2))).Labels, DeepEquals, []string{"db", "t", "idx_new (building)"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, tempIndexPrefix|3))).Labels, DeepEquals, []string{"db", "t", "index_3 (building)"})
+	c.Assert(labeler.label(string(model.GenerateIndexKey(11, tempIndexPrefix|2))).Labels, DeepEquals, []string{"table_11", "index_2 (building)"})
+	c.Assert(labeler.CrossBorder(string(model.GenerateIndexKey(10, 2)), string(model.GenerateIndexKey(10, tempIndexPrefix|2))), IsTrue)
+}
+
+func (s *testTiDBSuite) TestLookupLabel(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	table := newTestTableInfo(10, "orders")
+	table.Partition = &model.PartitionInfo{
+		Enable:      true,
+		Definitions: []*model.PartitionDefinition{{ID: 11, Name: model.CIStr{O: "p0", L: "p0"}}},
+	}
+	resolver.updateTableMap("db", []*model.TableInfo{table}, newSyncSummary())
+	resolver.updateTableMap("a.b", []*model.TableInfo{newTestTableInfo(20, "c")}, newSyncSummary())
+	resolver.updateTableMap("a", []*model.TableInfo{newTestTableInfo(21, "b.c")}, newSyncSummary())
+
+	infos := resolver.LookupLabel("db.orders")
+	c.Assert(infos, HasLen, 1)
+	c.Assert(infos[0].ID, Equals, int64(10))
+	infos = resolver.LookupLabel("db.orders/p0")
+	c.Assert(infos, HasLen, 1)
+	c.Assert(infos[0].ID, Equals, int64(11))
+
+	infos = resolver.LookupLabel("a.b.c")
+	c.Assert(infos, HasLen, 2)
+	c.Assert(infos[0].ID, Equals, int64(20))
+	c.Assert(infos[1].ID, Equals, int64(21))
+
+	c.Assert(resolver.LookupLabel("orders"), HasLen, 0)
+	c.Assert(resolver.LookupLabel("db.order"), HasLen, 0)
+}
+
+func (s *testTiDBSuite) TestIndexLabel(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	table := newTestTableInfo(10, "orders", newTestIndexInfo(1, "idx_user"))
+	table.Partition = &model.PartitionInfo{
+		Enable:      true,
+		Definitions: []*model.PartitionDefinition{{ID: 11, Name: model.CIStr{O: "p0", L: "p0"}}},
+	}
+	resolver.updateTableMap("db", []*model.TableInfo{table}, newSyncSummary())
+
+	cases := []struct {
+		tableID, indexID int64
+		label            string
+	}{
+		// A row key.
+		{10, 0, "db.orders"},
+		{10, 1, "db.orders.idx_user"},
+		// An unknown index.
+		{10, 2, "db.orders"},
+		{11, 0, "db.orders/p0"},
+		{11, 1, "db.orders/p0.idx_user"},
+	}
+	for _, cs := range cases {
+		label, ok := resolver.IndexLabel(cs.tableID, cs.indexID)
+		c.Assert(ok, IsTrue)
+		c.Assert(label, Equals, cs.label, Commentf("%d %d", cs.tableID, cs.indexID))
+	}
+
+	// The index names of a partition are those of its table.
+	resolver.TableMap.Store(11, &tableDetail{ID: 11, ParentID: 10, DB: "db", Name: "orders/p0"})
+	label, _ := resolver.IndexLabel(11, 1)
+	c.Assert(label, Equals, "db.orders/p0.idx_user")
+
+	_, ok := resolver.IndexLabel(12, 1)
+	c.Assert(ok, IsFalse)
+
+	resolver.Cluster = "c1"
+	label, _ = resolver.IndexLabel(10, 1)
+	c.Assert(label, Equals, "c1.db.orders.idx_user")
+}
+
+func (s *testTiDBSuite) TestCaseInsensitiveLookups(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("Shop", []*model.TableInfo{newTestTableInfo(10, "ORDERS")}, newSyncSummary())
+	c.Assert(resolver.LookupLabel("shop.orders"), HasLen, 0)
+	c.Assert(resolver.CheckAgainstSQL([]SQLTable{{ID: 10, DB: "shop", Name: "orders"}}).Discrepancies, HasLen, 1)
+
+	resolver.CaseInsensitiveLookups = true
+	for _, label := range []string{"shop.orders", "SHOP.Orders", "Shop.ORDERS"} {
+		infos := resolver.LookupLabel(label)
+		c.Assert(infos, HasLen, 1, Commentf("%s", label))
+		c.Assert(infos[0].DB, Equals, "Shop")
+		c.Assert(infos[0].Name, Equals, "ORDERS")
+	}
+	c.Assert(resolver.LookupLabel("shop.order"), HasLen, 0)
+	c.Assert(resolver.CheckAgainstSQL([]SQLTable{{ID: 10, DB: "shop", Name: "orders"}}).Discrepancies, HasLen, 0)
+
+	// The labels keep the original case.
+	labeler := &tidbLabeler{TableMap: resolver.TableMap, Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"Shop", "ORDERS", "row_1"})
+}
+
+func (s *testTiDBSuite) TestHiddenTables(c *C) {
+	tables := []*model.TableInfo{newTestTableInfo(10, "stats_meta")}
+
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("mysql", tables, newSyncSummary())
+	resolver.updateTableMap("test", []*model.TableInfo{newTestTableInfo(20, "t")}, newSyncSummary())
+	labeler := &tidbLabeler{TableMap: resolver.TableMap, Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"mysql", "stats_meta", "row_1", "hidden", "statistics"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(20, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+
+	resolver = &TableResolver{TableMap: newSyncMapTableStore(), HiddenTables: HiddenTablesStore}
+	resolver.updateTableMap("mysql", tables, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 10).Hidden, IsFalse)
+
+	resolver = &TableResolver{TableMap: newSyncMapTableStore(), HiddenTables: HiddenTablesSkip}
+	resolver.updateTableMap("mysql", tables, newSyncSummary())
+	_, ok := resolver.TableMap.Load(10)
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testTiDBSuite) TestDDLStateTables(c *C) {
+	var tableInfos []*model.TableInfo
+	err := json.Unmarshal([]byte(`[
+		{"id":10,"name":{"O":"t1","L":"t1"},"state":5},
+		{"id":11,"name":{"O":"t2","L":"t2"},"state":3,
+			"partition":{"enable":true,"definitions":[{"id":12,"name":{"O":"p0","L":"p0"}}]}},
+		{"id":13,"name":{"O":"t3","L":"t3"},"state":1},
+		{"id":14,"name":{"O":"t4","L":"t4"}}
+	]`), &tableInfos)
+	c.Assert(err, IsNil)
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("db", tableInfos, newSyncSummary())
+	c.Assert(loadTestDetail(c, resolver, 10).DDLState, Equals, model.StateNone)
+	c.Assert(loadTestDetail(c, resolver, 12).DDLState, Equals, model.StateWriteReorganization)
+	// A table reported without a state, e.g. by an older TiDB, is taken as public.
+	c.Assert(loadTestDetail(c, resolver, 14).DDLState, Equals, model.StateNone)
+	info, ok := resolver.Resolve(11)
+	c.Assert(ok, IsTrue)
+	c.Assert(info.DDLState, Equals, "write reorganization")
+
+	labeler := &tidbLabeler{TableMap: resolver.TableMap, Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"db", "t1", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"db", "t2/p0", "row_1", "DDL(write reorganization)"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(13, 1))).Labels, DeepEquals, []string{"db", "t3", "row_1", "DDL(delete only)"})
+
+	resolver = &TableResolver{TableMap: newSyncMapTableStore(), SkipDeleteOnlyTables: true}
+	resolver.updateTableMap("db", tableInfos, newSyncSummary())
+	_, ok = resolver.TableMap.Load(13)
+	c.Assert(ok, IsFalse)
+	c.Assert(loadTestDetail(c, resolver, 11).DDLState, Equals, model.StateWriteReorganization)
+}
+
+func (s *testTiDBSuite) TestTTLLabels(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/schema": `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"},"state":5},
+			{"id":11,"name":{"O":"events","L":"events"},"state":5,
+			"ttl_info":{"column":{"O":"created_at","L":"created_at"},"interval_expr":"3","enable":true,"job_interval":"1h"},
+			"partition":{"enable":true,"definitions":[{"id":12,"name":{"O":"p0","L":"p0"}}]}},
+			{"id":13,"name":{"O":"paused","L":"paused"},"state":5,
+			"ttl_info":{"column":{"O":"created_at","L":"created_at"},"interval_expr":"3","enable":false}}]`,
+	})
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	info, ok := resolver.Resolve(11)
+	c.Assert(ok, IsTrue)
+	c.Assert(info.TTL, IsTrue)
+
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(12, 1))).Labels, DeepEquals, []string{"test", "events/p0", "row_1", ttlLabel})
+	// The tables without TTL, or with TTL disabled, are not tagged.
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(13, 1))).Labels, DeepEquals, []string{"test", "paused", "row_1"})
+}
+
+func (s *testTiDBSuite) TestSequenceLabels(c *C) {
+	resolver := newTestResolver("100", map[string]string{
+		"/schema": `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"},"state":5},
+			{"id":11,"name":{"O":"seq","L":"seq"},"state":5,
+			"sequence":{"sequence_start":1,"sequence_increment":1,"sequence_cache":true}}]`,
+	})
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(loadTestDetail(c, resolver, 10).Kind, Equals, tableKindTable)
+	c.Assert(loadTestDetail(c, resolver, 11).Kind, Equals, tableKindSequence)
+	info, ok := resolver.Resolve(11)
+	c.Assert(ok, IsTrue)
+	c.Assert(info.Kind, Equals, "sequence")
+	c.Assert(resolver.LookupLabel("test.seq"), HasLen, 1)
+
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(11, 1))).Labels, DeepEquals, []string{"test", "seq (sequence)", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+}
+
+// testHTTPStatusAPIClient sends the requests to a real HTTP server through httpc.
+type testHTTPStatusAPIClient struct {
+	Client  *httpc.Client
+	BaseURL string
+}
+
+func (c *testHTTPStatusAPIClient) WithHeader(key, value string) statusAPIClient {
+	return &testHTTPStatusAPIClient{Client: c.Client.CloneAndAddRequestHeader(key, value), BaseURL: c.BaseURL}
+}
+
+func (c *testHTTPStatusAPIClient) WithCheckRedirect(f func(*http.Request, []*http.Request) error) statusAPIClient {
+	return &testHTTPStatusAPIClient{Client: c.Client.WithCheckRedirect(f), BaseURL: c.BaseURL}
+}
+
+func (c *testHTTPStatusAPIClient) Get(ctx context.Context, relativeURI string) (*httpc.Response, error) {
+	return c.Client.Send(ctx, c.BaseURL+relativeURI, http.MethodGet, nil,
+		tidb.ErrTiDBClientRequestFailed, "TiDB")
+}
+
+func (s *testTiDBSuite) TestRequestRetryAfter(c *C) {
+	var schemaRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schema":
+			if atomic.AddInt32(&schemaRequests, 1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`))
+		case "/schema/test":
+			_, _ = w.Write([]byte(`[{"id":10,"name":{"O":"t","L":"t"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+	start := time.Now()
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(time.Since(start) >= time.Second, IsTrue)
+	c.Assert(atomic.LoadInt32(&schemaRequests), Equals, int32(2))
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t")
+}
+
+func (s *testTiDBSuite) TestRedirects(c *C) {
+	var otherRequests int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&otherRequests, 1)
+		_, _ = w.Write([]byte(`[{"id":10,"name":{"O":"t","L":"t"}}]`))
+	}))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schema":
+			http.Redirect(w, r, "/v2/schema", http.StatusFound)
+		case "/v2/schema":
+			_, _ = w.Write([]byte(`[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`))
+		case "/schema/test":
+			http.Redirect(w, r, other.URL+"/schema/test", http.StatusFound)
+		case "/db-table/10":
+			http.Redirect(w, r, "/db-table/10", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+	resolver.TokenProvider = func(bool) (string, error) { return "secret", nil }
+	result := resolver.Sync(context.Background())
+	c.Assert(errorx.IsOfType(result.Err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(result.Err, ErrorMatches, ".*redirect to "+other.URL+"/schema/test is not followed under the same_host redirect policy, after 0 redirects.*")
+	c.Assert(atomic.LoadInt32(&otherRequests), Equals, int32(0))
+
+	_, err := resolver.Revalidate(context.Background(), 10)
+	c.Assert(err, ErrorMatches, ".*redirect to "+ts.URL+"/db-table/10 is not followed .*, after 10 redirects.*")
+
+	resolver.Redirects = RedirectNone
+	resolver.SetSchemaVersion(-1)
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, ErrorMatches, ".*redirect to "+ts.URL+"/v2/schema is not followed under the none redirect policy.*")
+}
+
+func (s *testTiDBSuite) TestSyncTimeout(c *C) {
+	canceled := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schema":
+			_, _ = w.Write([]byte(`[{"id":1,"db_name":{"O":"fast","L":"fast"},"state":5},{"id":2,"db_name":{"O":"slow","L":"slow"},"state":5}]`))
+		case "/schema/fast":
+			_, _ = w.Write([]byte(`[{"id":10,"name":{"O":"t","L":"t"}}]`))
+		case "/schema/slow":
+			<-r.Context().Done()
+			close(canceled)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+	resolver.SyncTimeout = 100 * time.Millisecond
+	start := time.Now()
+	result := resolver.Sync(context.Background())
+	c.Assert(time.Since(start) < 5*time.Second, IsTrue)
+	c.Assert(errorx.IsOfType(result.Err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(result.Err, ErrorMatches, ".*context deadline exceeded.*")
+	c.Assert(result.Version, Equals, int64(-1))
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+	// The databases fetched before the deadline are still applied.
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t")
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the outstanding request is not canceled")
+	}
+}
+
+func (s *testTiDBSuite) TestEndpointTimeouts(c *C) {
+	c.Assert(endpointOf("/schema"), Equals, EndpointSchema)
+	c.Assert(endpointOf("/schema/test"), Equals, EndpointSchemaDB)
+	c.Assert(endpointOf("/db-table/10"), Equals, EndpointDBTable)
+
+	var dbRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schema":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte(`[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`))
+		case "/schema/test":
+			atomic.AddInt32(&dbRequests, 1)
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+	resolver.RequestTimeout = 100 * time.Millisecond
+	resolver.EndpointTimeouts = map[StatusEndpoint]time.Duration{EndpointSchema: time.Minute}
+	result := resolver.Sync(context.Background())
+	c.Assert(errorx.IsOfType(result.Err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(result.Err, ErrorMatches, ".*context deadline exceeded.*")
+	// The slow `/schema` request is given more headroom, so only `/schema/test` reaches its timeout.
+	c.Assert(atomic.LoadInt32(&dbRequests), Equals, int32(1))
+
+	resolver.EndpointTimeouts = nil
+	result = resolver.Sync(context.Background())
+	c.Assert(result.Err, ErrorMatches, ".*context deadline exceeded.*")
+	c.Assert(atomic.LoadInt32(&dbRequests), Equals, int32(1))
+}
+
+func (s *testTiDBSuite) TestClockSkew(c *C) {
+	var ahead int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		date := time.Now().Add(time.Duration(atomic.LoadInt64(&ahead)))
+		w.Header().Set("Date", date.UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	syncSkewed := func(cluster string, threshold time.Duration) []observer.LoggedEntry {
+		core, logs := observer.New(zapcore.WarnLevel)
+		restore := log.ReplaceGlobals(zap.New(core), nil)
+		defer restore()
+		resolver := newTestResolver("100", nil)
+		resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+		resolver.ClockSkewThreshold = threshold
+		resolver.Cluster = cluster
+		resolver.Sync(context.Background())
+		return logs.FilterMessage("the clocks of tidb and the dashboard skew").All()
+	}
+
+	atomic.StoreInt64(&ahead, int64(time.Hour))
+	entries := syncSkewed("skew-east", time.Minute)
+	c.Assert(entries, HasLen, 1)
+	skew := entries[0].ContextMap()["skew"].(time.Duration)
+	c.Assert(skew > 59*time.Minute && skew < 61*time.Minute, IsTrue, Commentf("skew %s", skew))
+	c.Assert(testutil.ToFloat64(clockSkew.WithLabelValues("skew-east")) > 3500, IsTrue)
+
+	// A skew within the threshold is exposed without a warning, and the skews of the clusters are kept apart.
+	atomic.StoreInt64(&ahead, 0)
+	c.Assert(syncSkewed("skew-west", time.Minute), HasLen, 0)
+	c.Assert(testutil.ToFloat64(clockSkew.WithLabelValues("skew-west")) < 2, IsTrue)
+	c.Assert(testutil.ToFloat64(clockSkew.WithLabelValues("skew-east")) > 3500, IsTrue)
+}
+
+func (s *testTiDBSuite) TestColdSyncRetryBackoff(c *C) {
+	resolver := newTestResolver("100", nil)
+	resolver.SyncInterval = time.Minute
+	resolver.ColdSyncRetryInterval = time.Second
+	resolver.ColdSyncRetryMaxInterval = 5 * time.Second
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		c.Assert(resolver.nextSyncDelay(), Equals, delay)
+	}
+	resolver.SetSchemaVersion(100)
+	c.Assert(resolver.nextSyncDelay(), Equals, time.Minute)
+
+	// Without a max interval, the cold sync is retried at a fixed interval.
+	resolver = newTestResolver("100", nil)
+	resolver.ColdSyncRetryInterval = time.Second
+	c.Assert(resolver.nextSyncDelay(), Equals, time.Second)
+	c.Assert(resolver.nextSyncDelay(), Equals, time.Second)
+}
+
+func (s *testTiDBSuite) TestColdSync(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+	resolver.SyncInterval = time.Minute
+	resolver.SyncTimeout = time.Minute
+	resolver.ColdSyncTimeout = 100 * time.Millisecond
+	resolver.ColdSyncRetryInterval = time.Second
+	c.Assert(resolver.nextSyncDelay(), Equals, time.Second)
+	start := time.Now()
+	result := resolver.Sync(context.Background())
+	c.Assert(time.Since(start) < 5*time.Second, IsTrue)
+	c.Assert(result.Err, ErrorMatches, ".*context deadline exceeded.*")
+	select {
+	case <-resolver.ColdSynced():
+		c.Fatal("the cold sync is complete before any version is applied")
+	default:
+	}
+
+	resolver.tidbClient = &testStatusAPIClient{Responses: map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}}
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	select {
+	case <-resolver.ColdSynced():
+	default:
+		c.Fatal("the cold sync is not complete after a version is applied")
+	}
+	c.Assert(resolver.nextSyncDelay(), Equals, time.Minute)
+
+	// Resetting the version does not reopen the signal, and applying one again does not close it twice.
+	resolver.SetSchemaVersion(-1)
+	resolver.SetSchemaVersion(100)
+	<-resolver.ColdSynced()
+}
+
+func (s *testTiDBSuite) TestTableSnapshot(c *C) {
+	resolver := &TableResolver{TableMap: newSyncMapTableStore()}
+	resolver.updateTableMap("db", []*model.TableInfo{newTestTableInfo(30, "c"), newTestTableInfo(10, "a")}, newSyncSummary())
+	tables := resolver.tables()
+	c.Assert(resolver.tables(), Equals, tables)
+	detail, ok := tables.Load(10)
+	c.Assert(ok, IsTrue)
+	c.Assert(detail.Name, Equals, "a")
+	_, ok = tables.Load(20)
+	c.Assert(ok, IsFalse)
+
+	resolver.updateTableMap("db", []*model.TableInfo{newTestTableInfo(20, "b")}, newSyncSummary())
+	c.Assert(resolver.tables(), Not(Equals), tables)
+	_, ok = tables.Load(20)
+	c.Assert(ok, IsFalse)
+	detail, ok = resolver.tables().Load(20)
+	c.Assert(ok, IsTrue)
+	c.Assert(detail.Name, Equals, "b")
+
+	store := newLRUTableStore(10)
+	resolver.TableMap = store
+	c.Assert(resolver.tables(), Equals, evictedTables{r: resolver, store: store})
+}
+
+func (s *testTiDBSuite) TestMaxTableMapEntries(c *C) {
+	resolver := newTestResolver("1", map[string]string{
+		"/schema": `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"a","L":"a"}},{"id":11,"name":{"O":"b","L":"b"}},
+			{"id":12,"name":{"O":"c","L":"c"}}]`,
+		"/db-table/10": `{"db_info":{"id":1,"db_name":{"O":"test","L":"test"},"state":5},
+			"table_info":{"id":10,"name":{"O":"a","L":"a"}}}`,
+	})
+	cfg := DefaultLabelStrategyConfig()
+	cfg.MaxTableMapEntries = 2
+	c.Assert(cfg.Validate(), IsNil)
+	resolver.applyConfig(cfg)
+	_, ok := resolver.TableMap.(*lruTableStore)
+	c.Assert(ok, IsTrue)
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	_, ok = resolver.TableMap.Load(10)
+	c.Assert(ok, IsFalse)
+
+	// The evicted table is labeled by ID on its next lookup, which fetches it again in the background, and a
+	// table never stored is not fetched.
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"table_10", "row_1"})
+	c.Assert(labeler.label(string(model.GenerateRowKey(99, 1))).Labels, DeepEquals, []string{"table_99", "row_1"})
+	for i := 0; ; i++ {
+		if _, ok = resolver.TableMap.Load(10); ok {
+			break
+		}
+		if i == 1000 {
+			c.Fatal("the evicted table is not fetched again")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"test", "a", "row_1"})
+	client := resolver.tidbClient.(*testStatusAPIClient)
+	c.Assert(client.Requests, DeepEquals, []string{"/schema", "/schema/test", "/db-table/10"})
+	c.Assert(resolver.currentConfig().MaxTableMapEntries, Equals, 2)
+
+	// The evicted IDs are bounded by the capacity, and the ones found dropped by a complete sync are forgotten.
+	store := resolver.TableMap.(*lruTableStore)
+	for id := int64(100); id < 110; id++ {
+		store.Store(id, &tableDetail{ID: id})
+	}
+	c.Assert(store.evicted, HasLen, 2)
+	summary := newSyncSummary()
+	summary.seen[108], summary.seen[109] = struct{}{}, struct{}{}
+	summary.pruneDropped(store, time.Now(), 0)
+	c.Assert(store.evicted, HasLen, 0)
+	_, ok = store.Load(109)
+	c.Assert(ok, IsTrue)
+}
+
+func (s *testTiDBSuite) TestLazyFailedPruned(c *C) {
+	var f lazyFetcher
+	f.failed = map[int64]time.Time{1: time.Now().Add(-lazyRetryInterval), 2: time.Now()}
+	f.recordFailure(3)
+	c.Assert(f.failed, HasLen, 2)
+	c.Assert(f.recentlyFailed(2) && f.recentlyFailed(3), IsTrue)
+}
+
+func (s *testTiDBSuite) TestLookupDuringSync(c *C) {
+	responses := func(name string) map[string]string {
+		return map[string]string{
+			"/schema":   `[{"id":1,"db_name":{"O":"a","L":"a"},"state":5},{"id":2,"db_name":{"O":"b","L":"b"},"state":5}]`,
+			"/schema/a": fmt.Sprintf(`[{"id":10,"name":{"O":%q,"L":%q}}]`, name, name),
+			"/schema/b": fmt.Sprintf(`[{"id":20,"name":{"O":%q,"L":%q}}]`, name, name),
+		}
+	}
+	resolver := newTestResolver("100", responses("old"))
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+
+	// The second sync stalls after applying database a, until released.
+	applying := make(chan struct{})
+	release := make(chan struct{})
+	resolver.NormalizeName = func(db, table string) (string, string) {
+		if db == "b" && table == "new" {
+			close(applying)
+			<-release
+		}
+		return db, table
+	}
+	resolver.EtcdClient = &testEtcdKV{SchemaVersion: "101"}
+	resolver.tidbClient = &testStatusAPIClient{Responses: responses("new")}
+	done := make(chan SyncResult)
+	go func() {
+		done <- resolver.Sync(context.Background())
+	}()
+	<-applying
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				for _, id := range []int64{10, 20} {
+					detail, ok := resolver.tables().Load(id)
+					c.Check(ok && detail.Name == "old", IsTrue)
+					info, ok := resolver.Resolve(id)
+					c.Check(ok && info.Name == "old", IsTrue)
+				}
+				c.Check(resolver.LookupLabel("a.old"), HasLen, 1)
+				c.Check(resolver.LookupLabel("a.new"), HasLen, 0)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	c.Assert((<-done).Err, IsNil)
+
+	for _, id := range []int64{10, 20} {
+		info, ok := resolver.Resolve(id)
+		c.Assert(ok, IsTrue)
+		c.Assert(info.Name, Equals, "new")
+	}
+	c.Assert(resolver.LookupLabel("b.new"), HasLen, 1)
+}
+
+func (s *testTiDBSuite) TestTokenProvider(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/schema":
+			_, _ = w.Write([]byte(`[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`))
+		case "/schema/test":
+			_, _ = w.Write([]byte(`[{"id":10,"name":{"O":"t","L":"t"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	fetched := 0
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+	resolver.TokenProvider = func(refresh bool) (string, error) {
+		if refresh || fetched == 0 {
+			fetched++
+		}
+		return fmt.Sprintf("token-%d", fetched), nil
+	}
+	c.Assert(resolver.Sync(context.Background()).Err, IsNil)
+	c.Assert(fetched, Equals, 2)
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t")
+
+	// A token rejected even after refreshing fails the request.
+	resolver = newTestResolver("100", nil)
+	resolver.tidbClient = &testHTTPStatusAPIClient{Client: &httpc.Client{}, BaseURL: ts.URL}
+	resolver.TokenProvider = func(bool) (string, error) {
+		return "bad", nil
+	}
+	err := resolver.Sync(context.Background()).Err
+	c.Assert(errorx.IsOfType(err, ErrTiDBUnavailable), IsTrue)
+	c.Assert(httpc.StatusCodeOf(err), Equals, http.StatusUnauthorized)
+}
+
+func (s *testTiDBSuite) TestResyncAfterMisses(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}
+	resolver := newTestResolver("100", responses)
+	resolver.ResyncMissThreshold = 2
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(100))
+
+	// A table created without bumping the schema version seen by the dashboard.
+	responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}},{"id":11,"name":{"O":"t2","L":"t2"}}]`
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder(), OnMiss: resolver.recordMiss}
+	labeler.label(string(model.GenerateRowKey(11, 1)))
+	labeler.label(string(model.GenerateRowKey(11, 2)))
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(-1))
+
+	labeler.label(string(model.GenerateRowKey(12, 1)))
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Version, Equals, int64(100))
+	c.Assert(result.Added, Equals, 1)
+	c.Assert(loadTestDetail(c, resolver, 11).Name, Equals, "t2")
+	c.Assert(resolver.shouldForceResync(), IsFalse)
+}
+
+func (s *testTiDBSuite) TestResyncAfterStableVersion(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}
+	resolver := newTestResolver("100", responses)
+	resolver.MaxVersionStableInterval = time.Hour
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(100))
+
+	// A table created without bumping the schema version is only seen once the version is stable for too long.
+	responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}},{"id":11,"name":{"O":"t2","L":"t2"}}]`
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(-1))
+	resolver.versionAppliedAt.Store(time.Now().Add(-time.Hour))
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Version, Equals, int64(100))
+	c.Assert(result.Added, Equals, 1)
+
+	// The full sync restarts the timer.
+	c.Assert(resolver.versionStableTooLong(), IsFalse)
+	c.Assert(resolver.Sync(context.Background()).Version, Equals, int64(-1))
+
+	// A preloaded version is not synced again until the interval passes.
+	preloaded := newTestResolver("100", responses)
+	preloaded.MaxVersionStableInterval = time.Hour
+	preloaded.Preload([]TableInfo{{ID: 10, DB: "test", Name: "t"}}, 100)
+	c.Assert(preloaded.Sync(context.Background()).Version, Equals, int64(-1))
+}
+
+func (s *testTiDBSuite) TestMetaKeyLabels(c *C) {
+	tableMap := newSyncMapTableStore()
+	tableMap.Store(10, &tableDetail{ID: 10, DB: "test", Name: "t"})
+	labeler := &tidbLabeler{TableMap: tableMap, Decoder: NewTiDBKeyDecoder()}
+
+	// The meta keys encoded as TiDB does: `m`, the memcomparable key name, the type flag, and the rest.
+	testcases := []struct {
+		Raw    string
+		Labels []string
+	}{
+		{"mDDLJobLi\xffst\x00\x00\x00\x00\x00\x00\xf9\x00\x00\x00\x00\x00\x00\x00l\x80\x00\x00\x00\x00\x00\x00\x01", []string{"meta", "DDL job queue"}},
+		{"mDDLJobAd\xffdIdxList\xff\x00\x00\x00\x00\x00\x00\x00\x00\xf7\x00\x00\x00\x00\x00\x00\x00l", []string{"meta", "DDL job queue"}},
+		{"mDDLJobHi\xffstory\x00\x00\x00\xfc\x00\x00\x00\x00\x00\x00\x00h", []string{"meta", "DDL job history"}},
+		{"mDDLJobRe\xfforg\x00\x00\x00\x00\x00\xfa\x00\x00\x00\x00\x00\x00\x00h", []string{"meta", "DDL reorg"}},
+		{"mNextGlob\xffalID\x00\x00\x00\x00\xfb\x00\x00\x00\x00\x00\x00\x00s", []string{"meta"}},
+		// `DBs` is a hash of the database infos keyed by `DB:<database ID>`.
+		{"mDBs\x00\x00\x00\x00\x00\xfa\x00\x00\x00\x00\x00\x00\x00hDB:2\x00\x00\x00\x00\xfb", []string{"meta", "database info"}},
+		// `DB:<database ID>` is a hash of the table infos keyed by `Table:<table ID>`.
+		{"mDB:2\x00\x00\x00\x00\xfb\x00\x00\x00\x00\x00\x00\x00hTable:10\xff0\x00\x00\x00\x00\x00\x00\x00\xf8", []string{"meta", "table info"}},
+		{"mDB:10000\xff\x00\x00\x00\x00\x00\x00\x00\x00\xf7\x00\x00\x00\x00\x00\x00\x00h", []string{"meta", "table info"}},
+		{"mDB:abc\x00\x00\x00\x00\x00\xfa\x00\x00\x00\x00\x00\x00\x00h", []string{"meta"}},
+		// The auto ID allocators of the tables are the fields `<prefix>:<table ID>` of the same hash.
+		{"mDB:2\x00\x00\x00\x00\xfb\x00\x00\x00\x00\x00\x00\x00hTID:10\x00\x00\xfd", []string{"meta", "auto-id allocator", "test", "t"}},
+		{"mDB:2\x00\x00\x00\x00\xfb\x00\x00\x00\x00\x00\x00\x00hTARID:10\xff\x00\x00\x00\x00\x00\x00\x00\x00\xf7", []string{"meta", "auto-random allocator", "test", "t"}},
+		{"mDB:2\x00\x00\x00\x00\xfb\x00\x00\x00\x00\x00\x00\x00hIID:12\x00\x00\xfd", []string{"meta", "auto-increment allocator", "table_12"}},
+		{"mDB:2\x00\x00\x00\x00\xfb\x00\x00\x00\x00\x00\x00\x00hSID:10\x00\x00\xfd", []string{"meta", "sequence allocator", "test", "t"}},
+		{"mDB:2\x00\x00\x00\x00\xfb\x00\x00\x00\x00\x00\x00\x00hXID:10\x00\x00\xfd", []string{"meta", "table info"}},
+		{"mDDLJob", []string{"meta"}},
+	}
+	for _, t := range testcases {
+		c.Assert(labeler.label(string(model.EncodeKey([]byte(t.Raw)))).Labels, DeepEquals, t.Labels, Commentf("%q", t.Raw))
+	}
+}
+
+// testSnapshotStore keeps the snapshot in memory.
+type testSnapshotStore struct {
+	mu       sync.Mutex
+	snapshot []byte
+	// Stores is notified on each Store if not nil.
+	Stores chan struct{}
+}
+
+func (s *testSnapshotStore) Load(context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot, nil
+}
+
+func (s *testSnapshotStore) Store(_ context.Context, snapshot []byte) error {
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.mu.Unlock()
+	if s.Stores != nil {
+		s.Stores <- struct{}{}
+	}
+	return nil
+}
+
+func (s *testTiDBSuite) TestSnapshotStore(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"id":1,"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"},"index_info":[{"id":1,"idx_name":{"O":"idx","L":"idx"}}]}]`,
+	}
+	store := &testSnapshotStore{Stores: make(chan struct{}, 16)}
+	resolver := newTestResolver("100", responses)
+	resolver.SyncInterval = time.Millisecond
+	resolver.SnapshotStore = store
+	ctx, cancel := context.WithCancel(context.Background())
+	go resolver.Run(ctx)
+	select {
+	case <-store.Stores:
+	case <-time.After(10 * time.Second):
+		c.Fatal("the snapshot is not stored after the sync")
+	}
+	cancel()
+
+	// A new resolver labels the keys with the stored tables before its first sync.
+	restarted := newTestResolver("100", nil)
+	restarted.SnapshotStore = store
+	restarted.SyncInterval = time.Hour
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	restarted.Run(ctx)
+	c.Assert(restarted.SchemaVersion(), Equals, int64(100))
+	labeler := &tidbLabeler{TableMap: restarted.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateIndexKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "idx"})
+	// The first sync fetches the tables only once the version in etcd has changed.
+	c.Assert(restarted.Sync(context.Background()).Path, Equals, "")
+	restarted.EtcdClient.(*testEtcdKV).SchemaVersion = "101"
+	c.Assert(restarted.Sync(context.Background()).Path, Equals, syncPathSchema)
+
+	// A corrupt snapshot leaves the resolver cold.
+	for _, data := range [][]byte{
+		{},
+		{persistFormatV2},
+		// A snapshot stored before the schema version is persisted.
+		encodeTableSnapshot(restarted.TableMap),
+		// A snapshot stored before the cluster and the fingerprint are persisted.
+		append([]byte{1, 200, 1}, encodeTableSnapshot(restarted.TableMap)...),
+		encodePersistedSnapshot(persistedSnapshot{schemaVersion: 100, snapshot: []byte{snapshotFormatV6, 1}}),
+		// A snapshot of another cluster.
+		encodePersistedSnapshot(persistedSnapshot{
+			cluster:       "other",
+			fingerprint:   restarted.snapshotFingerprint(),
+			schemaVersion: 100,
+			snapshot:      encodeTableSnapshot(restarted.TableMap),
+		}),
+	} {
+		cold := newTestResolver("100", nil)
+		cold.SnapshotStore = &testSnapshotStore{snapshot: data}
+		cold.Run(ctx)
+		c.Assert(cold.SchemaVersion(), Equals, int64(-1))
+		_, ok := cold.TableMap.Load(10)
+		c.Assert(ok, IsFalse)
+	}
+
+	// A snapshot persisted under other tunables labels the keys, but the first sync fetches the tables again.
+	stale := newTestResolver("100", responses)
+	stale.SnapshotStore = store
+	stale.SyncInterval = time.Hour
+	stale.SkipPartitions = true
+	stale.Run(ctx)
+	c.Assert(stale.SchemaVersion(), Equals, int64(-1))
+	_, ok := stale.TableMap.Load(10)
+	c.Assert(ok, IsTrue)
+	c.Assert(stale.Sync(context.Background()).Path, Equals, syncPathSchema)
+
+	// A preloaded resolver keeps its tables.
+	preloaded := newTestResolver("100", nil)
+	preloaded.SnapshotStore = store
+	preloaded.SyncInterval = time.Hour
+	preloaded.Preload([]TableInfo{{ID: 20, DB: "test", Name: "t2"}}, -1)
+	preloaded.Run(ctx)
+	_, ok = preloaded.TableMap.Load(10)
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testTiDBSuite) TestForceResync(c *C) {
+	responses := map[string]string{
+		"/schema":      `[{"db_name":{"O":"test","L":"test"},"state":5}]`,
+		"/schema/test": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}
+	resolver := newTestResolver("1", responses)
+	c.Assert(resolver.Sync(context.Background()).Path, Equals, syncPathSchema)
+	c.Assert(resolver.Sync(context.Background()).Path, Equals, "")
+
+	// TableMap is repaired by a forced resync of the same version.
+	resolver.TableMap.Store(10, &tableDetail{ID: 10, DB: "test", Name: "wrong"})
+	resolver.ForceResync()
+	result := resolver.Sync(context.Background())
+	c.Assert(result.Err, IsNil)
+	c.Assert(result.Changed, Equals, 1)
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t")
+	c.Assert(resolver.Sync(context.Background()).Path, Equals, "")
+
+	// A forced resync failing half way is retried.
+	delete(responses, "/schema/test")
+	resolver.ForceResync()
+	c.Assert(resolver.Sync(context.Background()).Err, NotNil)
+	responses["/schema/test"] = `[{"id":10,"name":{"O":"t","L":"t"}}]`
+	c.Assert(resolver.Sync(context.Background()).Path, Equals, syncPathSchema)
+	c.Assert(resolver.Sync(context.Background()).Path, Equals, "")
+
+	// Run syncs at once.
+	kv := &testEtcdKV{SchemaVersion: "1", Gets: make(chan struct{}, 16)}
+	resolver.EtcdClient = kv
+	resolver.SyncInterval = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go resolver.Run(ctx)
+	resolver.ForceResync()
+	select {
+	case <-kv.Gets:
+	case <-time.After(10 * time.Second):
+		c.Fatal("sync is not triggered by ForceResync")
+	}
+}
+
+func (s *testTiDBSuite) TestPauseResume(c *C) {
+	kv := &testEtcdKV{SchemaVersion: "100", Gets: make(chan struct{}, 16)}
+	resolver := &TableResolver{
+		EtcdClient:   kv,
+		tidbClient:   &testStatusAPIClient{},
+		TableMap:     newSyncMapTableStore(),
+		SyncInterval: time.Millisecond,
+	}
+	resolver.Preload([]TableInfo{{ID: 10, DB: "test", Name: "t"}}, 100)
+	resolver.Pause()
+	c.Assert(resolver.Paused(), IsTrue)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go resolver.Run(ctx)
+
+	select {
+	case <-kv.Gets:
+		c.Fatal("a paused resolver syncs")
+	case <-time.After(50 * time.Millisecond):
+	}
+	labeler := &tidbLabeler{TableMap: resolver.tables(), Decoder: NewTiDBKeyDecoder()}
+	c.Assert(labeler.label(string(model.GenerateRowKey(10, 1))).Labels, DeepEquals, []string{"test", "t", "row_1"})
+
+	resolver.Resume()
+	c.Assert(resolver.Paused(), IsFalse)
+	select {
+	case <-kv.Gets:
+	case <-time.After(10 * time.Second):
+		c.Fatal("a resumed resolver does not sync")
+	}
+	// The version kept since the pause is unchanged, so the tables are not fetched again.
+	c.Assert(resolver.SchemaVersion(), Equals, int64(100))
+	c.Assert(loadTestDetail(c, resolver, 10).Name, Equals, "t")
+
+	// A paused lazy resolver fetches no table.
+	client := &testStatusAPIClient{}
+	lazy := newTestResolver("100", nil)
+	lazy.tidbClient = client
+	lazy.Lazy = true
+	lazy.Pause()
+	_, ok := lazy.Resolve(20)
+	c.Assert(ok, IsFalse)
+	c.Assert(client.Requests, HasLen, 0)
+	lazy.Resume()
+	_, ok = lazy.Resolve(20)
+	c.Assert(ok, IsFalse)
+	c.Assert(client.Requests, DeepEquals, []string{"/db-table/20"})
+}
+
+func (s *testTiDBSuite) TestColdSyncDeadline(c *C) {
+	client := &testStatusAPIClient{Responses: map[string]string{
+		"/schema":   `[{"id":1,"db_name":{"O":"a","L":"a"},"state":5},{"id":2,"db_name":{"O":"b","L":"b"},"state":5}]`,
+		"/schema/a": `[{"id":10,"name":{"O":"t","L":"t"}}]`,
+	}}
+	resolver := newTestResolver("100", nil)
+	resolver.tidbClient = client
+	resolver.SyncInterval = time.Millisecond
+	resolver.ColdSyncDeadline = 20 * time.Millisecond
+	strategy := &tidbLabelStrategy{TableResolver: resolver, NewKeyDecoder: NewTiDBKeyDecoder}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go resolver.Run(ctx)
+
+	// The syncs fail on database b, so the deadline passes with the tables of database a applied only.
+	select {
+	case <-resolver.ColdSynced():
+	case <-time.After(10 * time.Second):
+		c.Fatal("the deadline does not satisfy ColdSynced")
+	}
+	c.Assert(resolver.Partial(), IsTrue)
+	c.Assert(resolver.SchemaVersion(), Equals, int64(-1))
+	c.Assert(strategy.NewLabeler().Label([]string{string(model.GenerateRowKey(10, 1))})[0].Labels, DeepEquals,
+		[]string{"a", "t", "row_1", partialLabel})
+
+	client.mu.Lock()
+	client.Responses["/schema/b"] = `[{"id":20,"name":{"O":"t2","L":"t2"}}]`
+	client.mu.Unlock()
+	for start := time.Now(); resolver.Partial(); time.Sleep(time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			c.Fatal("a successful sync does not end the partial serving")
+		}
+	}
+	c.Assert(resolver.SchemaVersion(), Equals, int64(100))
+	c.Assert(strategy.NewLabeler().Label([]string{string(model.GenerateRowKey(10, 1))})[0].Labels, DeepEquals,
+		[]string{"a", "t", "row_1"})
+}
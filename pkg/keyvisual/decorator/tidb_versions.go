@@ -0,0 +1,84 @@
+// Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sort"
+	"sync"
+)
+
+// versionedSnapshot is the snapshot of TableMap as of a schema version.
+type versionedSnapshot struct {
+	version  int64
+	snapshot *tableSnapshot
+}
+
+// versionedSnapshots retains the snapshots of the recent schema versions, sorted by version.
+type versionedSnapshots struct {
+	mu        sync.Mutex
+	snapshots []versionedSnapshot
+}
+
+// add retains the snapshot of a version, replacing the one of the same version, and drops the oldest
+// versions beyond capacity.
+func (v *versionedSnapshots) add(version int64, snapshot *tableSnapshot, capacity int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	i := sort.Search(len(v.snapshots), func(i int) bool {
+		return v.snapshots[i].version >= version
+	})
+	if i < len(v.snapshots) && v.snapshots[i].version == version {
+		v.snapshots[i].snapshot = snapshot
+		return
+	}
+	v.snapshots = append(v.snapshots, versionedSnapshot{})
+	copy(v.snapshots[i+1:], v.snapshots[i:])
+	v.snapshots[i] = versionedSnapshot{version: version, snapshot: snapshot}
+	if extra := len(v.snapshots) - capacity; extra > 0 {
+		v.snapshots = append([]versionedSnapshot(nil), v.snapshots[extra:]...)
+	}
+}
+
+// closest returns the snapshot of the latest version not after version.
+func (v *versionedSnapshots) closest(version int64) (versionedSnapshot, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	i := sort.Search(len(v.snapshots), func(i int) bool {
+		return v.snapshots[i].version > version
+	})
+	if i == 0 {
+		return versionedSnapshot{}, false
+	}
+	return v.snapshots[i-1], true
+}
+
+// retainVersion retains the tables of a schema version just applied to TableMap, with applyMu held for
+// writing. Under a bounded TableMap, only the tables not evicted are retained.
+func (r *TableResolver) retainVersion(version int64) {
+	if r.RetainedVersions <= 0 {
+		return
+	}
+	gen := r.tableMapGen.Load()
+	snapshot, ok := r.snapshotCache.Load().(*tableSnapshot)
+	if !ok || snapshot.gen != gen {
+		snapshot = newTableSnapshot(r.TableMap, gen)
+	}
+	r.versions.add(version, snapshot, r.RetainedVersions)
+}
+
+// ResolveAt is like Resolve, but resolves the table as of a past schema version, by the tables of the latest
+// retained version not after it, see RetainedVersions. It also returns the version resolved by, and false if
+// no such version is retained or the table is not in it.
+func (r *TableResolver) ResolveAt(version int64, id int64) (TableInfo, int64, bool) {
+	retained, ok := r.versions.closest(version)
+	if !ok {
+		return TableInfo{}, -1, false
+	}
+	detail, ok := retained.snapshot.Load(id)
+	if !ok {
+		return TableInfo{}, retained.version, false
+	}
+	info := detail.toTableInfo()
+	info.Cluster = r.Cluster
+	return info, retained.version, true
+}
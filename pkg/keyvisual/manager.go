@@ -3,6 +3,7 @@
 package keyvisual
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"sync"
@@ -13,6 +14,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/decorator"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
@@ -53,7 +55,9 @@ func (s *Service) managerLoop(ctx context.Context) {
 
 func (s *Service) resetKeyVisualConfig(ctx context.Context, cfg *config.DynamicConfig) {
 	if !cfg.KeyVisual.AutoCollectionDisabled {
-		if s.keyVisualCfg != nil && s.keyVisualCfg.Policy != cfg.KeyVisual.Policy {
+		// The label strategy is created with its tunables when the service starts.
+		if s.keyVisualCfg != nil && (s.keyVisualCfg.Policy != cfg.KeyVisual.Policy ||
+			!bytes.Equal(s.keyVisualCfg.LabelStrategy, cfg.KeyVisual.LabelStrategy)) {
 			s.stopService()
 		}
 		s.reloadKeyVisualConfig(&cfg.KeyVisual)
@@ -115,7 +119,15 @@ func (s *Service) setDynamicConfig(c *gin.Context) {
 		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
 		return
 	}
+	if _, err := decorator.ParseLabelStrategyConfig(req.LabelStrategy); err != nil {
+		rest.Error(c, rest.ErrBadRequest.Wrap(err, "invalid label_strategy"))
+		return
+	}
 	var opt config.DynamicConfigOption = func(dc *config.DynamicConfig) {
+		// A client unaware of label_strategy keeps the current one.
+		if req.LabelStrategy == nil {
+			req.LabelStrategy = dc.KeyVisual.LabelStrategy
+		}
 		dc.KeyVisual = req
 	}
 	if err := s.cfgManager.Modify(opt); err != nil {
@@ -19,6 +19,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
+	apiutils "github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
 	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/decorator"
@@ -111,6 +112,20 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 
 	endpoint.Use(s.status.MWHandleStopped(stoppedHandler))
 	endpoint.GET("/heatmaps", s.heatmaps)
+	endpoint.GET("/decorator/status", s.getDecoratorStatus)
+	endpoint.POST("/decorator/pause", auth.MWRequireWritePriv(), s.pauseDecorator)
+	endpoint.POST("/decorator/resume", auth.MWRequireWritePriv(), s.resumeDecorator)
+	endpoint.GET("/decorator/tables", s.lookupDecoratorTables)
+	endpoint.GET("/decorator/hot_tables", s.getDecoratorHotTables)
+	endpoint.GET("/decorator/support_bundle", s.getDecoratorSupportBundle)
+	endpoint.GET("/decorator/changes", s.streamDecoratorChanges)
+	endpoint.GET("/decorator/snapshot", s.getDecoratorSnapshot)
+	endpoint.POST("/decorator/snapshot/diff", s.diffDecoratorSnapshot)
+	endpoint.GET("/decorator/tables/:id/partitions", s.resolveDecoratorPartitions)
+	endpoint.GET("/decorator/tables/:id/self_test", s.selfTestDecorator)
+	endpoint.POST("/decorator/resolve", s.resolveDecoratorTables)
+	endpoint.DELETE("/decorator/tables/:id", auth.MWRequireWritePriv(), s.dropDecoratorTable)
+	endpoint.GET("/decorator/sql_check", apiutils.MWConnectTiDB(s.tidbClient), s.checkDecoratorAgainstSQL)
 }
 
 func (s *Service) IsRunning() bool {
@@ -155,15 +170,25 @@ func (s *Service) newLabelStrategy(
 	wg *sync.WaitGroup,
 	etcdClient *clientv3.Client,
 	tidbClient *tidb.Client,
-) decorator.LabelStrategy {
+	pdClient *pd.Client,
+	db *dbstore.DB,
+) (decorator.LabelStrategy, error) {
 	switch s.keyVisualCfg.Policy {
 	case config.KeyVisualDBPolicy:
 		log.Debug("New LabelStrategy", zap.String("policy", s.keyVisualCfg.Policy))
-		return decorator.TiDBLabelStrategy(lc, wg, etcdClient, tidbClient)
+		snapshotStore, err := decorator.NewDBSnapshotStore(db)
+		if err != nil {
+			log.Warn("Failed to prepare the snapshot store of the TiDB tables", zap.Error(err))
+		}
+		cfg, err := decorator.ParseLabelStrategyConfig(s.keyVisualCfg.LabelStrategy)
+		if err != nil {
+			return nil, err
+		}
+		return decorator.TiDBLabelStrategy(lc, wg, cfg, etcdClient, tidbClient, pdClient, snapshotStore)
 	case config.KeyVisualKVPolicy:
 		log.Debug("New LabelStrategy", zap.String("policy", s.keyVisualCfg.Policy),
 			zap.String("separator", s.keyVisualCfg.PolicyKVSeparator))
-		return decorator.SeparatorLabelStrategy(s.keyVisualCfg)
+		return decorator.SeparatorLabelStrategy(s.keyVisualCfg), nil
 	default:
 		panic("unreachable")
 	}
@@ -102,6 +102,18 @@ func (c Client) WithEnforcedStatusAPIAddress(host string, statusPort int) *Clien
 	return &c
 }
 
+// WithStatusAPIHeader returns a client sending the header with each status API request.
+func (c Client) WithStatusAPIHeader(key, value string) *Client {
+	c.statusAPIHTTPClient = c.statusAPIHTTPClient.CloneAndAddRequestHeader(key, value)
+	return &c
+}
+
+// WithStatusAPICheckRedirect returns a client deciding by f whether to follow a redirect of the status API.
+func (c Client) WithStatusAPICheckRedirect(f func(req *http.Request, via []*http.Request) error) *Client {
+	c.statusAPIHTTPClient = c.statusAPIHTTPClient.WithCheckRedirect(f)
+	return &c
+}
+
 func (c Client) WithSQLAPIAddress(host string, sqlPort int) *Client {
 	c.sqlAPIAddress = net.JoinHostPort(host, strconv.Itoa(sqlPort))
 	return &c
@@ -170,6 +182,11 @@ func (c *Client) OpenSQLConn(user string, pass string) (*gorm.DB, error) {
 }
 
 func (c *Client) Get(relativeURI string) (*httpc.Response, error) {
+	return c.GetWithContext(c.lifecycleCtx, relativeURI)
+}
+
+// GetWithContext is like Get, but the request is canceled when ctx is done instead of when the client stops.
+func (c *Client) GetWithContext(ctx context.Context, relativeURI string) (*httpc.Response, error) {
 	var err error
 
 	overrideEndpoint := os.Getenv(tidbOverrideStatusEndpointEnvVar)
@@ -197,7 +214,7 @@ func (c *Client) Get(relativeURI string) (*httpc.Response, error) {
 	uri := fmt.Sprintf("%s://%s%s", c.statusAPIHTTPScheme, addr, relativeURI)
 	res, err := c.statusAPIHTTPClient.
 		WithTimeout(c.statusAPITimeout).
-		Send(c.lifecycleCtx, uri, http.MethodGet, nil, ErrTiDBClientRequestFailed, distro.R().TiDB)
+		Send(ctx, uri, http.MethodGet, nil, ErrTiDBClientRequestFailed, distro.R().TiDB)
 	if err != nil && c.forwarder.statusProxy.noAliveRemote.Load() {
 		return nil, ErrNoAliveTiDB.NewWithNoMessage()
 	}
@@ -5,6 +5,8 @@ package model
 import (
 	"bytes"
 	"encoding/binary"
+	"math"
+	"strconv"
 
 	"github.com/pingcap/errors"
 )
@@ -13,6 +15,9 @@ var (
 	tablePrefix  = []byte{'t'}
 	metaPrefix   = []byte{'m'}
 	recordPrefix = []byte{'r'}
+
+	recordPrefixSep = []byte("_r")
+	indexPrefixSep  = []byte("_i")
 )
 
 const (
@@ -57,6 +62,38 @@ func (buf KeyInfoBuffer) MetaOrTable() (isMeta bool, tableID int64) {
 	return false, 0
 }
 
+// MetaKeyName returns the name of a meta key, e.g. `DDLJobList`, which is encoded right after the meta prefix.
+func (buf KeyInfoBuffer) MetaKeyName() (string, bool) {
+	if !bytes.HasPrefix(buf, metaPrefix) {
+		return "", false
+	}
+	_, name, err := decodeBytes(buf[len(metaPrefix):], nil)
+	if err != nil {
+		return "", false
+	}
+	return string(name), true
+}
+
+// metaHashFlag is the type flag of a hash meta key, which is followed by the memcomparable encoded field.
+const metaHashFlag = 'h'
+
+// MetaKeyField returns the field of a hash meta key, e.g. `TID:10` of `DB:2`, which is encoded after the name
+// and the type flag.
+func (buf KeyInfoBuffer) MetaKeyField() (string, bool) {
+	if !bytes.HasPrefix(buf, metaPrefix) {
+		return "", false
+	}
+	rest, _, err := decodeBytes(buf[len(metaPrefix):], nil)
+	if err != nil || len(rest) < 8 || binary.BigEndian.Uint64(rest[:8]) != metaHashFlag {
+		return "", false
+	}
+	_, field, err := decodeBytes(rest[8:], nil)
+	if err != nil {
+		return "", false
+	}
+	return string(field), true
+}
+
 // RowInfo returns the row ID of the key, if the key is not table key, returns 0.
 func (buf KeyInfoBuffer) RowInfo() (isCommonHandle bool, rowID int64) {
 	if !bytes.HasPrefix(buf, tablePrefix) || len(buf) < 19 || !(buf[9] == '_' && buf[10] == 'r') {
@@ -69,6 +106,15 @@ func (buf KeyInfoBuffer) RowInfo() (isCommonHandle bool, rowID int64) {
 	return
 }
 
+// CommonHandle returns the encoded handle of a row key, which is a common handle if the table is clustered
+// by a primary key other than a single integer column. It returns false if the key is not a row key.
+func (buf KeyInfoBuffer) CommonHandle() ([]byte, bool) {
+	if !bytes.HasPrefix(buf, tablePrefix) || len(buf) < 11 || !(buf[9] == '_' && buf[10] == 'r') {
+		return nil, false
+	}
+	return buf[11:], true
+}
+
 // IndexInfo returns the row ID of the key, if the key is not table key, returns 0.
 func (buf KeyInfoBuffer) IndexInfo() (indexID int64) {
 	if !bytes.HasPrefix(buf, tablePrefix) || len(buf) < 19 || !(buf[9] == '_' && buf[10] == 'i') {
@@ -78,6 +124,22 @@ func (buf KeyInfoBuffer) IndexInfo() (indexID int64) {
 	return
 }
 
+// IndexPrefixInfo is like IndexInfo, but also accepts a key that ends inside the index ID, as region split
+// keys may do. The missing bytes of the index ID are taken as zero and isTruncated is true.
+func (buf KeyInfoBuffer) IndexPrefixInfo() (indexID int64, isTruncated bool) {
+	if !bytes.HasPrefix(buf, tablePrefix) || len(buf) < 11 || !(buf[9] == '_' && buf[10] == 'i') {
+		return
+	}
+	if len(buf) >= 19 {
+		_, indexID, _ = decodeInt(buf[11:19])
+		return indexID, false
+	}
+	var id [8]byte
+	copy(id[:], buf[11:])
+	_, indexID, _ = decodeInt(id[:])
+	return indexID, true
+}
+
 // GenerateTableKey generates a table split key.
 func (buf *KeyInfoBuffer) GenerateKey(tableID, rowID int64) Key {
 	if tableID == 0 {
@@ -107,6 +169,45 @@ func (buf *KeyInfoBuffer) GenerateKey(tableID, rowID int64) Key {
 	return encodeBytes(data)
 }
 
+// GenerateRowKey generates the key of a row identified by an integer handle.
+func GenerateRowKey(tableID, rowID int64) Key {
+	data := make([]byte, 0, len(tablePrefix)+len(recordPrefixSep)+8*2)
+	data = append(data, tablePrefix...)
+	data = encodeInt(data, tableID)
+	data = append(data, recordPrefixSep...)
+	data = encodeInt(data, rowID)
+	return encodeBytes(data)
+}
+
+// GenerateIndexKey generates the start key of an index.
+func GenerateIndexKey(tableID, indexID int64) Key {
+	data := make([]byte, 0, len(tablePrefix)+len(indexPrefixSep)+8*2)
+	data = append(data, tablePrefix...)
+	data = encodeInt(data, tableID)
+	data = append(data, indexPrefixSep...)
+	data = encodeInt(data, indexID)
+	return encodeBytes(data)
+}
+
+// GenerateRawTableKey returns the raw key made of the table prefix of tableID followed by relativeKey, like
+// the result of DecodeKey.
+func GenerateRawTableKey(tableID int64, relativeKey []byte) KeyInfoBuffer {
+	data := make([]byte, 0, len(tablePrefix)+8+len(relativeKey))
+	data = append(data, tablePrefix...)
+	data = encodeInt(data, tableID)
+	return append(data, relativeKey...)
+}
+
+// EncodeIntHandle encodes an integer handle as it follows `_r` in a row key.
+func EncodeIntHandle(rowID int64) []byte {
+	return encodeInt(nil, rowID)
+}
+
+// EncodeKey encodes a raw TiDB key into the memcomparable form used by region keys.
+func EncodeKey(raw []byte) Key {
+	return encodeBytes(raw)
+}
+
 var pads = make([]byte, encGroupSize)
 
 // decodeBytes decodes bytes which is encoded by encodeBytes before,
@@ -217,3 +318,69 @@ func decodeCmpUintToInt(u uint64) int64 {
 func encodeIntToCmpUint(v int64) uint64 {
 	return uint64(v) ^ signMask
 }
+
+// The flags of the memcomparable encoded datums, as encoded by the codec of TiDB. The flags of the compact
+// bytes and the decimals are not decoded.
+const (
+	nilFlag      byte = 0
+	bytesFlag    byte = 1
+	intFlag      byte = 3
+	uintFlag     byte = 4
+	floatFlag    byte = 5
+	durationFlag byte = 7
+	maxFlag      byte = 250
+)
+
+// DecodeHandleDatums decodes the column values of a common handle, each rendered as a string, e.g. `1` or
+// `abc`. The values are decoded in order until the handle ends, or a value can not be decoded, e.g. a decimal
+// or a value truncated by a region split key, in which case complete is false and the values before it are
+// returned. A datetime is rendered as its packed integer, and a duration as its nanoseconds.
+func DecodeHandleDatums(handle []byte) (values []string, complete bool) {
+	for len(handle) > 0 {
+		flag := handle[0]
+		b := handle[1:]
+		var value string
+		switch flag {
+		case nilFlag:
+			value = "NULL"
+		case bytesFlag:
+			var data []byte
+			var err error
+			if b, data, err = decodeBytes(b, nil); err != nil {
+				return values, false
+			}
+			value = string(data)
+		case intFlag, durationFlag:
+			if len(b) < 8 {
+				return values, false
+			}
+			value = strconv.FormatInt(decodeCmpUintToInt(binary.BigEndian.Uint64(b)), 10)
+			b = b[8:]
+		case uintFlag:
+			if len(b) < 8 {
+				return values, false
+			}
+			value = strconv.FormatUint(binary.BigEndian.Uint64(b), 10)
+			b = b[8:]
+		case floatFlag:
+			if len(b) < 8 {
+				return values, false
+			}
+			u := binary.BigEndian.Uint64(b)
+			if u&signMask > 0 {
+				u &^= signMask
+			} else {
+				u = ^u
+			}
+			value = strconv.FormatFloat(math.Float64frombits(u), 'g', -1, 64)
+			b = b[8:]
+		case maxFlag:
+			value = "MAX"
+		default:
+			return values, false
+		}
+		values = append(values, value)
+		handle = b
+	}
+	return values, true
+}
@@ -112,3 +112,120 @@ func (s *testCodecSuite) TestTiDBInfo(c *C) {
 		c.Assert(indexID, Equals, t.IndexID)
 	}
 }
+
+func (s *testCodecSuite) TestGenerateKey(c *C) {
+	buf := new(KeyInfoBuffer)
+
+	_, err := buf.DecodeKey(GenerateRowKey(0xff, 2))
+	c.Assert(err, IsNil)
+	isMeta, tableID := buf.MetaOrTable()
+	c.Assert(isMeta, IsFalse)
+	c.Assert(tableID, Equals, int64(0xff))
+	isCommonHandle, rowID := buf.RowInfo()
+	c.Assert(isCommonHandle, IsFalse)
+	c.Assert(rowID, Equals, int64(2))
+
+	_, err = buf.DecodeKey(GenerateIndexKey(0xff, 3))
+	c.Assert(err, IsNil)
+	_, tableID = buf.MetaOrTable()
+	c.Assert(tableID, Equals, int64(0xff))
+	c.Assert(buf.IndexInfo(), Equals, int64(3))
+}
+
+func (s *testCodecSuite) TestIndexPrefixInfo(c *C) {
+	buf := new(KeyInfoBuffer)
+
+	testcases := []struct {
+		Key         string
+		IndexID     int64
+		IsTruncated bool
+	}{
+		{"t\x80\x00\x00\x00\x00\x00\x00\xff_i\x80\x00\x00\x00\x00\x00\x00\x03", 3, false},
+		{"t\x80\x00\x00\x00\x00\x00\x00\xff_i\x80\x00\x00\x00\x00\x00\x00\x03\x01a", 3, false},
+		{"t\x80\x00\x00\x00\x00\x00\x00\xff_i\x80\x00\x00\x00\x00\x00\x01", 0x100, true},
+		{"t\x80\x00\x00\x00\x00\x00\x00\xff_r\x80\x00\x00\x00\x00\x00\x00\x03", 0, false},
+	}
+	for _, t := range testcases {
+		_, err := buf.DecodeKey(encodeBytes([]byte(t.Key)))
+		c.Assert(err, IsNil)
+		indexID, isTruncated := buf.IndexPrefixInfo()
+		c.Assert(indexID, Equals, t.IndexID)
+		c.Assert(isTruncated, Equals, t.IsTruncated)
+	}
+}
+
+func (s *testCodecSuite) TestMetaKeyName(c *C) {
+	buf := new(KeyInfoBuffer)
+
+	_, err := buf.DecodeKey(encodeBytes([]byte("m" + string(encodeBytes([]byte("DDLJobList"))) + "\x00\x00\x00\x00\x00\x00\x00l")))
+	c.Assert(err, IsNil)
+	name, ok := buf.MetaKeyName()
+	c.Assert(ok, IsTrue)
+	c.Assert(name, Equals, "DDLJobList")
+
+	// Truncated in the middle of the name.
+	_, err = buf.DecodeKey(encodeBytes([]byte("mDDLJob")))
+	c.Assert(err, IsNil)
+	_, ok = buf.MetaKeyName()
+	c.Assert(ok, IsFalse)
+
+	_, err = buf.DecodeKey(GenerateRowKey(1, 1))
+	c.Assert(err, IsNil)
+	_, ok = buf.MetaKeyName()
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testCodecSuite) TestMetaKeyField(c *C) {
+	buf := new(KeyInfoBuffer)
+
+	_, err := buf.DecodeKey(encodeBytes([]byte("m" + string(encodeBytes([]byte("DB:2"))) + "\x00\x00\x00\x00\x00\x00\x00h" +
+		string(encodeBytes([]byte("TID:10"))))))
+	c.Assert(err, IsNil)
+	field, ok := buf.MetaKeyField()
+	c.Assert(ok, IsTrue)
+	c.Assert(field, Equals, "TID:10")
+
+	// Not a hash.
+	_, err = buf.DecodeKey(encodeBytes([]byte("m" + string(encodeBytes([]byte("DDLJobList"))) + "\x00\x00\x00\x00\x00\x00\x00l")))
+	c.Assert(err, IsNil)
+	_, ok = buf.MetaKeyField()
+	c.Assert(ok, IsFalse)
+
+	// Truncated before the field.
+	_, err = buf.DecodeKey(encodeBytes([]byte("m" + string(encodeBytes([]byte("DB:2"))) + "\x00\x00\x00\x00\x00\x00\x00h")))
+	c.Assert(err, IsNil)
+	_, ok = buf.MetaKeyField()
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testCodecSuite) TestGenerateRawTableKey(c *C) {
+	buf := GenerateRawTableKey(0xff, []byte("_i\x80\x00\x00\x00\x00\x00\x00\x02"))
+	c.Assert(string(buf), Equals, "t\x80\x00\x00\x00\x00\x00\x00\xff_i\x80\x00\x00\x00\x00\x00\x00\x02")
+	_, tableID := buf.MetaOrTable()
+	c.Assert(tableID, Equals, int64(0xff))
+	c.Assert(buf.IndexInfo(), Equals, int64(2))
+}
+
+func (s *testCodecSuite) TestDecodeHandleDatums(c *C) {
+	handle := append([]byte{intFlag}, encodeInt(nil, -3)...)
+	handle = append(handle, bytesFlag)
+	handle = append(handle, encodeBytes([]byte("abcdefghij"))...)
+	buf := GenerateRawTableKey(0xff, append([]byte("_r"), handle...))
+	isCommonHandle, _ := buf.RowInfo()
+	c.Assert(isCommonHandle, IsTrue)
+	got, ok := buf.CommonHandle()
+	c.Assert(ok, IsTrue)
+	c.Assert(got, DeepEquals, handle)
+
+	values, complete := DecodeHandleDatums(handle)
+	c.Assert(complete, IsTrue)
+	c.Assert(values, DeepEquals, []string{"-3", "abcdefghij"})
+
+	// A region split key may end inside a value.
+	values, complete = DecodeHandleDatums(handle[:15])
+	c.Assert(complete, IsFalse)
+	c.Assert(values, DeepEquals, []string{"-3"})
+
+	_, ok = GenerateRawTableKey(0xff, []byte("_i\x80\x00\x00\x00\x00\x00\x00\x02")).CommonHandle()
+	c.Assert(ok, IsFalse)
+}
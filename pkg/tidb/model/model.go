@@ -21,6 +21,29 @@ const (
 	StatePublic
 )
 
+// String implements fmt.Stringer, in the words of TiDB.
+func (s SchemaState) String() string {
+	switch s {
+	case StateDeleteOnly:
+		return "delete only"
+	case StateWriteOnly:
+		return "write only"
+	case StateWriteReorganization:
+		return "write reorganization"
+	case StateDeleteReorganization:
+		return "delete reorganization"
+	case StatePublic:
+		return "public"
+	default:
+		return "none"
+	}
+}
+
+// InTransition reports whether a schema element is in the middle of a DDL, i.e. neither absent nor public.
+func (s SchemaState) InTransition() bool {
+	return s > StateNone && s < StatePublic
+}
+
 // CIStr is case insensitive string.
 type CIStr struct {
 	O string `json:"O"` // Original string.
@@ -38,31 +61,99 @@ type DBInfo struct {
 // It corresponds to the statement `CREATE INDEX Name ON Table (Column);`
 // See https://dev.mysql.com/doc/refman/5.7/en/create-index.html
 type IndexInfo struct {
-	ID   int64 `json:"id"`
-	Name CIStr `json:"idx_name"`
+	ID        int64          `json:"id"`
+	Name      CIStr          `json:"idx_name"`
+	Columns   []*IndexColumn `json:"idx_cols"`
+	IsPrimary bool           `json:"is_primary"`
+	// Global is set for the global indexes of a partitioned table, whose keys are in the key range of the
+	// table instead of the partitions.
+	Global bool `json:"is_global"`
+}
+
+// IndexColumn provides index column info.
+type IndexColumn struct {
+	Name CIStr `json:"name"`
+}
+
+// priKeyFlag is the flag of columns in the primary key, see mysql.PriKeyFlag.
+const priKeyFlag uint = 1 << 1
+
+// FieldType is the column type. Only the fields needed by the dashboard are kept.
+type FieldType struct {
+	Flag uint `json:"Flag"`
+}
+
+// ColumnInfo provides meta data describing of a table column.
+type ColumnInfo struct {
+	ID        int64     `json:"id"`
+	Name      CIStr     `json:"name"`
+	FieldType FieldType `json:"type"`
 }
 
 // PartitionDefinition defines a single partition.
 type PartitionDefinition struct {
 	ID   int64 `json:"id"`
 	Name CIStr `json:"name"`
+	// SubPartitions are the subpartitions of the partition, nested to any depth. TiDB does not report them
+	// yet, in which case the partition is a leaf.
+	SubPartitions []*PartitionDefinition `json:"sub_partitions,omitempty"`
+	// LessThan is the exclusive upper bound of a range partition, one value per partition column. A value is
+	// `MAXVALUE` for no bound.
+	LessThan []string `json:"less_than"`
 }
 
+// PartitionType is the type of the partitioning of a table.
+type PartitionType int
+
+// PartitionTypeRange is the RANGE and RANGE COLUMNS partitioning.
+const PartitionTypeRange PartitionType = 1
+
 // PartitionInfo provides table partition info.
 type PartitionInfo struct {
 	// User may already creates table with partition but table partition is not
 	// yet supported back then. When Enable is true, write/read need use tid
 	// rather than pid.
 	Enable      bool                   `json:"enable"`
+	Type        PartitionType          `json:"type"`
 	Definitions []*PartitionDefinition `json:"definitions"`
+	// AddingDefinitions and DroppingDefinitions are the partitions being added and dropped by a partition DDL
+	// like `REORGANIZE PARTITION`, whose state is DDLState. They are not in Definitions.
+	AddingDefinitions   []*PartitionDefinition `json:"adding_definitions"`
+	DroppingDefinitions []*PartitionDefinition `json:"dropping_definitions"`
+	DDLState            SchemaState            `json:"ddl_state"`
 }
 
 // TableInfo provides meta data describing a DB table.
 type TableInfo struct {
-	ID        int64          `json:"id"`
-	Name      CIStr          `json:"name"`
-	Indices   []*IndexInfo   `json:"index_info"`
-	Partition *PartitionInfo `json:"partition"`
+	ID             int64          `json:"id"`
+	Name           CIStr          `json:"name"`
+	Columns        []*ColumnInfo  `json:"cols"`
+	Indices        []*IndexInfo   `json:"index_info"`
+	Partition      *PartitionInfo `json:"partition"`
+	PKIsHandle     bool           `json:"pk_is_handle"`
+	IsCommonHandle bool           `json:"is_common_handle"`
+	State          SchemaState    `json:"state"`
+	// Sequence is set if the table is a sequence object, e.g. created by `CREATE SEQUENCE`.
+	Sequence *SequenceInfo `json:"sequence"`
+	// TTLInfo is set if the table is created with a `TTL` option, whose expired rows are deleted by TiDB.
+	TTLInfo *TTLInfo `json:"ttl_info"`
+}
+
+// TTLInfo provides meta data describing the TTL of a table.
+type TTLInfo struct {
+	ColumnName      CIStr  `json:"column"`
+	IntervalExprStr string `json:"interval_expr"`
+	// Enable is off after `TTL_ENABLE = 'OFF'`, in which case no rows are deleted.
+	Enable      bool   `json:"enable"`
+	JobInterval string `json:"job_interval"`
+}
+
+// SequenceInfo provides meta data describing a sequence object, which has an ID like a table and stores its
+// cached values in the key range of the ID.
+type SequenceInfo struct {
+	Start     int64 `json:"sequence_start"`
+	Increment int64 `json:"sequence_increment"`
+	Cache     bool  `json:"sequence_cache"`
 }
 
 // GetPartitionInfo returns the partition information.
@@ -72,3 +163,27 @@ func (t *TableInfo) GetPartitionInfo() *PartitionInfo {
 	}
 	return nil
 }
+
+// GetPKColumnNames returns the names of the columns which form the row handle of a clustered table,
+// or nil if the table is not clustered.
+func (t *TableInfo) GetPKColumnNames() []string {
+	var names []string
+	switch {
+	case t.PKIsHandle:
+		for _, col := range t.Columns {
+			if col.FieldType.Flag&priKeyFlag != 0 {
+				names = append(names, col.Name.O)
+			}
+		}
+	case t.IsCommonHandle:
+		for _, index := range t.Indices {
+			if !index.IsPrimary {
+				continue
+			}
+			for _, col := range index.Columns {
+				names = append(names, col.Name.O)
+			}
+		}
+	}
+	return names
+}
@@ -55,6 +55,24 @@ import { ConprofNgMonitoringConfig } from '../models';
 // @ts-ignore
 import { DeadlockModel } from '../models';
 // @ts-ignore
+import { DecoratorLookupSample } from '../models';
+// @ts-ignore
+import { DecoratorPartitionPruning } from '../models';
+// @ts-ignore
+import { DecoratorResolvedTables } from '../models';
+// @ts-ignore
+import { DecoratorSQLCheckReport } from '../models';
+// @ts-ignore
+import { DecoratorSelfTestResult } from '../models';
+// @ts-ignore
+import { DecoratorSnapshotDiff } from '../models';
+// @ts-ignore
+import { DecoratorSupportBundle } from '../models';
+// @ts-ignore
+import { DecoratorTableInfo } from '../models';
+// @ts-ignore
+import { DecoratorTableMapChange } from '../models';
+// @ts-ignore
 import { DiagnoseGenDiagnosisReportRequest } from '../models';
 // @ts-ignore
 import { DiagnoseGenerateMetricsRelationRequest } from '../models';
@@ -75,6 +93,10 @@ import { InfoTableSchema } from '../models';
 // @ts-ignore
 import { InfoWhoAmIResponse } from '../models';
 // @ts-ignore
+import { KeyvisualDecoratorResolveRequest } from '../models';
+// @ts-ignore
+import { KeyvisualDecoratorStatusResponse } from '../models';
+// @ts-ignore
 import { LogsearchCreateTaskGroupRequest } from '../models';
 // @ts-ignore
 import { LogsearchPreviewModel } from '../models';
@@ -1291,16 +1313,482 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
         },
         /**
          * 
-         * @summary Get current alert count from AlertManager
-         * @param {string} address ip:port
+         * @summary Get current alert count from AlertManager
+         * @param {string} address ip:port
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getAlertManagerCounts: async (address: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'address' is not null or undefined
+            assertParamExists('getAlertManagerCounts', 'address', address)
+            const localVarPath = `/topology/alertmanager/{address}/count`
+                .replace(`{${"address"}}`, encodeURIComponent(String(address)));
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get AlertManager instance
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getAlertManagerTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/alertmanager`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get Grafana instance
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getGrafanaTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/grafana`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get all PD instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getPDTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/pd`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * List all profiling tasks with a given group ID
+         * @summary List all tasks with a given group ID
+         * @param {string} groupId group ID
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getProfilingGroupDetail: async (groupId: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'groupId' is not null or undefined
+            assertParamExists('getProfilingGroupDetail', 'groupId', groupId)
+            const localVarPath = `/profiling/group/detail/{groupId}`
+                .replace(`{${"groupId"}}`, encodeURIComponent(String(groupId)));
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * List all profiling groups
+         * @summary List all profiling groups
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getProfilingGroups: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/profiling/group/list`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get all Scheduling instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getSchedulingTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/scheduling`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get location labels of all TiKV / TiFlash instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getStoreLocationTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/store_location`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get all TiKV / TiFlash instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getStoreTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/store`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get all TSO instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getTSOTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/tso`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get all TiCDC instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getTiCDCTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/ticdc`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get all TiDB instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getTiDBTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/tidb`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get all TiProxy instances
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        getTiProxyTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/topology/tiproxy`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary Get information about this TiDB Dashboard
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        infoGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/info/info`;
+            // use dummy base URL string because the URL constructor only accepts absolute URLs.
+            const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
+            let baseOptions;
+            if (configuration) {
+                baseOptions = configuration.baseOptions;
+            }
+
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarHeaderParameter = {} as any;
+            const localVarQueryParameter = {} as any;
+
+            // authentication JwtAuth required
+            await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
+
+
+    
+            setSearchParams(localVarUrlObj, localVarQueryParameter);
+            let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
+            localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+
+            return {
+                url: toPathString(localVarUrlObj),
+                options: localVarRequestOptions,
+            };
+        },
+        /**
+         * 
+         * @summary List all databases
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getAlertManagerCounts: async (address: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            // verify required parameter 'address' is not null or undefined
-            assertParamExists('getAlertManagerCounts', 'address', address)
-            const localVarPath = `/topology/alertmanager/{address}/count`
-                .replace(`{${"address"}}`, encodeURIComponent(String(address)));
+        infoListDatabases: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/info/databases`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1328,12 +1816,13 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
         },
         /**
          * 
-         * @summary Get AlertManager instance
+         * @summary List tables by database name
+         * @param {string} [databaseName] Database name
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getAlertManagerTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/alertmanager`;
+        infoListTables: async (databaseName?: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/info/tables`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1348,6 +1837,10 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             // authentication JwtAuth required
             await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
 
+            if (databaseName !== undefined) {
+                localVarQueryParameter['database_name'] = databaseName;
+            }
+
 
     
             setSearchParams(localVarUrlObj, localVarQueryParameter);
@@ -1361,12 +1854,12 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
         },
         /**
          * 
-         * @summary Get Grafana instance
+         * @summary Get information about current session
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getGrafanaTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/grafana`;
+        infoWhoami: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/info/whoami`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1394,12 +1887,12 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
         },
         /**
          * 
-         * @summary Get all PD instances
+         * @summary Get Key Visual Dynamic Config
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getPDTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/pd`;
+        keyvisualConfigGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/config`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1426,17 +1919,16 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * List all profiling tasks with a given group ID
-         * @summary List all tasks with a given group ID
-         * @param {string} groupId group ID
+         * 
+         * @summary Set Key Visual Dynamic Config
+         * @param {ConfigKeyVisualConfig} request Request body
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getProfilingGroupDetail: async (groupId: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            // verify required parameter 'groupId' is not null or undefined
-            assertParamExists('getProfilingGroupDetail', 'groupId', groupId)
-            const localVarPath = `/profiling/group/detail/{groupId}`
-                .replace(`{${"groupId"}}`, encodeURIComponent(String(groupId)));
+        keyvisualConfigPut: async (request: ConfigKeyVisualConfig, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'request' is not null or undefined
+            assertParamExists('keyvisualConfigPut', 'request', request)
+            const localVarPath = `/keyvisual/config`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1444,7 +1936,7 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
                 baseOptions = configuration.baseOptions;
             }
 
-            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarRequestOptions = { method: 'PUT', ...baseOptions, ...options};
             const localVarHeaderParameter = {} as any;
             const localVarQueryParameter = {} as any;
 
@@ -1453,9 +1945,12 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
 
 
     
+            localVarHeaderParameter['Content-Type'] = 'application/json';
+
             setSearchParams(localVarUrlObj, localVarQueryParameter);
             let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
             localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+            localVarRequestOptions.data = serializeDataIfNeeded(request, localVarRequestOptions, configuration)
 
             return {
                 url: toPathString(localVarUrlObj),
@@ -1463,13 +1958,13 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * List all profiling groups
-         * @summary List all profiling groups
+         * Server-sent events, each named `change` with a decorator.TableMapChange, are pushed after each schema sync changing the tables. A client falling behind is disconnected, and should reload the tables before reconnecting.
+         * @summary Stream the changes of the tables resolved by the key visual label decorator
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getProfilingGroups: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/profiling/group/list`;
+        keyvisualDecoratorChangesGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/changes`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1496,13 +1991,14 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get all Scheduling instances
+         * Only the sampled lookups are counted, see `LookupSampleRate`. The result is empty if sampling is disabled.
+         * @summary Get the tables looked up the most by the key visual label decorator
+         * @param {number} [limit] The number of tables to return, defaults to 10
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getSchedulingTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/scheduling`;
+        keyvisualDecoratorHotTablesGet: async (limit?: number, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/hot_tables`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1517,6 +2013,10 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             // authentication JwtAuth required
             await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
 
+            if (limit !== undefined) {
+                localVarQueryParameter['limit'] = limit;
+            }
+
 
     
             setSearchParams(localVarUrlObj, localVarQueryParameter);
@@ -1529,13 +2029,13 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get location labels of all TiKV / TiFlash instances
+         * No request is sent to TiDB until the syncs are resumed, while the keys are still labeled with the tables resolved so far. A schema sync already running is not interrupted.
+         * @summary Pause the schema syncs of the key visual label decorator
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getStoreLocationTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/store_location`;
+        keyvisualDecoratorPausePost: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/pause`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1543,7 +2043,7 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
                 baseOptions = configuration.baseOptions;
             }
 
-            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarRequestOptions = { method: 'POST', ...baseOptions, ...options};
             const localVarHeaderParameter = {} as any;
             const localVarQueryParameter = {} as any;
 
@@ -1562,13 +2062,16 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get all TiKV / TiFlash instances
+         * For the other services of the dashboard to share the synced tables instead of syncing their own. The IDs of partitions are resolved as well.
+         * @summary Resolve a batch of table IDs by the tables synced by the key visual label decorator
+         * @param {KeyvisualDecoratorResolveRequest} request Request body
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getStoreTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/store`;
+        keyvisualDecoratorResolvePost: async (request: KeyvisualDecoratorResolveRequest, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'request' is not null or undefined
+            assertParamExists('keyvisualDecoratorResolvePost', 'request', request)
+            const localVarPath = `/keyvisual/decorator/resolve`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1576,7 +2079,7 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
                 baseOptions = configuration.baseOptions;
             }
 
-            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarRequestOptions = { method: 'POST', ...baseOptions, ...options};
             const localVarHeaderParameter = {} as any;
             const localVarQueryParameter = {} as any;
 
@@ -1585,9 +2088,12 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
 
 
     
+            localVarHeaderParameter['Content-Type'] = 'application/json';
+
             setSearchParams(localVarUrlObj, localVarQueryParameter);
             let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
             localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+            localVarRequestOptions.data = serializeDataIfNeeded(request, localVarRequestOptions, configuration)
 
             return {
                 url: toPathString(localVarUrlObj),
@@ -1595,13 +2101,13 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get all TSO instances
+         * The next schema sync only fetches the tables again if the schema version has changed since the pause.
+         * @summary Resume the schema syncs of the key visual label decorator paused before
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getTSOTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/tso`;
+        keyvisualDecoratorResumePost: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/resume`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1609,7 +2115,7 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
                 baseOptions = configuration.baseOptions;
             }
 
-            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarRequestOptions = { method: 'POST', ...baseOptions, ...options};
             const localVarHeaderParameter = {} as any;
             const localVarQueryParameter = {} as any;
 
@@ -1628,13 +2134,16 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get all TiCDC instances
+         * A table renamed keeps its ID, while a table replaced, e.g. by TRUNCATE TABLE, is both removed and added.
+         * @summary Diff a snapshot exported earlier against the tables resolved by the key visual label decorator now
+         * @param {string} snapshot The binary snapshot exported earlier
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getTiCDCTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/ticdc`;
+        keyvisualDecoratorSnapshotDiffPost: async (snapshot: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'snapshot' is not null or undefined
+            assertParamExists('keyvisualDecoratorSnapshotDiffPost', 'snapshot', snapshot)
+            const localVarPath = `/keyvisual/decorator/snapshot/diff`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1642,7 +2151,7 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
                 baseOptions = configuration.baseOptions;
             }
 
-            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarRequestOptions = { method: 'POST', ...baseOptions, ...options};
             const localVarHeaderParameter = {} as any;
             const localVarQueryParameter = {} as any;
 
@@ -1651,9 +2160,12 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
 
 
     
+            localVarHeaderParameter['Content-Type'] = 'application/octet-stream';
+
             setSearchParams(localVarUrlObj, localVarQueryParameter);
             let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
             localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
+            localVarRequestOptions.data = serializeDataIfNeeded(snapshot, localVarRequestOptions, configuration)
 
             return {
                 url: toPathString(localVarUrlObj),
@@ -1661,13 +2173,13 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get all TiDB instances
+         * The snapshot can be compared with the tables at a later time by the snapshot diff API.
+         * @summary Export the tables resolved by the key visual label decorator as a binary snapshot
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getTiDBTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/tidb`;
+        keyvisualDecoratorSnapshotGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/snapshot`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1694,13 +2206,13 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get all TiProxy instances
+         * The table IDs and names in `INFORMATION_SCHEMA.TABLES` and `INFORMATION_SCHEMA.PARTITIONS` are compared with the ones resolved from the TiDB status API, which may disagree, e.g. during an upgrade. It is an on-demand diagnostic, which queries all tables. The tables changed by a DDL after the last schema sync show up as discrepancies until the next schema sync.
+         * @summary Check the tables resolved by the key visual label decorator against the tables listed by SQL
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        getTiProxyTopology: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/topology/tiproxy`;
+        keyvisualDecoratorSqlCheckGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/sql_check`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1728,12 +2240,12 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
         },
         /**
          * 
-         * @summary Get information about this TiDB Dashboard
+         * @summary Get the status of the key visual label decorator
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        infoGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/info/info`;
+        keyvisualDecoratorStatusGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/status`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1760,13 +2272,13 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary List all databases
+         * The bundle contains the schema versions, the last sync result, the tunables and all resolved tables. Tokens are never included. The tables are never half updated by a concurrent schema sync: they are all from before or all from after it, unless streaming apply is enabled.
+         * @summary Export the state of the key visual label decorator as a support bundle
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        infoListDatabases: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/info/databases`;
+        keyvisualDecoratorSupportBundleGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            const localVarPath = `/keyvisual/decorator/support_bundle`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1794,13 +2306,15 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
         },
         /**
          * 
-         * @summary List tables by database name
-         * @param {string} [databaseName] Database name
+         * @summary Look up the tables by the label shown in Key Visualizer
+         * @param {string} label The label in the &#x60;db.table&#x60; or &#x60;db.table/partition&#x60; form, prefixed by &#x60;cluster.&#x60; if the decorator is tagged with a cluster
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        infoListTables: async (databaseName?: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/info/tables`;
+        keyvisualDecoratorTablesGet: async (label: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'label' is not null or undefined
+            assertParamExists('keyvisualDecoratorTablesGet', 'label', label)
+            const localVarPath = `/keyvisual/decorator/tables`;
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1815,8 +2329,8 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             // authentication JwtAuth required
             await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
 
-            if (databaseName !== undefined) {
-                localVarQueryParameter['database_name'] = databaseName;
+            if (label !== undefined) {
+                localVarQueryParameter['label'] = label;
             }
 
 
@@ -1831,13 +2345,17 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get information about current session
+         * The table, or partition, is removed with all its partitions. A table still in TiDB is added back by the next schema sync of a new schema version.
+         * @summary Drop a table left behind in the key visual label decorator
+         * @param {number} id The table ID
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        infoWhoami: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/info/whoami`;
+        keyvisualDecoratorTablesIdDelete: async (id: number, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'id' is not null or undefined
+            assertParamExists('keyvisualDecoratorTablesIdDelete', 'id', id)
+            const localVarPath = `/keyvisual/decorator/tables/{id}`
+                .replace(`{${"id"}}`, encodeURIComponent(String(id)));
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1845,7 +2363,7 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
                 baseOptions = configuration.baseOptions;
             }
 
-            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
+            const localVarRequestOptions = { method: 'DELETE', ...baseOptions, ...options};
             const localVarHeaderParameter = {} as any;
             const localVarQueryParameter = {} as any;
 
@@ -1864,13 +2382,18 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Get Key Visual Dynamic Config
+         * The targets, e.g. the partitions left by the partition pruning of a query plan, and their subpartitions are marked as involved, and the other partitions as not involved. The targets which are not partitions of the table are listed as unknown.
+         * @summary Resolve the partitions of a table, marking the ones involved by partition pruning
+         * @param {number} id The ID of the partitioned table, or of one of its partitions
+         * @param {string} [targets] The comma separated IDs of the target partitions
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        keyvisualConfigGet: async (options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            const localVarPath = `/keyvisual/config`;
+        keyvisualDecoratorTablesIdPartitionsGet: async (id: number, targets?: string, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'id' is not null or undefined
+            assertParamExists('keyvisualDecoratorTablesIdPartitionsGet', 'id', id)
+            const localVarPath = `/keyvisual/decorator/tables/{id}/partitions`
+                .replace(`{${"id"}}`, encodeURIComponent(String(id)));
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1885,6 +2408,10 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             // authentication JwtAuth required
             await setApiKeyToObject(localVarHeaderParameter, "Authorization", configuration)
 
+            if (targets !== undefined) {
+                localVarQueryParameter['targets'] = targets;
+            }
+
 
     
             setSearchParams(localVarUrlObj, localVarQueryParameter);
@@ -1897,16 +2424,17 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
             };
         },
         /**
-         * 
-         * @summary Set Key Visual Dynamic Config
-         * @param {ConfigKeyVisualConfig} request Request body
+         * A smoke test of the key decoding and the table lookup of the key visual label decorator which needs no live data, e.g. after a deployment. The result tells whether the synthesized row key of the table is labeled with its `db.table`.
+         * @summary Check that a row key of a table is decoded and labeled with the table
+         * @param {number} id The table ID
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        keyvisualConfigPut: async (request: ConfigKeyVisualConfig, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
-            // verify required parameter 'request' is not null or undefined
-            assertParamExists('keyvisualConfigPut', 'request', request)
-            const localVarPath = `/keyvisual/config`;
+        keyvisualDecoratorTablesIdSelfTestGet: async (id: number, options: AxiosRequestConfig = {}): Promise<RequestArgs> => {
+            // verify required parameter 'id' is not null or undefined
+            assertParamExists('keyvisualDecoratorTablesIdSelfTestGet', 'id', id)
+            const localVarPath = `/keyvisual/decorator/tables/{id}/self_test`
+                .replace(`{${"id"}}`, encodeURIComponent(String(id)));
             // use dummy base URL string because the URL constructor only accepts absolute URLs.
             const localVarUrlObj = new URL(localVarPath, DUMMY_BASE_URL);
             let baseOptions;
@@ -1914,7 +2442,7 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
                 baseOptions = configuration.baseOptions;
             }
 
-            const localVarRequestOptions = { method: 'PUT', ...baseOptions, ...options};
+            const localVarRequestOptions = { method: 'GET', ...baseOptions, ...options};
             const localVarHeaderParameter = {} as any;
             const localVarQueryParameter = {} as any;
 
@@ -1923,12 +2451,9 @@ export const DefaultApiAxiosParamCreator = function (configuration?: Configurati
 
 
     
-            localVarHeaderParameter['Content-Type'] = 'application/json';
-
             setSearchParams(localVarUrlObj, localVarQueryParameter);
             let headersFromBaseOptions = baseOptions && baseOptions.headers ? baseOptions.headers : {};
             localVarRequestOptions.headers = {...localVarHeaderParameter, ...headersFromBaseOptions, ...options.headers};
-            localVarRequestOptions.data = serializeDataIfNeeded(request, localVarRequestOptions, configuration)
 
             return {
                 url: toPathString(localVarUrlObj),
@@ -4615,60 +5140,208 @@ export const DefaultApiFp = function(configuration?: Configuration) {
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        async infoGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<InfoInfoResponse>> {
-            const localVarAxiosArgs = await localVarAxiosParamCreator.infoGet(options);
+        async infoGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<InfoInfoResponse>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.infoGet(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * 
+         * @summary List all databases
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async infoListDatabases(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<Array<string>>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.infoListDatabases(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * 
+         * @summary List tables by database name
+         * @param {string} [databaseName] Database name
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async infoListTables(databaseName?: string, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<Array<InfoTableSchema>>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.infoListTables(databaseName, options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * 
+         * @summary Get information about current session
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async infoWhoami(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<InfoWhoAmIResponse>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.infoWhoami(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * 
+         * @summary Get Key Visual Dynamic Config
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualConfigGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<ConfigKeyVisualConfig>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualConfigGet(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * 
+         * @summary Set Key Visual Dynamic Config
+         * @param {ConfigKeyVisualConfig} request Request body
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualConfigPut(request: ConfigKeyVisualConfig, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<ConfigKeyVisualConfig>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualConfigPut(request, options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * Server-sent events, each named `change` with a decorator.TableMapChange, are pushed after each schema sync changing the tables. A client falling behind is disconnected, and should reload the tables before reconnecting.
+         * @summary Stream the changes of the tables resolved by the key visual label decorator
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorChangesGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<DecoratorTableMapChange>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorChangesGet(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * Only the sampled lookups are counted, see `LookupSampleRate`. The result is empty if sampling is disabled.
+         * @summary Get the tables looked up the most by the key visual label decorator
+         * @param {number} [limit] The number of tables to return, defaults to 10
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorHotTablesGet(limit?: number, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<Array<DecoratorLookupSample>>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorHotTablesGet(limit, options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * No request is sent to TiDB until the syncs are resumed, while the keys are still labeled with the tables resolved so far. A schema sync already running is not interrupted.
+         * @summary Pause the schema syncs of the key visual label decorator
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorPausePost(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<void>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorPausePost(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * For the other services of the dashboard to share the synced tables instead of syncing their own. The IDs of partitions are resolved as well.
+         * @summary Resolve a batch of table IDs by the tables synced by the key visual label decorator
+         * @param {KeyvisualDecoratorResolveRequest} request Request body
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorResolvePost(request: KeyvisualDecoratorResolveRequest, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<DecoratorResolvedTables>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorResolvePost(request, options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * The next schema sync only fetches the tables again if the schema version has changed since the pause.
+         * @summary Resume the schema syncs of the key visual label decorator paused before
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorResumePost(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<void>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorResumePost(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * A table renamed keeps its ID, while a table replaced, e.g. by TRUNCATE TABLE, is both removed and added.
+         * @summary Diff a snapshot exported earlier against the tables resolved by the key visual label decorator now
+         * @param {string} snapshot The binary snapshot exported earlier
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorSnapshotDiffPost(snapshot: string, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<DecoratorSnapshotDiff>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorSnapshotDiffPost(snapshot, options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * The snapshot can be compared with the tables at a later time by the snapshot diff API.
+         * @summary Export the tables resolved by the key visual label decorator as a binary snapshot
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorSnapshotGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<string>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorSnapshotGet(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * The table IDs and names in `INFORMATION_SCHEMA.TABLES` and `INFORMATION_SCHEMA.PARTITIONS` are compared with the ones resolved from the TiDB status API, which may disagree, e.g. during an upgrade. It is an on-demand diagnostic, which queries all tables. The tables changed by a DDL after the last schema sync show up as discrepancies until the next schema sync.
+         * @summary Check the tables resolved by the key visual label decorator against the tables listed by SQL
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorSqlCheckGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<DecoratorSQLCheckReport>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorSqlCheckGet(options);
+            return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
+        },
+        /**
+         * 
+         * @summary Get the status of the key visual label decorator
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        async keyvisualDecoratorStatusGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<KeyvisualDecoratorStatusResponse>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorStatusGet(options);
             return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
         },
         /**
-         * 
-         * @summary List all databases
+         * The bundle contains the schema versions, the last sync result, the tunables and all resolved tables. Tokens are never included. The tables are never half updated by a concurrent schema sync: they are all from before or all from after it, unless streaming apply is enabled.
+         * @summary Export the state of the key visual label decorator as a support bundle
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        async infoListDatabases(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<Array<string>>> {
-            const localVarAxiosArgs = await localVarAxiosParamCreator.infoListDatabases(options);
+        async keyvisualDecoratorSupportBundleGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<DecoratorSupportBundle>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorSupportBundleGet(options);
             return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
         },
         /**
          * 
-         * @summary List tables by database name
-         * @param {string} [databaseName] Database name
+         * @summary Look up the tables by the label shown in Key Visualizer
+         * @param {string} label The label in the &#x60;db.table&#x60; or &#x60;db.table/partition&#x60; form, prefixed by &#x60;cluster.&#x60; if the decorator is tagged with a cluster
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        async infoListTables(databaseName?: string, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<Array<InfoTableSchema>>> {
-            const localVarAxiosArgs = await localVarAxiosParamCreator.infoListTables(databaseName, options);
+        async keyvisualDecoratorTablesGet(label: string, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<Array<DecoratorTableInfo>>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorTablesGet(label, options);
             return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
         },
         /**
-         * 
-         * @summary Get information about current session
+         * The table, or partition, is removed with all its partitions. A table still in TiDB is added back by the next schema sync of a new schema version.
+         * @summary Drop a table left behind in the key visual label decorator
+         * @param {number} id The table ID
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        async infoWhoami(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<InfoWhoAmIResponse>> {
-            const localVarAxiosArgs = await localVarAxiosParamCreator.infoWhoami(options);
+        async keyvisualDecoratorTablesIdDelete(id: number, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<Array<number>>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorTablesIdDelete(id, options);
             return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
         },
         /**
-         * 
-         * @summary Get Key Visual Dynamic Config
+         * The targets, e.g. the partitions left by the partition pruning of a query plan, and their subpartitions are marked as involved, and the other partitions as not involved. The targets which are not partitions of the table are listed as unknown.
+         * @summary Resolve the partitions of a table, marking the ones involved by partition pruning
+         * @param {number} id The ID of the partitioned table, or of one of its partitions
+         * @param {string} [targets] The comma separated IDs of the target partitions
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        async keyvisualConfigGet(options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<ConfigKeyVisualConfig>> {
-            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualConfigGet(options);
+        async keyvisualDecoratorTablesIdPartitionsGet(id: number, targets?: string, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<DecoratorPartitionPruning>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorTablesIdPartitionsGet(id, targets, options);
             return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
         },
         /**
-         * 
-         * @summary Set Key Visual Dynamic Config
-         * @param {ConfigKeyVisualConfig} request Request body
+         * A smoke test of the key decoding and the table lookup of the key visual label decorator which needs no live data, e.g. after a deployment. The result tells whether the synthesized row key of the table is labeled with its `db.table`.
+         * @summary Check that a row key of a table is decoded and labeled with the table
+         * @param {number} id The table ID
          * @param {*} [options] Override http request option.
          * @throws {RequiredError}
          */
-        async keyvisualConfigPut(request: ConfigKeyVisualConfig, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<ConfigKeyVisualConfig>> {
-            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualConfigPut(request, options);
+        async keyvisualDecoratorTablesIdSelfTestGet(id: number, options?: AxiosRequestConfig): Promise<(axios?: AxiosInstance, basePath?: string) => AxiosPromise<DecoratorSelfTestResult>> {
+            const localVarAxiosArgs = await localVarAxiosParamCreator.keyvisualDecoratorTablesIdSelfTestGet(id, options);
             return createRequestFunction(localVarAxiosArgs, globalAxios, BASE_PATH, configuration);
         },
         /**
@@ -5775,6 +6448,140 @@ export const DefaultApiFactory = function (configuration?: Configuration, basePa
         keyvisualConfigPut(request: ConfigKeyVisualConfig, options?: any): AxiosPromise<ConfigKeyVisualConfig> {
             return localVarFp.keyvisualConfigPut(request, options).then((request) => request(axios, basePath));
         },
+        /**
+         * Server-sent events, each named `change` with a decorator.TableMapChange, are pushed after each schema sync changing the tables. A client falling behind is disconnected, and should reload the tables before reconnecting.
+         * @summary Stream the changes of the tables resolved by the key visual label decorator
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorChangesGet(options?: any): AxiosPromise<DecoratorTableMapChange> {
+            return localVarFp.keyvisualDecoratorChangesGet(options).then((request) => request(axios, basePath));
+        },
+        /**
+         * Only the sampled lookups are counted, see `LookupSampleRate`. The result is empty if sampling is disabled.
+         * @summary Get the tables looked up the most by the key visual label decorator
+         * @param {number} [limit] The number of tables to return, defaults to 10
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorHotTablesGet(limit?: number, options?: any): AxiosPromise<Array<DecoratorLookupSample>> {
+            return localVarFp.keyvisualDecoratorHotTablesGet(limit, options).then((request) => request(axios, basePath));
+        },
+        /**
+         * No request is sent to TiDB until the syncs are resumed, while the keys are still labeled with the tables resolved so far. A schema sync already running is not interrupted.
+         * @summary Pause the schema syncs of the key visual label decorator
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorPausePost(options?: any): AxiosPromise<void> {
+            return localVarFp.keyvisualDecoratorPausePost(options).then((request) => request(axios, basePath));
+        },
+        /**
+         * For the other services of the dashboard to share the synced tables instead of syncing their own. The IDs of partitions are resolved as well.
+         * @summary Resolve a batch of table IDs by the tables synced by the key visual label decorator
+         * @param {KeyvisualDecoratorResolveRequest} request Request body
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorResolvePost(request: KeyvisualDecoratorResolveRequest, options?: any): AxiosPromise<DecoratorResolvedTables> {
+            return localVarFp.keyvisualDecoratorResolvePost(request, options).then((request) => request(axios, basePath));
+        },
+        /**
+         * The next schema sync only fetches the tables again if the schema version has changed since the pause.
+         * @summary Resume the schema syncs of the key visual label decorator paused before
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorResumePost(options?: any): AxiosPromise<void> {
+            return localVarFp.keyvisualDecoratorResumePost(options).then((request) => request(axios, basePath));
+        },
+        /**
+         * A table renamed keeps its ID, while a table replaced, e.g. by TRUNCATE TABLE, is both removed and added.
+         * @summary Diff a snapshot exported earlier against the tables resolved by the key visual label decorator now
+         * @param {string} snapshot The binary snapshot exported earlier
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorSnapshotDiffPost(snapshot: string, options?: any): AxiosPromise<DecoratorSnapshotDiff> {
+            return localVarFp.keyvisualDecoratorSnapshotDiffPost(snapshot, options).then((request) => request(axios, basePath));
+        },
+        /**
+         * The snapshot can be compared with the tables at a later time by the snapshot diff API.
+         * @summary Export the tables resolved by the key visual label decorator as a binary snapshot
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorSnapshotGet(options?: any): AxiosPromise<string> {
+            return localVarFp.keyvisualDecoratorSnapshotGet(options).then((request) => request(axios, basePath));
+        },
+        /**
+         * The table IDs and names in `INFORMATION_SCHEMA.TABLES` and `INFORMATION_SCHEMA.PARTITIONS` are compared with the ones resolved from the TiDB status API, which may disagree, e.g. during an upgrade. It is an on-demand diagnostic, which queries all tables. The tables changed by a DDL after the last schema sync show up as discrepancies until the next schema sync.
+         * @summary Check the tables resolved by the key visual label decorator against the tables listed by SQL
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorSqlCheckGet(options?: any): AxiosPromise<DecoratorSQLCheckReport> {
+            return localVarFp.keyvisualDecoratorSqlCheckGet(options).then((request) => request(axios, basePath));
+        },
+        /**
+         * 
+         * @summary Get the status of the key visual label decorator
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorStatusGet(options?: any): AxiosPromise<KeyvisualDecoratorStatusResponse> {
+            return localVarFp.keyvisualDecoratorStatusGet(options).then((request) => request(axios, basePath));
+        },
+        /**
+         * The bundle contains the schema versions, the last sync result, the tunables and all resolved tables. Tokens are never included. The tables are never half updated by a concurrent schema sync: they are all from before or all from after it, unless streaming apply is enabled.
+         * @summary Export the state of the key visual label decorator as a support bundle
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorSupportBundleGet(options?: any): AxiosPromise<DecoratorSupportBundle> {
+            return localVarFp.keyvisualDecoratorSupportBundleGet(options).then((request) => request(axios, basePath));
+        },
+        /**
+         * 
+         * @summary Look up the tables by the label shown in Key Visualizer
+         * @param {string} label The label in the &#x60;db.table&#x60; or &#x60;db.table/partition&#x60; form, prefixed by &#x60;cluster.&#x60; if the decorator is tagged with a cluster
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorTablesGet(label: string, options?: any): AxiosPromise<Array<DecoratorTableInfo>> {
+            return localVarFp.keyvisualDecoratorTablesGet(label, options).then((request) => request(axios, basePath));
+        },
+        /**
+         * The table, or partition, is removed with all its partitions. A table still in TiDB is added back by the next schema sync of a new schema version.
+         * @summary Drop a table left behind in the key visual label decorator
+         * @param {number} id The table ID
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorTablesIdDelete(id: number, options?: any): AxiosPromise<Array<number>> {
+            return localVarFp.keyvisualDecoratorTablesIdDelete(id, options).then((request) => request(axios, basePath));
+        },
+        /**
+         * The targets, e.g. the partitions left by the partition pruning of a query plan, and their subpartitions are marked as involved, and the other partitions as not involved. The targets which are not partitions of the table are listed as unknown.
+         * @summary Resolve the partitions of a table, marking the ones involved by partition pruning
+         * @param {number} id The ID of the partitioned table, or of one of its partitions
+         * @param {string} [targets] The comma separated IDs of the target partitions
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorTablesIdPartitionsGet(id: number, targets?: string, options?: any): AxiosPromise<DecoratorPartitionPruning> {
+            return localVarFp.keyvisualDecoratorTablesIdPartitionsGet(id, targets, options).then((request) => request(axios, basePath));
+        },
+        /**
+         * A smoke test of the key decoding and the table lookup of the key visual label decorator which needs no live data, e.g. after a deployment. The result tells whether the synthesized row key of the table is labeled with its `db.table`.
+         * @summary Check that a row key of a table is decoded and labeled with the table
+         * @param {number} id The table ID
+         * @param {*} [options] Override http request option.
+         * @throws {RequiredError}
+         */
+        keyvisualDecoratorTablesIdSelfTestGet(id: number, options?: any): AxiosPromise<DecoratorSelfTestResult> {
+            return localVarFp.keyvisualDecoratorTablesIdSelfTestGet(id, options).then((request) => request(axios, basePath));
+        },
         /**
          * Heatmaps in a given range to visualize TiKV usage
          * @summary Key Visual Heatmaps
@@ -6732,6 +7539,111 @@ export interface DefaultApiKeyvisualConfigPutRequest {
     readonly request: ConfigKeyVisualConfig
 }
 
+/**
+ * Request parameters for keyvisualDecoratorHotTablesGet operation in DefaultApi.
+ * @export
+ * @interface DefaultApiKeyvisualDecoratorHotTablesGetRequest
+ */
+export interface DefaultApiKeyvisualDecoratorHotTablesGetRequest {
+    /**
+     * The number of tables to return, defaults to 10
+     * @type {number}
+     * @memberof DefaultApiKeyvisualDecoratorHotTablesGet
+     */
+    readonly limit?: number
+}
+
+/**
+ * Request parameters for keyvisualDecoratorResolvePost operation in DefaultApi.
+ * @export
+ * @interface DefaultApiKeyvisualDecoratorResolvePostRequest
+ */
+export interface DefaultApiKeyvisualDecoratorResolvePostRequest {
+    /**
+     * Request body
+     * @type {KeyvisualDecoratorResolveRequest}
+     * @memberof DefaultApiKeyvisualDecoratorResolvePost
+     */
+    readonly request: KeyvisualDecoratorResolveRequest
+}
+
+/**
+ * Request parameters for keyvisualDecoratorSnapshotDiffPost operation in DefaultApi.
+ * @export
+ * @interface DefaultApiKeyvisualDecoratorSnapshotDiffPostRequest
+ */
+export interface DefaultApiKeyvisualDecoratorSnapshotDiffPostRequest {
+    /**
+     * The binary snapshot exported earlier
+     * @type {string}
+     * @memberof DefaultApiKeyvisualDecoratorSnapshotDiffPost
+     */
+    readonly snapshot: string
+}
+
+/**
+ * Request parameters for keyvisualDecoratorTablesGet operation in DefaultApi.
+ * @export
+ * @interface DefaultApiKeyvisualDecoratorTablesGetRequest
+ */
+export interface DefaultApiKeyvisualDecoratorTablesGetRequest {
+    /**
+     * The label in the &#x60;db.table&#x60; or &#x60;db.table/partition&#x60; form, prefixed by &#x60;cluster.&#x60; if the decorator is tagged with a cluster
+     * @type {string}
+     * @memberof DefaultApiKeyvisualDecoratorTablesGet
+     */
+    readonly label: string
+}
+
+/**
+ * Request parameters for keyvisualDecoratorTablesIdDelete operation in DefaultApi.
+ * @export
+ * @interface DefaultApiKeyvisualDecoratorTablesIdDeleteRequest
+ */
+export interface DefaultApiKeyvisualDecoratorTablesIdDeleteRequest {
+    /**
+     * The table ID
+     * @type {number}
+     * @memberof DefaultApiKeyvisualDecoratorTablesIdDelete
+     */
+    readonly id: number
+}
+
+/**
+ * Request parameters for keyvisualDecoratorTablesIdPartitionsGet operation in DefaultApi.
+ * @export
+ * @interface DefaultApiKeyvisualDecoratorTablesIdPartitionsGetRequest
+ */
+export interface DefaultApiKeyvisualDecoratorTablesIdPartitionsGetRequest {
+    /**
+     * The ID of the partitioned table, or of one of its partitions
+     * @type {number}
+     * @memberof DefaultApiKeyvisualDecoratorTablesIdPartitionsGet
+     */
+    readonly id: number
+
+    /**
+     * The comma separated IDs of the target partitions
+     * @type {string}
+     * @memberof DefaultApiKeyvisualDecoratorTablesIdPartitionsGet
+     */
+    readonly targets?: string
+}
+
+/**
+ * Request parameters for keyvisualDecoratorTablesIdSelfTestGet operation in DefaultApi.
+ * @export
+ * @interface DefaultApiKeyvisualDecoratorTablesIdSelfTestGetRequest
+ */
+export interface DefaultApiKeyvisualDecoratorTablesIdSelfTestGetRequest {
+    /**
+     * The table ID
+     * @type {number}
+     * @memberof DefaultApiKeyvisualDecoratorTablesIdSelfTestGet
+     */
+    readonly id: number
+}
+
 /**
  * Request parameters for keyvisualHeatmapsGet operation in DefaultApi.
  * @export
@@ -8164,6 +9076,167 @@ export class DefaultApi extends BaseAPI {
         return DefaultApiFp(this.configuration).keyvisualConfigPut(requestParameters.request, options).then((request) => request(this.axios, this.basePath));
     }
 
+    /**
+     * Server-sent events, each named `change` with a decorator.TableMapChange, are pushed after each schema sync changing the tables. A client falling behind is disconnected, and should reload the tables before reconnecting.
+     * @summary Stream the changes of the tables resolved by the key visual label decorator
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorChangesGet(options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorChangesGet(options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * Only the sampled lookups are counted, see `LookupSampleRate`. The result is empty if sampling is disabled.
+     * @summary Get the tables looked up the most by the key visual label decorator
+     * @param {DefaultApiKeyvisualDecoratorHotTablesGetRequest} requestParameters Request parameters.
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorHotTablesGet(requestParameters: DefaultApiKeyvisualDecoratorHotTablesGetRequest = {}, options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorHotTablesGet(requestParameters.limit, options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * No request is sent to TiDB until the syncs are resumed, while the keys are still labeled with the tables resolved so far. A schema sync already running is not interrupted.
+     * @summary Pause the schema syncs of the key visual label decorator
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorPausePost(options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorPausePost(options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * For the other services of the dashboard to share the synced tables instead of syncing their own. The IDs of partitions are resolved as well.
+     * @summary Resolve a batch of table IDs by the tables synced by the key visual label decorator
+     * @param {DefaultApiKeyvisualDecoratorResolvePostRequest} requestParameters Request parameters.
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorResolvePost(requestParameters: DefaultApiKeyvisualDecoratorResolvePostRequest, options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorResolvePost(requestParameters.request, options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * The next schema sync only fetches the tables again if the schema version has changed since the pause.
+     * @summary Resume the schema syncs of the key visual label decorator paused before
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorResumePost(options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorResumePost(options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * A table renamed keeps its ID, while a table replaced, e.g. by TRUNCATE TABLE, is both removed and added.
+     * @summary Diff a snapshot exported earlier against the tables resolved by the key visual label decorator now
+     * @param {DefaultApiKeyvisualDecoratorSnapshotDiffPostRequest} requestParameters Request parameters.
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorSnapshotDiffPost(requestParameters: DefaultApiKeyvisualDecoratorSnapshotDiffPostRequest, options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorSnapshotDiffPost(requestParameters.snapshot, options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * The snapshot can be compared with the tables at a later time by the snapshot diff API.
+     * @summary Export the tables resolved by the key visual label decorator as a binary snapshot
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorSnapshotGet(options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorSnapshotGet(options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * The table IDs and names in `INFORMATION_SCHEMA.TABLES` and `INFORMATION_SCHEMA.PARTITIONS` are compared with the ones resolved from the TiDB status API, which may disagree, e.g. during an upgrade. It is an on-demand diagnostic, which queries all tables. The tables changed by a DDL after the last schema sync show up as discrepancies until the next schema sync.
+     * @summary Check the tables resolved by the key visual label decorator against the tables listed by SQL
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorSqlCheckGet(options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorSqlCheckGet(options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * 
+     * @summary Get the status of the key visual label decorator
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorStatusGet(options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorStatusGet(options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * The bundle contains the schema versions, the last sync result, the tunables and all resolved tables. Tokens are never included. The tables are never half updated by a concurrent schema sync: they are all from before or all from after it, unless streaming apply is enabled.
+     * @summary Export the state of the key visual label decorator as a support bundle
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorSupportBundleGet(options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorSupportBundleGet(options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * 
+     * @summary Look up the tables by the label shown in Key Visualizer
+     * @param {DefaultApiKeyvisualDecoratorTablesGetRequest} requestParameters Request parameters.
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorTablesGet(requestParameters: DefaultApiKeyvisualDecoratorTablesGetRequest, options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorTablesGet(requestParameters.label, options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * The table, or partition, is removed with all its partitions. A table still in TiDB is added back by the next schema sync of a new schema version.
+     * @summary Drop a table left behind in the key visual label decorator
+     * @param {DefaultApiKeyvisualDecoratorTablesIdDeleteRequest} requestParameters Request parameters.
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorTablesIdDelete(requestParameters: DefaultApiKeyvisualDecoratorTablesIdDeleteRequest, options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorTablesIdDelete(requestParameters.id, options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * The targets, e.g. the partitions left by the partition pruning of a query plan, and their subpartitions are marked as involved, and the other partitions as not involved. The targets which are not partitions of the table are listed as unknown.
+     * @summary Resolve the partitions of a table, marking the ones involved by partition pruning
+     * @param {DefaultApiKeyvisualDecoratorTablesIdPartitionsGetRequest} requestParameters Request parameters.
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorTablesIdPartitionsGet(requestParameters: DefaultApiKeyvisualDecoratorTablesIdPartitionsGetRequest, options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorTablesIdPartitionsGet(requestParameters.id, requestParameters.targets, options).then((request) => request(this.axios, this.basePath));
+    }
+
+    /**
+     * A smoke test of the key decoding and the table lookup of the key visual label decorator which needs no live data, e.g. after a deployment. The result tells whether the synthesized row key of the table is labeled with its `db.table`.
+     * @summary Check that a row key of a table is decoded and labeled with the table
+     * @param {DefaultApiKeyvisualDecoratorTablesIdSelfTestGetRequest} requestParameters Request parameters.
+     * @param {*} [options] Override http request option.
+     * @throws {RequiredError}
+     * @memberof DefaultApi
+     */
+    public keyvisualDecoratorTablesIdSelfTestGet(requestParameters: DefaultApiKeyvisualDecoratorTablesIdSelfTestGetRequest, options?: AxiosRequestConfig) {
+        return DefaultApiFp(this.configuration).keyvisualDecoratorTablesIdSelfTestGet(requestParameters.id, options).then((request) => request(this.axios, this.basePath));
+    }
+
     /**
      * Heatmaps in a given range to visualize TiKV usage
      * @summary Key Visual Heatmaps
@@ -26,6 +26,12 @@ export interface ConfigKeyVisualConfig {
      * @memberof ConfigKeyVisualConfig
      */
     'auto_collection_disabled'?: boolean;
+    /**
+     * LabelStrategy holds the tunables of the label strategy of the db policy, as a JSON object of decorator.LabelStrategyConfig. The fields omitted take their defaults.
+     * @type {object}
+     * @memberof ConfigKeyVisualConfig
+     */
+    'label_strategy'?: object;
     /**
      * 
      * @type {string}
@@ -0,0 +1,372 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorLabelStrategyConfig
+ */
+export interface DecoratorLabelStrategyConfig {
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'binding_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'case_insensitive_lookups'?: boolean;
+    /**
+     * ClockSkewThreshold of zero disables the check of the clock skew.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'clock_skew_threshold'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cluster'?: string;
+    /**
+     * ColdSyncDeadline of zero waits for the cold sync forever.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_deadline'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_retry_interval'?: number;
+    /**
+     * ColdSyncRetryMaxInterval of zero retries the cold sync every ColdSyncRetryInterval.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_retry_max_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_timeout'?: number;
+    /**
+     * ConsistencyCheckSize of zero disables the consistency check.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'consistency_check_size'?: number;
+    /**
+     * DroppedTableGrace of zero removes the dropped tables at once under PruneDroppedTables. The defaults keep them for 10 minutes.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'dropped_table_grace'?: number;
+    /**
+     * DuplicateTableIDs defaults to DuplicateKeepLast if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'duplicate_table_ids'?: string;
+    /**
+     * The tables of the databases with empty names are skipped if EmptyDBName is empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'empty_db_name'?: string;
+    /**
+     * 
+     * @type {{ [key: string]: number; }}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'endpoint_timeouts'?: { [key: string]: number; };
+    /**
+     * EtcdGetTimeout defaults to 1 second if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'etcd_get_timeout'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'group_partitions'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'handle_labels'?: boolean;
+    /**
+     * HiddenTables defaults to HiddenTablesTag if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'hidden_tables'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'hot_tables_first'?: boolean;
+    /**
+     * InconsistentIndexIDs defaults to InconsistentIndexesByName if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'inconsistent_index_ids'?: string;
+    /**
+     * IndexLabelTables are the tables, in the `db.table` form, whose index keys are labeled by index. The index keys of the other tables are labeled `indexes`. All tables are labeled by index if it is empty.
+     * @type {Array<string>}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'index_label_tables'?: Array<string>;
+    /**
+     * LabelCacheSize is the number of region keys whose labels are cached. Defaults to 65536 if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'label_cache_size'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'lazy'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'log_sync_summary'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'lookup_sample_rate'?: number;
+    /**
+     * LookupSampleWindow defaults to 10 minutes if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'lookup_sample_window'?: number;
+    /**
+     * MaxResponseSize defaults to 512 MiB if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'max_response_size'?: number;
+    /**
+     * MaxTableMapEntries of zero leaves TableMap unbounded.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'max_table_map_entries'?: number;
+    /**
+     * MaxVersionStableInterval of zero disables the full syncs of an unchanged schema version.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'max_version_stable_interval'?: number;
+    /**
+     * MemoryBudget of zero leaves the memory of the tables unbounded.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'memory_budget'?: number;
+    /**
+     * OwnerChangeDelay defaults to 5 seconds if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'owner_change_delay'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'partition_bound_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'pk_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'prune_dropped_tables'?: boolean;
+    /**
+     * Redirects defaults to RedirectSameHost if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'redirects'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'region_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'reorg_partitions'?: boolean;
+    /**
+     * RequestTimeout of zero means no bound. EndpointTimeouts are keyed by the endpoint families, e.g. `schema_db`, and default to RequestTimeout.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'request_timeout'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'resource_control_labels'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'resync_miss_threshold'?: number;
+    /**
+     * RetainedVersions of zero retains no past schema version.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'retained_versions'?: number;
+    /**
+     * SchemaPathName defaults to SchemaNameOriginal if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'schema_path_name'?: string;
+    /**
+     * SchemaVersionDebounce defaults to 500 milliseconds if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'schema_version_debounce'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'skip_delete_only_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'skip_partitions'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'stale_revalidate_interval'?: number;
+    /**
+     * StaleRevalidateSize or StaleRevalidateInterval of zero disables the revalidation of stale tables.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'stale_revalidate_size'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'stats_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'streaming_apply'?: boolean;
+    /**
+     * SyncConcurrency defaults to 4 if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_concurrency'?: number;
+    /**
+     * SyncInterval defaults to 1 minute if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_jitter'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_on_ddl_owner_change'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_on_schema_version_change'?: boolean;
+    /**
+     * SyncTimeout of zero means no bound.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_timeout'?: number;
+    /**
+     * TableInfoMetricsLimit of zero disables the table_info metric.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'table_info_metrics_limit'?: number;
+    /**
+     * UnresolvedLabelFormat defaults to `table_%d` if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'unresolved_label_format'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'warmup_concurrency'?: number;
+    /**
+     * WatchKeepAliveInterval of zero disables the probes of the watch.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'watch_keepalive_interval'?: number;
+}
+
@@ -0,0 +1,48 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorLookupSample
+ */
+export interface DecoratorLookupSample {
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLookupSample
+     */
+    'count'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorLookupSample
+     */
+    'db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorLookupSample
+     */
+    'name'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLookupSample
+     */
+    'table_id'?: number;
+}
+
@@ -0,0 +1,44 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorPartitionResolution } from './decorator-partition-resolution';
+import { DecoratorTableInfo } from './decorator-table-info';
+
+/**
+ * 
+ * @export
+ * @interface DecoratorPartitionPruning
+ */
+export interface DecoratorPartitionPruning {
+    /**
+     * Partitions are all partitions of the table, sorted by ID.
+     * @type {Array<DecoratorPartitionResolution>}
+     * @memberof DecoratorPartitionPruning
+     */
+    'partitions'?: Array<DecoratorPartitionResolution>;
+    /**
+     * 
+     * @type {DecoratorTableInfo}
+     * @memberof DecoratorPartitionPruning
+     */
+    'table'?: DecoratorTableInfo;
+    /**
+     * Unknown are the targets which are not partitions of the table, sorted.
+     * @type {Array<number>}
+     * @memberof DecoratorPartitionPruning
+     */
+    'unknown'?: Array<number>;
+}
+
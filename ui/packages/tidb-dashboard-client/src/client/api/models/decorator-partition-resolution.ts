@@ -0,0 +1,96 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorPartitionResolution
+ */
+export interface DecoratorPartitionResolution {
+    /**
+     * Cluster is the TableResolver.Cluster the table is resolved by.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'db'?: string;
+    /**
+     * DDLState is the state of a table in the middle of a DDL, e.g. `write reorganization`, or empty.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'ddl_state'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorPartitionResolution
+     */
+    'id'?: number;
+    /**
+     * Indices maps index IDs to index names.
+     * @type {{ [key: string]: string; }}
+     * @memberof DecoratorPartitionResolution
+     */
+    'indices'?: { [key: string]: string; };
+    /**
+     * Involved is whether the partition is one of the targets, or a subpartition of one of them.
+     * @type {boolean}
+     * @memberof DecoratorPartitionResolution
+     */
+    'involved'?: boolean;
+    /**
+     * Kind is `sequence` for a sequence object, or empty for a table.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'kind'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'name'?: string;
+    /**
+     * ParentID is the ID of the partitioned table of a partition, or 0 for a table.
+     * @type {number}
+     * @memberof DecoratorPartitionResolution
+     */
+    'parent_id'?: number;
+    /**
+     * PartitionBound is the value range of a range partition, e.g. `RANGE[10,20)`, or empty.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'partition_bound'?: string;
+    /**
+     * PartitionPath is the names of a partition and its parent partitions below the table, outermost first, e.g. `[\"p0\", \"sp1\"]` for the subpartition named `t/p0/sp1`. It is empty for a table.
+     * @type {Array<string>}
+     * @memberof DecoratorPartitionResolution
+     */
+    'partition_path'?: Array<string>;
+    /**
+     * TTL is whether the expired rows of the table are deleted by TiDB, see tableDetail.TTL.
+     * @type {boolean}
+     * @memberof DecoratorPartitionResolution
+     */
+    'ttl'?: boolean;
+}
+
@@ -0,0 +1,49 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorTableInfo } from './decorator-table-info';
+
+/**
+ * 
+ * @export
+ * @interface DecoratorResolvedTables
+ */
+export interface DecoratorResolvedTables {
+    /**
+     * Cluster is the TableResolver.Cluster the tables are resolved by.
+     * @type {string}
+     * @memberof DecoratorResolvedTables
+     */
+    'cluster'?: string;
+    /**
+     * Missing are the IDs not found, in the order asked, each once.
+     * @type {Array<number>}
+     * @memberof DecoratorResolvedTables
+     */
+    'missing'?: Array<number>;
+    /**
+     * SchemaVersion is the schema version applied by the last successful sync, or -1 if there is none.
+     * @type {number}
+     * @memberof DecoratorResolvedTables
+     */
+    'schema_version'?: number;
+    /**
+     * Tables are the tables and partitions found, in the order of the IDs asked, each once.
+     * @type {Array<DecoratorTableInfo>}
+     * @memberof DecoratorResolvedTables
+     */
+    'tables'?: Array<DecoratorTableInfo>;
+}
+
@@ -0,0 +1,66 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSelfTestResult
+ */
+export interface DecoratorSelfTestResult {
+    /**
+     * DecodedTableID is the table ID decoded from the key.
+     * @type {number}
+     * @memberof DecoratorSelfTestResult
+     */
+    'decoded_table_id'?: number;
+    /**
+     * Expected is the table in the `db.table` form that the key should be labeled with, or empty if the table is not resolved.
+     * @type {string}
+     * @memberof DecoratorSelfTestResult
+     */
+    'expected'?: string;
+    /**
+     * Key is the synthesized row key, in hex.
+     * @type {string}
+     * @memberof DecoratorSelfTestResult
+     */
+    'key'?: string;
+    /**
+     * Labels are the labels of the key.
+     * @type {Array<string>}
+     * @memberof DecoratorSelfTestResult
+     */
+    'labels'?: Array<string>;
+    /**
+     * OK is whether the key is decoded into the table ID and labeled with the expected table.
+     * @type {boolean}
+     * @memberof DecoratorSelfTestResult
+     */
+    'ok'?: boolean;
+    /**
+     * Reason is why the self test fails, or empty if it is OK.
+     * @type {string}
+     * @memberof DecoratorSelfTestResult
+     */
+    'reason'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSelfTestResult
+     */
+    'table_id'?: number;
+}
+
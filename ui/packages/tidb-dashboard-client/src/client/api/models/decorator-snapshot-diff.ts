@@ -0,0 +1,50 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorSnapshotRename } from './decorator-snapshot-rename';
+import { DecoratorTableInfo } from './decorator-table-info';
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSnapshotDiff
+ */
+export interface DecoratorSnapshotDiff {
+    /**
+     * 
+     * @type {Array<DecoratorTableInfo>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'added'?: Array<DecoratorTableInfo>;
+    /**
+     * Changed are the IDs of the tables and partitions keeping their names but changed otherwise, e.g. in their indexes.
+     * @type {Array<number>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'changed'?: Array<number>;
+    /**
+     * 
+     * @type {Array<DecoratorTableInfo>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'removed'?: Array<DecoratorTableInfo>;
+    /**
+     * Renamed are the tables and partitions keeping their IDs under other names.
+     * @type {Array<DecoratorSnapshotRename>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'renamed'?: Array<DecoratorSnapshotRename>;
+}
+
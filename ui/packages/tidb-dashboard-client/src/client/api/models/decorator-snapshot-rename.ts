@@ -0,0 +1,54 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSnapshotRename
+ */
+export interface DecoratorSnapshotRename {
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSnapshotRename
+     */
+    'id'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'new_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'new_name'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'old_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'old_name'?: string;
+}
+
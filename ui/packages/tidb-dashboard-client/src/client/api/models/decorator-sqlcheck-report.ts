@@ -0,0 +1,49 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorSQLDiscrepancy } from './decorator-sqldiscrepancy';
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSQLCheckReport
+ */
+export interface DecoratorSQLCheckReport {
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'checked_at'?: string;
+    /**
+     * Discrepancies are sorted by ID.
+     * @type {Array<DecoratorSQLDiscrepancy>}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'discrepancies'?: Array<DecoratorSQLDiscrepancy>;
+    /**
+     * SchemaVersion is the schema version of TableMap when checked. A DDL running between the SQL query and the last sync shows up as discrepancies, which go away when checked again after the next sync.
+     * @type {number}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'schema_version'?: number;
+    /**
+     * Tables is the number of tables and partitions listed by SQL which are compared.
+     * @type {number}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'tables'?: number;
+}
+
@@ -0,0 +1,60 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSQLDiscrepancy
+ */
+export interface DecoratorSQLDiscrepancy {
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'id'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'kind'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'map_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'map_name'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'sql_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'sql_name'?: string;
+}
+
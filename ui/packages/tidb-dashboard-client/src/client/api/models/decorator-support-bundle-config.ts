@@ -0,0 +1,354 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSupportBundleConfig
+ */
+export interface DecoratorSupportBundleConfig {
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'case_insensitive_lookups'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'clock_skew_threshold'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_deadline'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_retry_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_retry_max_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_timeout'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'consistency_check_size'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'dropped_table_grace'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'duplicate_table_ids'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'empty_db_name'?: string;
+    /**
+     * 
+     * @type {{ [key: string]: number; }}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'endpoint_timeouts'?: { [key: string]: number; };
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'etcd_get_timeout'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_audit_sink'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_limiter'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_normalize_name'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_priority_hint'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_snapshot_store'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_token_provider'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'hidden_tables'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'hot_tables_first'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'inconsistent_index_ids'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lazy'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'log_sync_summary'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lookup_sample_rate'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lookup_sample_window'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lru_table_map'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'max_response_size'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'max_table_map_entries'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'max_version_stable_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'memory_budget'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'owner_change_delay'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'prune_dropped_tables'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'redirects'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'reorg_partitions'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'request_timeout'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'resync_miss_threshold'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'retained_versions'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'schema_path_name'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'schema_version_debounce'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'skip_delete_only_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'skip_partitions'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'stale_revalidate_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'stale_revalidate_size'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'stats_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'streaming_apply'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_concurrency'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_jitter'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_on_ddl_owner_change'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_on_schema_version_change'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_timeout'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'table_info_metrics_limit'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'warmup_concurrency'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'watch_keepalive_interval'?: number;
+}
+
@@ -0,0 +1,144 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSupportBundleTable
+ */
+export interface DecoratorSupportBundleTable {
+    /**
+     * Cluster is the TableResolver.Cluster the table is resolved by.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'clustered'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'common_handle'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'db'?: string;
+    /**
+     * DDLState is the state of a table in the middle of a DDL, e.g. `write reorganization`, or empty.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'ddl_state'?: string;
+    /**
+     * DroppedAt is when the table was found dropped, see TableResolver.DroppedTableGrace, or zero if it is not.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'dropped_at'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'hidden'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'id'?: number;
+    /**
+     * Indices maps index IDs to index names.
+     * @type {{ [key: string]: string; }}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'indices'?: { [key: string]: string; };
+    /**
+     * Kind is `sequence` for a sequence object, or empty for a table.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'kind'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'name'?: string;
+    /**
+     * ParentID is the ID of the partitioned table of a partition, or 0 for a table.
+     * @type {number}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'parent_id'?: number;
+    /**
+     * PartitionBound is the value range of a range partition, e.g. `RANGE[10,20)`, or empty.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'partition_bound'?: string;
+    /**
+     * PartitionIDs are the skipped partitions of SkipPartitions.
+     * @type {Array<number>}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'partition_ids'?: Array<number>;
+    /**
+     * PartitionPath is the names of a partition and its parent partitions below the table, outermost first, e.g. `[\"p0\", \"sp1\"]` for the subpartition named `t/p0/sp1`. It is empty for a table.
+     * @type {Array<string>}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'partition_path'?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'pk_columns'?: Array<string>;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'raw_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'raw_name'?: string;
+    /**
+     * TTL is whether the expired rows of the table are deleted by TiDB, see tableDetail.TTL.
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'ttl'?: boolean;
+    /**
+     * UpdatedAt is when the table was last fetched from TiDB, or zero if it is preloaded.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'updated_at'?: string;
+}
+
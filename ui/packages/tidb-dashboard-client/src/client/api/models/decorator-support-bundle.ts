@@ -0,0 +1,87 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorSupportBundleConfig } from './decorator-support-bundle-config';
+import { DecoratorSupportBundleTable } from './decorator-support-bundle-table';
+import { DecoratorSyncResult } from './decorator-sync-result';
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSupportBundle
+ */
+export interface DecoratorSupportBundle {
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundle
+     */
+    'collected_at'?: string;
+    /**
+     * 
+     * @type {DecoratorSupportBundleConfig}
+     * @memberof DecoratorSupportBundle
+     */
+    'config'?: DecoratorSupportBundleConfig;
+    /**
+     * Degradation is how far the tables are degraded to stay within TableResolver.MemoryBudget: `none`, `indices`, `partitions` or `eviction`.
+     * @type {string}
+     * @memberof DecoratorSupportBundle
+     */
+    'degradation'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundle
+     */
+    'etcd_error'?: string;
+    /**
+     * EtcdSchemaVersion is the schema version read from etcd while collecting, or -1 if it can not be read.
+     * @type {number}
+     * @memberof DecoratorSupportBundle
+     */
+    'etcd_schema_version'?: number;
+    /**
+     * LastSync is the result of the last sync, or nil if no sync has run.
+     * @type {DecoratorSyncResult}
+     * @memberof DecoratorSupportBundle
+     */
+    'last_sync'?: DecoratorSyncResult;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundle
+     */
+    'last_sync_error'?: string;
+    /**
+     * Partial is whether the tables are served as partial, see TableResolver.Partial.
+     * @type {boolean}
+     * @memberof DecoratorSupportBundle
+     */
+    'partial'?: boolean;
+    /**
+     * SchemaVersion is the schema version applied by the last successful sync, or -1 if there is none.
+     * @type {number}
+     * @memberof DecoratorSupportBundle
+     */
+    'schema_version'?: number;
+    /**
+     * Tables are all tables and partitions in TableMap, sorted by ID.
+     * @type {Array<DecoratorSupportBundleTable>}
+     * @memberof DecoratorSupportBundle
+     */
+    'tables'?: Array<DecoratorSupportBundleTable>;
+}
+
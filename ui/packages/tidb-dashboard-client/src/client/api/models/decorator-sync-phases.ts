@@ -0,0 +1,48 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSyncPhases
+ */
+export interface DecoratorSyncPhases {
+    /**
+     * Apply is applying the fetched tables to TableMap, including waiting for the readers of TableMap.
+     * @type {number}
+     * @memberof DecoratorSyncPhases
+     */
+    'apply'?: number;
+    /**
+     * Etcd is the get of the schema version from etcd.
+     * @type {number}
+     * @memberof DecoratorSyncPhases
+     */
+    'etcd'?: number;
+    /**
+     * Schema is the `/schema` request listing the databases.
+     * @type {number}
+     * @memberof DecoratorSyncPhases
+     */
+    'schema'?: number;
+    /**
+     * SchemaDB is the `/schema/{db}` requests fetching the tables of all databases, which run concurrently. Under StreamingApply, it includes applying the tables as well.
+     * @type {number}
+     * @memberof DecoratorSyncPhases
+     */
+    'schema_db'?: number;
+}
+
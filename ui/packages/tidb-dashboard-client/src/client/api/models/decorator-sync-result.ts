@@ -0,0 +1,91 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorSyncPhases } from './decorator-sync-phases';
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSyncResult
+ */
+export interface DecoratorSyncResult {
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'added'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'changed'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'duration'?: number;
+    /**
+     * ID identifies the sync. All logs of the sync carry it in the `sync-id` field.
+     * @type {string}
+     * @memberof DecoratorSyncResult
+     */
+    'id'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'partitions'?: number;
+    /**
+     * Path tells how the tables are fetched, e.g. \"schema\". It is empty if the sync stops before fetching.
+     * @type {string}
+     * @memberof DecoratorSyncResult
+     */
+    'path'?: string;
+    /**
+     * Phases breaks Duration down by the phases of the sync.
+     * @type {DecoratorSyncPhases}
+     * @memberof DecoratorSyncResult
+     */
+    'phases'?: DecoratorSyncPhases;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'removed'?: number;
+    /**
+     * Resumed is the number of databases not fetched again, as they are fetched by the previous syncs failing half way toward the same version.
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'resumed'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSyncResult
+     */
+    'started_at'?: string;
+    /**
+     * Version is the schema version applied by the sync. It is -1 if nothing is applied, because the version has not changed, TiDB is not ready yet, or the sync failed.
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'version'?: number;
+}
+
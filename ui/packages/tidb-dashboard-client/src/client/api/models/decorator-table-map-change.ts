@@ -0,0 +1,48 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorTableMapChange
+ */
+export interface DecoratorTableMapChange {
+    /**
+     * 
+     * @type {Array<number>}
+     * @memberof DecoratorTableMapChange
+     */
+    'added'?: Array<number>;
+    /**
+     * 
+     * @type {Array<number>}
+     * @memberof DecoratorTableMapChange
+     */
+    'changed'?: Array<number>;
+    /**
+     * Removed are the tables seen by the previous complete sync but not by this one. It is only reported by complete syncs. The removed tables are kept in TableMap, like the labels of their remaining regions.
+     * @type {Array<number>}
+     * @memberof DecoratorTableMapChange
+     */
+    'removed'?: Array<number>;
+    /**
+     * Version is the schema version applied, or -1 if the sync failed half way, in which case the tables updated before the failure are still reported.
+     * @type {number}
+     * @memberof DecoratorTableMapChange
+     */
+    'version'?: number;
+}
+
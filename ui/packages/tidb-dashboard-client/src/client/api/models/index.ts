@@ -22,6 +22,23 @@ export * from './conprof-profile-detail';
 export * from './conprof-target';
 export * from './deadlock-model';
 export * from './decorator-label-key';
+export * from './decorator-label-strategy-config';
+export * from './decorator-lookup-sample';
+export * from './decorator-partition-pruning';
+export * from './decorator-partition-resolution';
+export * from './decorator-resolved-tables';
+export * from './decorator-sqlcheck-report';
+export * from './decorator-sqldiscrepancy';
+export * from './decorator-self-test-result';
+export * from './decorator-snapshot-diff';
+export * from './decorator-snapshot-rename';
+export * from './decorator-support-bundle';
+export * from './decorator-support-bundle-config';
+export * from './decorator-support-bundle-table';
+export * from './decorator-sync-phases';
+export * from './decorator-sync-result';
+export * from './decorator-table-info';
+export * from './decorator-table-map-change';
 export * from './diagnose-gen-diagnosis-report-request';
 export * from './diagnose-generate-metrics-relation-request';
 export * from './diagnose-generate-report-request';
@@ -40,6 +57,9 @@ export * from './hostinfo-partition-info';
 export * from './info-info-response';
 export * from './info-table-schema';
 export * from './info-who-am-iresponse';
+export * from './keyvisual-decorator-resolve-request';
+export * from './keyvisual-decorator-status-response';
+export * from './keyvisual-decorator-sync-record';
 export * from './logsearch-create-task-group-request';
 export * from './logsearch-preview-model';
 export * from './logsearch-search-log-request';
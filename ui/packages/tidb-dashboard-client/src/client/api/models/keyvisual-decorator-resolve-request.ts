@@ -0,0 +1,36 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+
+/**
+ * 
+ * @export
+ * @interface KeyvisualDecoratorResolveRequest
+ */
+export interface KeyvisualDecoratorResolveRequest {
+    /**
+     * Cluster, if not empty, must be the cluster the decorator is tagged with, so that a table ID is never resolved against the tables of another cluster.
+     * @type {string}
+     * @memberof KeyvisualDecoratorResolveRequest
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {Array<number>}
+     * @memberof KeyvisualDecoratorResolveRequest
+     */
+    'ids': Array<number>;
+}
+
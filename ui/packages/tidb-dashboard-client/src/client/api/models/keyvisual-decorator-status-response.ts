@@ -0,0 +1,63 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorLabelStrategyConfig } from './decorator-label-strategy-config';
+import { KeyvisualDecoratorSyncRecord } from './keyvisual-decorator-sync-record';
+import { RestErrorResponse } from './rest-error-response';
+
+/**
+ * 
+ * @export
+ * @interface KeyvisualDecoratorStatusResponse
+ */
+export interface KeyvisualDecoratorStatusResponse {
+    /**
+     * Config is the config the label strategy runs with, or null if it is not configurable.
+     * @type {DecoratorLabelStrategyConfig}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'config'?: DecoratorLabelStrategyConfig;
+    /**
+     * History is the outcomes of the recent schema syncs, the latest first.
+     * @type {Array<KeyvisualDecoratorSyncRecord>}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'history'?: Array<KeyvisualDecoratorSyncRecord>;
+    /**
+     * LastError is the error of the last schema sync, or null if it succeeded.
+     * @type {RestErrorResponse}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'last_error'?: RestErrorResponse;
+    /**
+     * Partial is whether the label strategy is ready with part of the tables only, as the cold sync has missed its deadline. The keys of the tables are labeled `partial` until a schema sync succeeds.
+     * @type {boolean}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'partial'?: boolean;
+    /**
+     * Paused is whether the schema syncs are paused by the pause API.
+     * @type {boolean}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'paused'?: boolean;
+    /**
+     * Ready is whether the cold sync has completed, after which the keys are labeled with the table names. It is always true if the label strategy does not resolve tables.
+     * @type {boolean}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'ready'?: boolean;
+}
+
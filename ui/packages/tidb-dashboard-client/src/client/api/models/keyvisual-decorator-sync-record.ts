@@ -0,0 +1,98 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Dashboard API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 1.0
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+
+import { DecoratorSyncPhases } from './decorator-sync-phases';
+import { RestErrorResponse } from './rest-error-response';
+
+/**
+ * 
+ * @export
+ * @interface KeyvisualDecoratorSyncRecord
+ */
+export interface KeyvisualDecoratorSyncRecord {
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'added'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'changed'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'duration'?: number;
+    /**
+     * Error is the error of the sync, or null if it succeeded.
+     * @type {RestErrorResponse}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'error'?: RestErrorResponse;
+    /**
+     * ID identifies the sync. All logs of the sync carry it in the `sync-id` field.
+     * @type {string}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'id'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'partitions'?: number;
+    /**
+     * Path tells how the tables are fetched, e.g. \"schema\". It is empty if the sync stops before fetching.
+     * @type {string}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'path'?: string;
+    /**
+     * Phases breaks Duration down by the phases of the sync.
+     * @type {DecoratorSyncPhases}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'phases'?: DecoratorSyncPhases;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'removed'?: number;
+    /**
+     * Resumed is the number of databases not fetched again, as they are fetched by the previous syncs failing half way toward the same version.
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'resumed'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'started_at'?: string;
+    /**
+     * Version is the schema version applied by the sync. It is -1 if nothing is applied, because the version has not changed, TiDB is not ready yet, or the sync failed.
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'version'?: number;
+}
+
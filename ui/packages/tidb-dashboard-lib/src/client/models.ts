@@ -178,6 +178,12 @@ export interface ConfigKeyVisualConfig {
      * @memberof ConfigKeyVisualConfig
      */
     'auto_collection_disabled'?: boolean;
+    /**
+     * LabelStrategy holds the tunables of the label strategy of the db policy, as a JSON object of decorator.LabelStrategyConfig. The fields omitted take their defaults.
+     * @type {object}
+     * @memberof ConfigKeyVisualConfig
+     */
+    'label_strategy'?: object;
     /**
      * 
      * @type {string}
@@ -500,16 +506,1544 @@ export interface ConprofContinuousProfilingConfig {
 export interface ConprofEstimateSizeRes {
     /**
      * 
-     * @type {number}
-     * @memberof ConprofEstimateSizeRes
+     * @type {number}
+     * @memberof ConprofEstimateSizeRes
+     */
+    'instance_count'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof ConprofEstimateSizeRes
+     */
+    'profile_size'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface ConprofGroupProfileDetail
+ */
+export interface ConprofGroupProfileDetail {
+    /**
+     * 
+     * @type {number}
+     * @memberof ConprofGroupProfileDetail
+     */
+    'profile_duration_secs'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof ConprofGroupProfileDetail
+     */
+    'state'?: string;
+    /**
+     * 
+     * @type {Array<ConprofProfileDetail>}
+     * @memberof ConprofGroupProfileDetail
+     */
+    'target_profiles'?: Array<ConprofProfileDetail>;
+    /**
+     * 
+     * @type {number}
+     * @memberof ConprofGroupProfileDetail
+     */
+    'ts'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface ConprofGroupProfiles
+ */
+export interface ConprofGroupProfiles {
+    /**
+     * 
+     * @type {ConprofComponentNum}
+     * @memberof ConprofGroupProfiles
+     */
+    'component_num'?: ConprofComponentNum;
+    /**
+     * 
+     * @type {number}
+     * @memberof ConprofGroupProfiles
+     */
+    'profile_duration_secs'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof ConprofGroupProfiles
+     */
+    'state'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof ConprofGroupProfiles
+     */
+    'ts'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface ConprofNgMonitoringConfig
+ */
+export interface ConprofNgMonitoringConfig {
+    /**
+     * 
+     * @type {ConprofContinuousProfilingConfig}
+     * @memberof ConprofNgMonitoringConfig
+     */
+    'continuous_profiling'?: ConprofContinuousProfilingConfig;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface ConprofProfileDetail
+ */
+export interface ConprofProfileDetail {
+    /**
+     * 
+     * @type {string}
+     * @memberof ConprofProfileDetail
+     */
+    'error'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof ConprofProfileDetail
+     */
+    'profile_type'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof ConprofProfileDetail
+     */
+    'state'?: string;
+    /**
+     * 
+     * @type {ConprofTarget}
+     * @memberof ConprofProfileDetail
+     */
+    'target'?: ConprofTarget;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface ConprofTarget
+ */
+export interface ConprofTarget {
+    /**
+     * 
+     * @type {string}
+     * @memberof ConprofTarget
+     */
+    'address'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof ConprofTarget
+     */
+    'component'?: string;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DeadlockModel
+ */
+export interface DeadlockModel {
+    /**
+     * 
+     * @type {string}
+     * @memberof DeadlockModel
+     */
+    'current_sql'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DeadlockModel
+     */
+    'id'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DeadlockModel
+     */
+    'instance'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DeadlockModel
+     */
+    'key'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DeadlockModel
+     */
+    'key_info'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DeadlockModel
+     */
+    'occur_time'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DeadlockModel
+     */
+    'retryable'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DeadlockModel
+     */
+    'trx_holding_lock'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DeadlockModel
+     */
+    'try_lock_trx_id'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorLabelKey
+ */
+export interface DecoratorLabelKey {
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorLabelKey
+     */
+    'key': string;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof DecoratorLabelKey
+     */
+    'labels': Array<string>;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorLabelStrategyConfig
+ */
+export interface DecoratorLabelStrategyConfig {
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'binding_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'case_insensitive_lookups'?: boolean;
+    /**
+     * ClockSkewThreshold of zero disables the check of the clock skew.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'clock_skew_threshold'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cluster'?: string;
+    /**
+     * ColdSyncDeadline of zero waits for the cold sync forever.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_deadline'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_retry_interval'?: number;
+    /**
+     * ColdSyncRetryMaxInterval of zero retries the cold sync every ColdSyncRetryInterval.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_retry_max_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'cold_sync_timeout'?: number;
+    /**
+     * ConsistencyCheckSize of zero disables the consistency check.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'consistency_check_size'?: number;
+    /**
+     * DroppedTableGrace of zero removes the dropped tables at once under PruneDroppedTables. The defaults keep them for 10 minutes.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'dropped_table_grace'?: number;
+    /**
+     * DuplicateTableIDs defaults to DuplicateKeepLast if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'duplicate_table_ids'?: string;
+    /**
+     * The tables of the databases with empty names are skipped if EmptyDBName is empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'empty_db_name'?: string;
+    /**
+     * 
+     * @type {{ [key: string]: number; }}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'endpoint_timeouts'?: { [key: string]: number; };
+    /**
+     * EtcdGetTimeout defaults to 1 second if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'etcd_get_timeout'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'group_partitions'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'handle_labels'?: boolean;
+    /**
+     * HiddenTables defaults to HiddenTablesTag if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'hidden_tables'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'hot_tables_first'?: boolean;
+    /**
+     * InconsistentIndexIDs defaults to InconsistentIndexesByName if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'inconsistent_index_ids'?: string;
+    /**
+     * IndexLabelTables are the tables, in the `db.table` form, whose index keys are labeled by index. The index keys of the other tables are labeled `indexes`. All tables are labeled by index if it is empty.
+     * @type {Array<string>}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'index_label_tables'?: Array<string>;
+    /**
+     * LabelCacheSize is the number of region keys whose labels are cached. Defaults to 65536 if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'label_cache_size'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'lazy'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'log_sync_summary'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'lookup_sample_rate'?: number;
+    /**
+     * LookupSampleWindow defaults to 10 minutes if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'lookup_sample_window'?: number;
+    /**
+     * MaxResponseSize defaults to 512 MiB if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'max_response_size'?: number;
+    /**
+     * MaxTableMapEntries of zero leaves TableMap unbounded.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'max_table_map_entries'?: number;
+    /**
+     * MaxVersionStableInterval of zero disables the full syncs of an unchanged schema version.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'max_version_stable_interval'?: number;
+    /**
+     * MemoryBudget of zero leaves the memory of the tables unbounded.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'memory_budget'?: number;
+    /**
+     * OwnerChangeDelay defaults to 5 seconds if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'owner_change_delay'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'partition_bound_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'pk_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'prune_dropped_tables'?: boolean;
+    /**
+     * Redirects defaults to RedirectSameHost if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'redirects'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'region_labels'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'reorg_partitions'?: boolean;
+    /**
+     * RequestTimeout of zero means no bound. EndpointTimeouts are keyed by the endpoint families, e.g. `schema_db`, and default to RequestTimeout.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'request_timeout'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'resource_control_labels'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'resync_miss_threshold'?: number;
+    /**
+     * RetainedVersions of zero retains no past schema version.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'retained_versions'?: number;
+    /**
+     * SchemaPathName defaults to SchemaNameOriginal if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'schema_path_name'?: string;
+    /**
+     * SchemaVersionDebounce defaults to 500 milliseconds if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'schema_version_debounce'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'skip_delete_only_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'skip_partitions'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'stale_revalidate_interval'?: number;
+    /**
+     * StaleRevalidateSize or StaleRevalidateInterval of zero disables the revalidation of stale tables.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'stale_revalidate_size'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'stats_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'streaming_apply'?: boolean;
+    /**
+     * SyncConcurrency defaults to 4 if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_concurrency'?: number;
+    /**
+     * SyncInterval defaults to 1 minute if zero.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_jitter'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_on_ddl_owner_change'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_on_schema_version_change'?: boolean;
+    /**
+     * SyncTimeout of zero means no bound.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'sync_timeout'?: number;
+    /**
+     * TableInfoMetricsLimit of zero disables the table_info metric.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'table_info_metrics_limit'?: number;
+    /**
+     * UnresolvedLabelFormat defaults to `table_%d` if empty.
+     * @type {string}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'unresolved_label_format'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'warmup_concurrency'?: number;
+    /**
+     * WatchKeepAliveInterval of zero disables the probes of the watch.
+     * @type {number}
+     * @memberof DecoratorLabelStrategyConfig
+     */
+    'watch_keepalive_interval'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorLookupSample
+ */
+export interface DecoratorLookupSample {
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLookupSample
+     */
+    'count'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorLookupSample
+     */
+    'db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorLookupSample
+     */
+    'name'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorLookupSample
+     */
+    'table_id'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorPartitionPruning
+ */
+export interface DecoratorPartitionPruning {
+    /**
+     * Partitions are all partitions of the table, sorted by ID.
+     * @type {Array<DecoratorPartitionResolution>}
+     * @memberof DecoratorPartitionPruning
+     */
+    'partitions'?: Array<DecoratorPartitionResolution>;
+    /**
+     * 
+     * @type {DecoratorTableInfo}
+     * @memberof DecoratorPartitionPruning
+     */
+    'table'?: DecoratorTableInfo;
+    /**
+     * Unknown are the targets which are not partitions of the table, sorted.
+     * @type {Array<number>}
+     * @memberof DecoratorPartitionPruning
+     */
+    'unknown'?: Array<number>;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorPartitionResolution
+ */
+export interface DecoratorPartitionResolution {
+    /**
+     * Cluster is the TableResolver.Cluster the table is resolved by.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'db'?: string;
+    /**
+     * DDLState is the state of a table in the middle of a DDL, e.g. `write reorganization`, or empty.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'ddl_state'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorPartitionResolution
+     */
+    'id'?: number;
+    /**
+     * Indices maps index IDs to index names.
+     * @type {{ [key: string]: string; }}
+     * @memberof DecoratorPartitionResolution
+     */
+    'indices'?: { [key: string]: string; };
+    /**
+     * Involved is whether the partition is one of the targets, or a subpartition of one of them.
+     * @type {boolean}
+     * @memberof DecoratorPartitionResolution
+     */
+    'involved'?: boolean;
+    /**
+     * Kind is `sequence` for a sequence object, or empty for a table.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'kind'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'name'?: string;
+    /**
+     * ParentID is the ID of the partitioned table of a partition, or 0 for a table.
+     * @type {number}
+     * @memberof DecoratorPartitionResolution
+     */
+    'parent_id'?: number;
+    /**
+     * PartitionBound is the value range of a range partition, e.g. `RANGE[10,20)`, or empty.
+     * @type {string}
+     * @memberof DecoratorPartitionResolution
+     */
+    'partition_bound'?: string;
+    /**
+     * PartitionPath is the names of a partition and its parent partitions below the table, outermost first, e.g. `[\"p0\", \"sp1\"]` for the subpartition named `t/p0/sp1`. It is empty for a table.
+     * @type {Array<string>}
+     * @memberof DecoratorPartitionResolution
+     */
+    'partition_path'?: Array<string>;
+    /**
+     * TTL is whether the expired rows of the table are deleted by TiDB, see tableDetail.TTL.
+     * @type {boolean}
+     * @memberof DecoratorPartitionResolution
+     */
+    'ttl'?: boolean;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorResolvedTables
+ */
+export interface DecoratorResolvedTables {
+    /**
+     * Cluster is the TableResolver.Cluster the tables are resolved by.
+     * @type {string}
+     * @memberof DecoratorResolvedTables
+     */
+    'cluster'?: string;
+    /**
+     * Missing are the IDs not found, in the order asked, each once.
+     * @type {Array<number>}
+     * @memberof DecoratorResolvedTables
+     */
+    'missing'?: Array<number>;
+    /**
+     * SchemaVersion is the schema version applied by the last successful sync, or -1 if there is none.
+     * @type {number}
+     * @memberof DecoratorResolvedTables
+     */
+    'schema_version'?: number;
+    /**
+     * Tables are the tables and partitions found, in the order of the IDs asked, each once.
+     * @type {Array<DecoratorTableInfo>}
+     * @memberof DecoratorResolvedTables
+     */
+    'tables'?: Array<DecoratorTableInfo>;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSelfTestResult
+ */
+export interface DecoratorSelfTestResult {
+    /**
+     * DecodedTableID is the table ID decoded from the key.
+     * @type {number}
+     * @memberof DecoratorSelfTestResult
+     */
+    'decoded_table_id'?: number;
+    /**
+     * Expected is the table in the `db.table` form that the key should be labeled with, or empty if the table is not resolved.
+     * @type {string}
+     * @memberof DecoratorSelfTestResult
+     */
+    'expected'?: string;
+    /**
+     * Key is the synthesized row key, in hex.
+     * @type {string}
+     * @memberof DecoratorSelfTestResult
+     */
+    'key'?: string;
+    /**
+     * Labels are the labels of the key.
+     * @type {Array<string>}
+     * @memberof DecoratorSelfTestResult
+     */
+    'labels'?: Array<string>;
+    /**
+     * OK is whether the key is decoded into the table ID and labeled with the expected table.
+     * @type {boolean}
+     * @memberof DecoratorSelfTestResult
+     */
+    'ok'?: boolean;
+    /**
+     * Reason is why the self test fails, or empty if it is OK.
+     * @type {string}
+     * @memberof DecoratorSelfTestResult
+     */
+    'reason'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSelfTestResult
+     */
+    'table_id'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSnapshotDiff
+ */
+export interface DecoratorSnapshotDiff {
+    /**
+     * 
+     * @type {Array<DecoratorTableInfo>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'added'?: Array<DecoratorTableInfo>;
+    /**
+     * Changed are the IDs of the tables and partitions keeping their names but changed otherwise, e.g. in their indexes.
+     * @type {Array<number>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'changed'?: Array<number>;
+    /**
+     * 
+     * @type {Array<DecoratorTableInfo>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'removed'?: Array<DecoratorTableInfo>;
+    /**
+     * Renamed are the tables and partitions keeping their IDs under other names.
+     * @type {Array<DecoratorSnapshotRename>}
+     * @memberof DecoratorSnapshotDiff
+     */
+    'renamed'?: Array<DecoratorSnapshotRename>;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSnapshotRename
+ */
+export interface DecoratorSnapshotRename {
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSnapshotRename
+     */
+    'id'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'new_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'new_name'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'old_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSnapshotRename
+     */
+    'old_name'?: string;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSQLCheckReport
+ */
+export interface DecoratorSQLCheckReport {
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'checked_at'?: string;
+    /**
+     * Discrepancies are sorted by ID.
+     * @type {Array<DecoratorSQLDiscrepancy>}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'discrepancies'?: Array<DecoratorSQLDiscrepancy>;
+    /**
+     * SchemaVersion is the schema version of TableMap when checked. A DDL running between the SQL query and the last sync shows up as discrepancies, which go away when checked again after the next sync.
+     * @type {number}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'schema_version'?: number;
+    /**
+     * Tables is the number of tables and partitions listed by SQL which are compared.
+     * @type {number}
+     * @memberof DecoratorSQLCheckReport
+     */
+    'tables'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSQLDiscrepancy
+ */
+export interface DecoratorSQLDiscrepancy {
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'id'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'kind'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'map_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'map_name'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'sql_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSQLDiscrepancy
+     */
+    'sql_name'?: string;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSupportBundleConfig
+ */
+export interface DecoratorSupportBundleConfig {
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'case_insensitive_lookups'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'clock_skew_threshold'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_deadline'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_retry_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_retry_max_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'cold_sync_timeout'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'consistency_check_size'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'dropped_table_grace'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'duplicate_table_ids'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'empty_db_name'?: string;
+    /**
+     * 
+     * @type {{ [key: string]: number; }}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'endpoint_timeouts'?: { [key: string]: number; };
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'etcd_get_timeout'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_audit_sink'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_limiter'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_normalize_name'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_priority_hint'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_snapshot_store'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'has_token_provider'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'hidden_tables'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'hot_tables_first'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'inconsistent_index_ids'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lazy'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'log_sync_summary'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lookup_sample_rate'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lookup_sample_window'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'lru_table_map'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'max_response_size'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'max_table_map_entries'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'max_version_stable_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'memory_budget'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'owner_change_delay'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'prune_dropped_tables'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'redirects'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'reorg_partitions'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'request_timeout'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'resync_miss_threshold'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'retained_versions'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'schema_path_name'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'schema_version_debounce'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'skip_delete_only_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'skip_partitions'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'stale_revalidate_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'stale_revalidate_size'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'stats_tables'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'streaming_apply'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_concurrency'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_interval'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_jitter'?: number;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_on_ddl_owner_change'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_on_schema_version_change'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'sync_timeout'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'table_info_metrics_limit'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'warmup_concurrency'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleConfig
+     */
+    'watch_keepalive_interval'?: number;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSupportBundleTable
+ */
+export interface DecoratorSupportBundleTable {
+    /**
+     * Cluster is the TableResolver.Cluster the table is resolved by.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'clustered'?: boolean;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'common_handle'?: boolean;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'db'?: string;
+    /**
+     * DDLState is the state of a table in the middle of a DDL, e.g. `write reorganization`, or empty.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'ddl_state'?: string;
+    /**
+     * DroppedAt is when the table was found dropped, see TableResolver.DroppedTableGrace, or zero if it is not.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'dropped_at'?: string;
+    /**
+     * 
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'hidden'?: boolean;
+    /**
+     * 
+     * @type {number}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'id'?: number;
+    /**
+     * Indices maps index IDs to index names.
+     * @type {{ [key: string]: string; }}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'indices'?: { [key: string]: string; };
+    /**
+     * Kind is `sequence` for a sequence object, or empty for a table.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'kind'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'name'?: string;
+    /**
+     * ParentID is the ID of the partitioned table of a partition, or 0 for a table.
+     * @type {number}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'parent_id'?: number;
+    /**
+     * PartitionBound is the value range of a range partition, e.g. `RANGE[10,20)`, or empty.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'partition_bound'?: string;
+    /**
+     * PartitionIDs are the skipped partitions of SkipPartitions.
+     * @type {Array<number>}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'partition_ids'?: Array<number>;
+    /**
+     * PartitionPath is the names of a partition and its parent partitions below the table, outermost first, e.g. `[\"p0\", \"sp1\"]` for the subpartition named `t/p0/sp1`. It is empty for a table.
+     * @type {Array<string>}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'partition_path'?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'pk_columns'?: Array<string>;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'raw_db'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'raw_name'?: string;
+    /**
+     * TTL is whether the expired rows of the table are deleted by TiDB, see tableDetail.TTL.
+     * @type {boolean}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'ttl'?: boolean;
+    /**
+     * UpdatedAt is when the table was last fetched from TiDB, or zero if it is preloaded.
+     * @type {string}
+     * @memberof DecoratorSupportBundleTable
+     */
+    'updated_at'?: string;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface DecoratorSupportBundle
+ */
+export interface DecoratorSupportBundle {
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundle
+     */
+    'collected_at'?: string;
+    /**
+     * 
+     * @type {DecoratorSupportBundleConfig}
+     * @memberof DecoratorSupportBundle
+     */
+    'config'?: DecoratorSupportBundleConfig;
+    /**
+     * Degradation is how far the tables are degraded to stay within TableResolver.MemoryBudget: `none`, `indices`, `partitions` or `eviction`.
+     * @type {string}
+     * @memberof DecoratorSupportBundle
+     */
+    'degradation'?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundle
+     */
+    'etcd_error'?: string;
+    /**
+     * EtcdSchemaVersion is the schema version read from etcd while collecting, or -1 if it can not be read.
+     * @type {number}
+     * @memberof DecoratorSupportBundle
+     */
+    'etcd_schema_version'?: number;
+    /**
+     * LastSync is the result of the last sync, or nil if no sync has run.
+     * @type {DecoratorSyncResult}
+     * @memberof DecoratorSupportBundle
+     */
+    'last_sync'?: DecoratorSyncResult;
+    /**
+     * 
+     * @type {string}
+     * @memberof DecoratorSupportBundle
      */
-    'instance_count'?: number;
+    'last_sync_error'?: string;
     /**
-     * 
+     * Partial is whether the tables are served as partial, see TableResolver.Partial.
+     * @type {boolean}
+     * @memberof DecoratorSupportBundle
+     */
+    'partial'?: boolean;
+    /**
+     * SchemaVersion is the schema version applied by the last successful sync, or -1 if there is none.
      * @type {number}
-     * @memberof ConprofEstimateSizeRes
+     * @memberof DecoratorSupportBundle
      */
-    'profile_size'?: number;
+    'schema_version'?: number;
+    /**
+     * Tables are all tables and partitions in TableMap, sorted by ID.
+     * @type {Array<DecoratorSupportBundleTable>}
+     * @memberof DecoratorSupportBundle
+     */
+    'tables'?: Array<DecoratorSupportBundleTable>;
 }
 
 
@@ -518,33 +2052,33 @@ export interface ConprofEstimateSizeRes {
 /**
  * 
  * @export
- * @interface ConprofGroupProfileDetail
+ * @interface DecoratorSyncPhases
  */
-export interface ConprofGroupProfileDetail {
+export interface DecoratorSyncPhases {
     /**
-     * 
+     * Apply is applying the fetched tables to TableMap, including waiting for the readers of TableMap.
      * @type {number}
-     * @memberof ConprofGroupProfileDetail
+     * @memberof DecoratorSyncPhases
      */
-    'profile_duration_secs'?: number;
+    'apply'?: number;
     /**
-     * 
-     * @type {string}
-     * @memberof ConprofGroupProfileDetail
+     * Etcd is the get of the schema version from etcd.
+     * @type {number}
+     * @memberof DecoratorSyncPhases
      */
-    'state'?: string;
+    'etcd'?: number;
     /**
-     * 
-     * @type {Array<ConprofProfileDetail>}
-     * @memberof ConprofGroupProfileDetail
+     * Schema is the `/schema` request listing the databases.
+     * @type {number}
+     * @memberof DecoratorSyncPhases
      */
-    'target_profiles'?: Array<ConprofProfileDetail>;
+    'schema'?: number;
     /**
-     * 
+     * SchemaDB is the `/schema/{db}` requests fetching the tables of all databases, which run concurrently. Under StreamingApply, it includes applying the tables as well.
      * @type {number}
-     * @memberof ConprofGroupProfileDetail
+     * @memberof DecoratorSyncPhases
      */
-    'ts'?: number;
+    'schema_db'?: number;
 }
 
 
@@ -553,85 +2087,75 @@ export interface ConprofGroupProfileDetail {
 /**
  * 
  * @export
- * @interface ConprofGroupProfiles
+ * @interface DecoratorSyncResult
  */
-export interface ConprofGroupProfiles {
+export interface DecoratorSyncResult {
     /**
      * 
-     * @type {ConprofComponentNum}
-     * @memberof ConprofGroupProfiles
+     * @type {number}
+     * @memberof DecoratorSyncResult
      */
-    'component_num'?: ConprofComponentNum;
+    'added'?: number;
     /**
      * 
      * @type {number}
-     * @memberof ConprofGroupProfiles
+     * @memberof DecoratorSyncResult
      */
-    'profile_duration_secs'?: number;
+    'changed'?: number;
     /**
      * 
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'duration'?: number;
+    /**
+     * ID identifies the sync. All logs of the sync carry it in the `sync-id` field.
      * @type {string}
-     * @memberof ConprofGroupProfiles
+     * @memberof DecoratorSyncResult
      */
-    'state'?: string;
+    'id'?: string;
     /**
      * 
      * @type {number}
-     * @memberof ConprofGroupProfiles
+     * @memberof DecoratorSyncResult
      */
-    'ts'?: number;
-}
-
-
-
-
-/**
- * 
- * @export
- * @interface ConprofNgMonitoringConfig
- */
-export interface ConprofNgMonitoringConfig {
+    'partitions'?: number;
     /**
-     * 
-     * @type {ConprofContinuousProfilingConfig}
-     * @memberof ConprofNgMonitoringConfig
+     * Path tells how the tables are fetched, e.g. \"schema\". It is empty if the sync stops before fetching.
+     * @type {string}
+     * @memberof DecoratorSyncResult
      */
-    'continuous_profiling'?: ConprofContinuousProfilingConfig;
-}
-
-
-
-
-/**
- * 
- * @export
- * @interface ConprofProfileDetail
- */
-export interface ConprofProfileDetail {
+    'path'?: string;
     /**
-     * 
-     * @type {string}
-     * @memberof ConprofProfileDetail
+     * Phases breaks Duration down by the phases of the sync.
+     * @type {DecoratorSyncPhases}
+     * @memberof DecoratorSyncResult
      */
-    'error'?: string;
+    'phases'?: DecoratorSyncPhases;
     /**
      * 
-     * @type {string}
-     * @memberof ConprofProfileDetail
+     * @type {number}
+     * @memberof DecoratorSyncResult
      */
-    'profile_type'?: string;
+    'removed'?: number;
+    /**
+     * Resumed is the number of databases not fetched again, as they are fetched by the previous syncs failing half way toward the same version.
+     * @type {number}
+     * @memberof DecoratorSyncResult
+     */
+    'resumed'?: number;
     /**
      * 
      * @type {string}
-     * @memberof ConprofProfileDetail
+     * @memberof DecoratorSyncResult
      */
-    'state'?: string;
+    'started_at'?: string;
     /**
-     * 
-     * @type {ConprofTarget}
-     * @memberof ConprofProfileDetail
+     * Version is the schema version applied by the sync. It is -1 if nothing is applied, because the version has not changed, TiDB is not ready yet, or the sync failed.
+     * @type {number}
+     * @memberof DecoratorSyncResult
      */
-    'target'?: ConprofTarget;
+    'version'?: number;
 }
 
 
@@ -640,86 +2164,75 @@ export interface ConprofProfileDetail {
 /**
  * 
  * @export
- * @interface ConprofTarget
+ * @interface DecoratorTableInfo
  */
-export interface ConprofTarget {
+export interface DecoratorTableInfo {
     /**
-     * 
+     * Cluster is the TableResolver.Cluster the table is resolved by.
      * @type {string}
-     * @memberof ConprofTarget
+     * @memberof DecoratorTableInfo
      */
-    'address'?: string;
+    'cluster'?: string;
     /**
      * 
      * @type {string}
-     * @memberof ConprofTarget
+     * @memberof DecoratorTableInfo
      */
-    'component'?: string;
-}
-
-
-
-
-/**
- * 
- * @export
- * @interface DeadlockModel
- */
-export interface DeadlockModel {
+    'db'?: string;
     /**
-     * 
+     * DDLState is the state of a table in the middle of a DDL, e.g. `write reorganization`, or empty.
      * @type {string}
-     * @memberof DeadlockModel
+     * @memberof DecoratorTableInfo
      */
-    'current_sql'?: string;
+    'ddl_state'?: string;
     /**
      * 
      * @type {number}
-     * @memberof DeadlockModel
+     * @memberof DecoratorTableInfo
      */
     'id'?: number;
     /**
-     * 
-     * @type {string}
-     * @memberof DeadlockModel
+     * Indices maps index IDs to index names.
+     * @type {{ [key: string]: string; }}
+     * @memberof DecoratorTableInfo
      */
-    'instance'?: string;
+    'indices'?: { [key: string]: string; };
     /**
-     * 
+     * Kind is `sequence` for a sequence object, or empty for a table.
      * @type {string}
-     * @memberof DeadlockModel
+     * @memberof DecoratorTableInfo
      */
-    'key'?: string;
+    'kind'?: string;
     /**
      * 
      * @type {string}
-     * @memberof DeadlockModel
+     * @memberof DecoratorTableInfo
      */
-    'key_info'?: string;
+    'name'?: string;
     /**
-     * 
-     * @type {string}
-     * @memberof DeadlockModel
+     * ParentID is the ID of the partitioned table of a partition, or 0 for a table.
+     * @type {number}
+     * @memberof DecoratorTableInfo
      */
-    'occur_time'?: string;
+    'parent_id'?: number;
     /**
-     * 
-     * @type {boolean}
-     * @memberof DeadlockModel
+     * PartitionBound is the value range of a range partition, e.g. `RANGE[10,20)`, or empty.
+     * @type {string}
+     * @memberof DecoratorTableInfo
      */
-    'retryable'?: boolean;
+    'partition_bound'?: string;
     /**
-     * 
-     * @type {number}
-     * @memberof DeadlockModel
+     * PartitionPath is the names of a partition and its parent partitions below the table, outermost first, e.g. `[\"p0\", \"sp1\"]` for the subpartition named `t/p0/sp1`. It is empty for a table.
+     * @type {Array<string>}
+     * @memberof DecoratorTableInfo
      */
-    'trx_holding_lock'?: number;
+    'partition_path'?: Array<string>;
     /**
-     * 
-     * @type {number}
-     * @memberof DeadlockModel
+     * TTL is whether the expired rows of the table are deleted by TiDB, see tableDetail.TTL.
+     * @type {boolean}
+     * @memberof DecoratorTableInfo
      */
-    'try_lock_trx_id'?: number;
+    'ttl'?: boolean;
 }
 
 
@@ -728,21 +2241,33 @@ export interface DeadlockModel {
 /**
  * 
  * @export
- * @interface DecoratorLabelKey
+ * @interface DecoratorTableMapChange
  */
-export interface DecoratorLabelKey {
+export interface DecoratorTableMapChange {
     /**
      * 
-     * @type {string}
-     * @memberof DecoratorLabelKey
+     * @type {Array<number>}
+     * @memberof DecoratorTableMapChange
      */
-    'key': string;
+    'added'?: Array<number>;
     /**
      * 
-     * @type {Array<string>}
-     * @memberof DecoratorLabelKey
+     * @type {Array<number>}
+     * @memberof DecoratorTableMapChange
      */
-    'labels': Array<string>;
+    'changed'?: Array<number>;
+    /**
+     * Removed are the tables seen by the previous complete sync but not by this one. It is only reported by complete syncs. The removed tables are kept in TableMap, like the labels of their remaining regions.
+     * @type {Array<number>}
+     * @memberof DecoratorTableMapChange
+     */
+    'removed'?: Array<number>;
+    /**
+     * Version is the schema version applied, or -1 if the sync failed half way, in which case the tables updated before the failure are still reported.
+     * @type {number}
+     * @memberof DecoratorTableMapChange
+     */
+    'version'?: number;
 }
 
 
@@ -1360,6 +2885,159 @@ export interface InfoWhoAmIResponse {
 
 
 
+/**
+ * 
+ * @export
+ * @interface KeyvisualDecoratorResolveRequest
+ */
+export interface KeyvisualDecoratorResolveRequest {
+    /**
+     * Cluster, if not empty, must be the cluster the decorator is tagged with, so that a table ID is never resolved against the tables of another cluster.
+     * @type {string}
+     * @memberof KeyvisualDecoratorResolveRequest
+     */
+    'cluster'?: string;
+    /**
+     * 
+     * @type {Array<number>}
+     * @memberof KeyvisualDecoratorResolveRequest
+     */
+    'ids': Array<number>;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface KeyvisualDecoratorStatusResponse
+ */
+export interface KeyvisualDecoratorStatusResponse {
+    /**
+     * Config is the config the label strategy runs with, or null if it is not configurable.
+     * @type {DecoratorLabelStrategyConfig}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'config'?: DecoratorLabelStrategyConfig;
+    /**
+     * History is the outcomes of the recent schema syncs, the latest first.
+     * @type {Array<KeyvisualDecoratorSyncRecord>}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'history'?: Array<KeyvisualDecoratorSyncRecord>;
+    /**
+     * LastError is the error of the last schema sync, or null if it succeeded.
+     * @type {RestErrorResponse}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'last_error'?: RestErrorResponse;
+    /**
+     * Partial is whether the label strategy is ready with part of the tables only, as the cold sync has missed its deadline. The keys of the tables are labeled `partial` until a schema sync succeeds.
+     * @type {boolean}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'partial'?: boolean;
+    /**
+     * Paused is whether the schema syncs are paused by the pause API.
+     * @type {boolean}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'paused'?: boolean;
+    /**
+     * Ready is whether the cold sync has completed, after which the keys are labeled with the table names. It is always true if the label strategy does not resolve tables.
+     * @type {boolean}
+     * @memberof KeyvisualDecoratorStatusResponse
+     */
+    'ready'?: boolean;
+}
+
+
+
+
+/**
+ * 
+ * @export
+ * @interface KeyvisualDecoratorSyncRecord
+ */
+export interface KeyvisualDecoratorSyncRecord {
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'added'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'changed'?: number;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'duration'?: number;
+    /**
+     * Error is the error of the sync, or null if it succeeded.
+     * @type {RestErrorResponse}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'error'?: RestErrorResponse;
+    /**
+     * ID identifies the sync. All logs of the sync carry it in the `sync-id` field.
+     * @type {string}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'id'?: string;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'partitions'?: number;
+    /**
+     * Path tells how the tables are fetched, e.g. \"schema\". It is empty if the sync stops before fetching.
+     * @type {string}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'path'?: string;
+    /**
+     * Phases breaks Duration down by the phases of the sync.
+     * @type {DecoratorSyncPhases}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'phases'?: DecoratorSyncPhases;
+    /**
+     * 
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'removed'?: number;
+    /**
+     * Resumed is the number of databases not fetched again, as they are fetched by the previous syncs failing half way toward the same version.
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'resumed'?: number;
+    /**
+     * 
+     * @type {string}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'started_at'?: string;
+    /**
+     * Version is the schema version applied by the sync. It is -1 if nothing is applied, because the version has not changed, TiDB is not ready yet, or the sync failed.
+     * @type {number}
+     * @memberof KeyvisualDecoratorSyncRecord
+     */
+    'version'?: number;
+}
+
+
+
+
 /**
  * 
  * @export